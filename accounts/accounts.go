@@ -0,0 +1,329 @@
+package accounts
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/storage"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const accountsBucket = "accounts"
+
+// schema migrations of account data, applied when registry is opened
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial account schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+// how often login times and new accounts are written to database
+const flushInterval = time.Minute
+
+// unknown players tracked at once until they're seen often enough to get account.
+// Once full, further unknown players are let in without being tracked.
+const maxCandidates = 10000
+
+// unknown players not seen for this long start over counting hellos
+const candidateTimeout = 10 * time.Minute
+
+var ErrNotFound = errors.New("account not found")
+
+// Account is a player record keyed by XUID
+type Account struct {
+	Xuid      string    `json:"xuid"`
+	Gamertag  string    `json:"gamertag"`
+	CreatedAt time.Time `json:"created_at"`
+	LastLogin time.Time `json:"last_login"`
+	Flags     Flags     `json:"flags"`
+}
+
+// Flags of account that change how servers treat the player
+type Flags struct {
+	Banned    bool   `json:"banned"`
+	BanReason string `json:"ban_reason,omitempty"`
+	Tester    bool   `json:"tester"`
+}
+
+// Registry maps XUIDs to player accounts persisted in storage.
+// All accounts are cached in memory so lookups from UDP servers do not touch the disk.
+// Logins only update the cache, login times and auto registered accounts are written by Flush.
+// Safe for concurrent use.
+type Registry struct {
+	store               storage.Store
+	autoRegister        bool
+	registerAfterHellos int
+	maxRegistrations    int // per flush
+
+	writeMu sync.Mutex // serializes writes to store, taken before mu
+
+	mu         sync.RWMutex
+	cache      map[string]Account
+	persisted  map[string]time.Time  // LastLogin value last written to database
+	candidates map[string]*candidate // unknown players seen, not registered yet
+}
+
+// candidate is unknown player seen by status server. Xuids in hello are not verified,
+// so account is only created for those seen repeatedly, not for every xuid ever sent.
+type candidate struct {
+	firstSeen time.Time
+	lastSeen  time.Time
+	hellos    int
+}
+
+// Open migrates account schema and loads all accounts from store
+func Open(store storage.Store, cfg config.AccountsConfig) (*Registry, error) {
+	if err := storage.Migrate(store, accountsBucket, migrations); err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		store:               store,
+		autoRegister:        cfg.AutoRegister,
+		registerAfterHellos: cfg.RegisterAfterHellos,
+		maxRegistrations:    cfg.MaxRegistrationsPerMinute,
+		cache:               make(map[string]Account),
+		persisted:           make(map[string]time.Time),
+		candidates:          make(map[string]*candidate),
+	}
+
+	err := storage.LoadAllJSON(store, accountsBucket, func(_ string, account Account) {
+		r.cache[account.Xuid] = account
+		r.persisted[account.Xuid] = account.LastLogin
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading accounts: %w", err)
+	}
+
+	return r, nil
+}
+
+// Get returns account of given XUID
+func (r *Registry) Get(xuid string) (Account, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	account, ok := r.cache[xuid]
+	return account, ok
+}
+
+// List returns all accounts ordered by XUID
+func (r *Registry) List() []Account {
+	r.mu.RLock()
+	result := make([]Account, 0, len(r.cache))
+	for _, account := range r.cache {
+		result = append(result, account)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Xuid < result[j].Xuid
+	})
+	return result
+}
+
+// Put creates or replaces account
+func (r *Registry) Put(account Account) error {
+	if account.Xuid == "" {
+		return errors.New("account without xuid")
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.write(account); err != nil {
+		return err
+	}
+	r.cache[account.Xuid] = account
+	delete(r.candidates, account.Xuid)
+	return nil
+}
+
+// Update applies change to existing account and stores the result
+func (r *Registry) Update(xuid string, change func(*Account)) (Account, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.cache[xuid]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	change(&account)
+	account.Xuid = xuid
+
+	if err := r.write(account); err != nil {
+		return Account{}, err
+	}
+	r.cache[xuid] = account
+	return account, nil
+}
+
+// Ban marks player as banned, creating account for player not registered yet
+func (r *Registry) Ban(xuid string, reason string, now time.Time) (Account, error) {
+	if xuid == "" {
+		return Account{}, errors.New("account without xuid")
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.cache[xuid]
+	if !ok {
+		account = Account{Xuid: xuid, CreatedAt: now}
+		if c, tracked := r.candidates[xuid]; tracked {
+			account.CreatedAt, account.LastLogin = c.firstSeen, c.lastSeen
+		}
+	}
+	account.Flags.Banned = true
+	account.Flags.BanReason = reason
+
+	if err := r.write(account); err != nil {
+		return Account{}, err
+	}
+	r.cache[xuid] = account
+	delete(r.candidates, xuid)
+	return account, nil
+}
+
+// Delete removes account
+func (r *Registry) Delete(xuid string) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.cache[xuid]; !ok {
+		return ErrNotFound
+	}
+
+	if err := r.store.Delete(accountsBucket, xuid); err != nil {
+		return fmt.Errorf("deleting account %s: %w", xuid, err)
+	}
+	delete(r.cache, xuid)
+	delete(r.persisted, xuid)
+	return nil
+}
+
+// Login records player login and returns the account. It's called for every hello, so it never touches storage.
+// Unknown players are let in if auto registration is enabled, otherwise ok is false. Empty xuid is never let in.
+// They get an account once seen in enough hellos, created by next Flush.
+func (r *Registry) Login(xuid string, now time.Time) (account Account, ok bool) {
+	if xuid == "" {
+		return Account{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok = r.cache[xuid]
+	if ok {
+		account.LastLogin = now
+		r.cache[xuid] = account
+		return account, true
+	}
+	if !r.autoRegister {
+		return Account{}, false
+	}
+
+	c, tracked := r.candidates[xuid]
+	if !tracked && len(r.candidates) < maxCandidates {
+		c = &candidate{firstSeen: now}
+		r.candidates[xuid] = c
+	}
+	if c == nil {
+		return Account{Xuid: xuid, CreatedAt: now, LastLogin: now}, true
+	}
+	c.lastSeen = now
+	c.hellos++
+	return Account{Xuid: xuid, CreatedAt: c.firstSeen, LastLogin: now}, true
+}
+
+// Flush creates accounts of players seen often enough, at most configured number at once,
+// and writes login times changed since last flush. Returns number of accounts written.
+func (r *Registry) Flush(now time.Time) (int, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.mu.Lock()
+	var pending []Account
+	for xuid, c := range r.candidates {
+		if now.Sub(c.lastSeen) > candidateTimeout {
+			delete(r.candidates, xuid)
+			continue
+		}
+		if c.hellos >= r.registerAfterHellos && len(pending) < r.maxRegistrations {
+			account := Account{Xuid: xuid, CreatedAt: c.firstSeen, LastLogin: c.lastSeen}
+			r.cache[xuid] = account
+			delete(r.candidates, xuid)
+			pending = append(pending, account)
+		}
+	}
+	registered := len(pending)
+	for xuid, account := range r.cache {
+		if slices.ContainsFunc(pending[:registered], func(a Account) bool { return a.Xuid == xuid }) {
+			continue
+		}
+		if persisted, ok := r.persisted[xuid]; !ok || !account.LastLogin.Equal(persisted) {
+			pending = append(pending, account)
+		}
+	}
+	r.mu.Unlock()
+
+	// writes are serialized by writeMu, so accounts can't change other than by login meanwhile
+	var errs []error
+	for _, account := range pending {
+		if err := storage.PutJSON(r.store, accountsBucket, account.Xuid, account); err != nil {
+			errs = append(errs, fmt.Errorf("storing account %s: %w", account.Xuid, err))
+			continue
+		}
+		r.mu.Lock()
+		r.persisted[account.Xuid] = account.LastLogin
+		r.mu.Unlock()
+	}
+	return len(pending) - len(errs), errors.Join(errs...)
+}
+
+// Run flushes accounts periodically until context is cancelled
+func (r *Registry) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := r.Flush(now); err != nil {
+				logging.Warn.Printf("[ACCOUNTS] %v", err)
+			}
+		}
+	}
+}
+
+// IsBanned reports whether XUID belongs to banned account
+func (r *Registry) IsBanned(xuid string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cache[xuid].Flags.Banned
+}
+
+// write persists account. caller has to hold write lock.
+func (r *Registry) write(account Account) error {
+	if err := storage.PutJSON(r.store, accountsBucket, account.Xuid, account); err != nil {
+		return fmt.Errorf("storing account %s: %w", account.Xuid, err)
+	}
+	r.persisted[account.Xuid] = account.LastLogin
+	return nil
+}
@@ -0,0 +1,167 @@
+package accounts
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/storage"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestRegistry(t *testing.T, store storage.Store, autoRegister bool) *Registry {
+	registry, err := Open(store, config.AccountsConfig{AutoRegister: autoRegister, RegisterAfterHellos: 2, MaxRegistrationsPerMinute: 1})
+	if err != nil {
+		t.Fatalf("Failed opening registry: %v", err)
+	}
+	return registry
+}
+
+func TestLoginAutoRegisterAndPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.db")
+	store, err := storage.OpenBoltStore(path)
+	if err != nil {
+		t.Fatalf("Failed opening store: %v", err)
+	}
+	registry := openTestRegistry(t, store, true)
+	now := time.Now().Truncate(time.Second)
+
+	account, ok := registry.Login("00900004EA25063", now)
+	if !ok {
+		t.Fatalf("Expected unknown player to be let in")
+	}
+	if _, err := registry.Update("00900004EA25063", func(a *Account) {}); err != ErrNotFound {
+		t.Errorf("Expected no account after single hello, got %v", err)
+	}
+	account, _ = registry.Login("00900004EA25063", now.Add(time.Second))
+	if !account.CreatedAt.Equal(now) || !account.LastLogin.Equal(now.Add(time.Second)) {
+		t.Errorf("Unexpected timestamps: %v / %v", account.CreatedAt, account.LastLogin)
+	}
+	if written, err := registry.Flush(now.Add(time.Minute)); err != nil || written != 1 {
+		t.Fatalf("Expected account created by flush, got %d (err: %v)", written, err)
+	}
+
+	if _, err := registry.Update("00900004EA25063", func(a *Account) { a.Gamertag = "Hound" }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	store.Close()
+
+	store, err = storage.OpenBoltStore(path)
+	if err != nil {
+		t.Fatalf("Failed reopening store: %v", err)
+	}
+	defer store.Close()
+	reopened := openTestRegistry(t, store, true)
+	account, ok = reopened.Get("00900004EA25063")
+	if !ok {
+		t.Fatalf("Expected account to survive reopening")
+	}
+	if account.Gamertag != "Hound" {
+		t.Errorf("Expected gamertag Hound, got %q", account.Gamertag)
+	}
+}
+
+func TestLoginWithoutAutoRegister(t *testing.T) {
+	registry := openTestRegistry(t, storage.NewMemoryStore(), false)
+
+	if _, ok := registry.Login("UNKNOWN", time.Now()); ok {
+		t.Errorf("Expected unknown account to be denied")
+	}
+	if len(registry.List()) != 0 {
+		t.Errorf("Expected no account to be created")
+	}
+}
+
+func TestBanAndDelete(t *testing.T) {
+	registry := openTestRegistry(t, storage.NewMemoryStore(), false)
+
+	if err := registry.Put(Account{Xuid: "PLAYER1"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if registry.IsBanned("PLAYER1") {
+		t.Errorf("Expected new account not to be banned")
+	}
+
+	registry.Update("PLAYER1", func(a *Account) { a.Flags.Banned = true })
+	if !registry.IsBanned("PLAYER1") {
+		t.Errorf("Expected account to be banned")
+	}
+
+	if err := registry.Delete("PLAYER1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := registry.Delete("PLAYER1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestBanUnknownPlayer(t *testing.T) {
+	registry := openTestRegistry(t, storage.NewMemoryStore(), true)
+	now := time.Now()
+
+	registry.Login("CANDIDATE", now)
+	for _, xuid := range []string{"CANDIDATE", "UNKNOWN"} {
+		account, err := registry.Ban(xuid, "cheating", now.Add(time.Second))
+		if err != nil {
+			t.Fatalf("Ban of %s failed: %v", xuid, err)
+		}
+		if !account.Flags.Banned || account.Flags.BanReason != "cheating" {
+			t.Errorf("Expected %s banned for cheating, got %+v", xuid, account.Flags)
+		}
+		if !registry.IsBanned(xuid) {
+			t.Errorf("Expected %s to be banned", xuid)
+		}
+	}
+	if account, _ := registry.Get("CANDIDATE"); !account.CreatedAt.Equal(now) {
+		t.Errorf("Expected banned candidate to keep first seen time, got %v", account.CreatedAt)
+	}
+	if written, _ := registry.Flush(now.Add(time.Minute)); written != 0 {
+		t.Errorf("Expected banned candidate not registered again, got %d writes", written)
+	}
+}
+
+func TestLoginRejectsEmptyXuid(t *testing.T) {
+	registry := openTestRegistry(t, storage.NewMemoryStore(), true)
+	now := time.Now()
+
+	for range 2 {
+		if _, ok := registry.Login("", now); ok {
+			t.Errorf("Expected empty xuid to be denied")
+		}
+	}
+	if written, err := registry.Flush(now.Add(time.Minute)); err != nil || written != 0 {
+		t.Errorf("Expected nothing to flush, got %d (err: %v)", written, err)
+	}
+}
+
+func TestFlushLimitsRegistrations(t *testing.T) {
+	store := storage.NewMemoryStore()
+	registry := openTestRegistry(t, store, true)
+	now := time.Now()
+
+	registry.Login("ONCE", now)
+	for _, xuid := range []string{"PLAYER1", "PLAYER2"} {
+		registry.Login(xuid, now)
+		registry.Login(xuid, now)
+	}
+	registry.Flush(now)
+	if accounts := registry.List(); len(accounts) != 1 {
+		t.Fatalf("Expected single registration per flush, got %d accounts", len(accounts))
+	}
+	registry.Flush(now)
+	if accounts := registry.List(); len(accounts) != 2 {
+		t.Errorf("Expected second player registered by next flush, got %d accounts", len(accounts))
+	}
+	if _, ok := registry.Get("ONCE"); ok {
+		t.Errorf("Expected player seen once not to be registered")
+	}
+
+	// login times are only written by flush
+	registry.Login("PLAYER1", now.Add(time.Minute))
+	if written, _ := registry.Flush(now.Add(time.Minute)); written != 1 {
+		t.Errorf("Expected changed login time written, got %d writes", written)
+	}
+	reopened := openTestRegistry(t, store, true)
+	if account, _ := reopened.Get("PLAYER1"); !account.LastLogin.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected flushed login time, got %v", account.LastLogin)
+	}
+}
@@ -0,0 +1,113 @@
+package accounts
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RegisterAdminRoutes exposes account management on admin api
+func (r *Registry) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /accounts", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.List())
+	})
+
+	a.HandleFunc("GET /accounts/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		account, ok := r.Get(req.PathValue("xuid"))
+		if !ok {
+			admin.WriteError(w, http.StatusNotFound, ErrNotFound.Error())
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, account)
+	})
+
+	a.HandleFunc("PUT /accounts/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		var account Account
+		if err := json.NewDecoder(req.Body).Decode(&account); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid account: "+err.Error())
+			return
+		}
+		account.Xuid = req.PathValue("xuid")
+		existing, found := r.Get(account.Xuid)
+		if found {
+			account.CreatedAt = existing.CreatedAt
+			account.LastLogin = existing.LastLogin
+		} else {
+			account.CreatedAt = time.Now()
+		}
+
+		if err := r.Put(account); err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.Audit(req, "account.put", account.Xuid, optional(existing, found), account)
+		admin.WriteJSON(w, http.StatusOK, account)
+	})
+
+	a.HandleFunc("DELETE /accounts/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		xuid := req.PathValue("xuid")
+		existing, found := r.Get(xuid)
+		err := r.Delete(xuid)
+		if err == nil {
+			admin.Audit(req, "account.delete", xuid, optional(existing, found), nil)
+		}
+		writeResult(w, http.StatusNoContent, nil, err)
+	})
+
+	a.HandleFunc("GET /bans", func(w http.ResponseWriter, req *http.Request) {
+		banned := []Account{}
+		for _, account := range r.List() {
+			if account.Flags.Banned {
+				banned = append(banned, account)
+			}
+		}
+		admin.WriteJSON(w, http.StatusOK, banned)
+	})
+
+	a.HandleFunc("PUT /bans/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		reason := req.URL.Query().Get("reason")
+		xuid := req.PathValue("xuid")
+		before, found := r.Get(xuid)
+		account, err := r.Ban(xuid, reason, time.Now())
+		if err == nil {
+			admin.Audit(req, "ban.add", xuid, optional(before, found), account)
+		}
+		writeResult(w, http.StatusOK, account, err)
+	})
+
+	a.HandleFunc("DELETE /bans/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		xuid := req.PathValue("xuid")
+		before, _ := r.Get(xuid)
+		account, err := r.Update(xuid, func(a *Account) {
+			a.Flags.Banned = false
+			a.Flags.BanReason = ""
+		})
+		if err == nil {
+			admin.Audit(req, "ban.remove", xuid, before.Flags, account.Flags)
+		}
+		writeResult(w, http.StatusOK, account, err)
+	})
+}
+
+// audited value of account that may not exist
+func optional(account Account, found bool) any {
+	if !found {
+		return nil
+	}
+	return account
+}
+
+func writeResult(w http.ResponseWriter, status int, value any, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		admin.WriteError(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		admin.WriteError(w, http.StatusInternalServerError, err.Error())
+	case value == nil:
+		w.WriteHeader(status)
+	default:
+		admin.WriteJSON(w, status, value)
+	}
+}
@@ -0,0 +1,233 @@
+package admin
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unauthorized requests are counted and logged at most this often, instead of audited one by one
+const unauthorizedLogInterval = time.Minute
+
+// Server is the administrative HTTP API. Subsystems register their own routes on it,
+// everything served is guarded by the configured bearer token.
+type Server struct {
+	mux     *http.ServeMux
+	cfg     config.AdminConfig
+	token   []byte
+	auditor Auditor
+
+	unauthorizedMu     sync.Mutex
+	unauthorized       int // requests rejected since last log
+	unauthorizedLogged time.Time
+}
+
+// ActorHeader names operator doing the request, recorded in audit log.
+// Token is shared, so it is taken on trust from authorized clients.
+const ActorHeader = "X-Admin-Actor"
+
+// AuditRecord describes single administrative action
+type AuditRecord struct {
+	Actor  string
+	Source string // remote address of request, empty for actions done by server itself
+	Action string
+	Target string
+	Before any
+	After  any
+}
+
+// Auditor records administrative actions
+type Auditor interface {
+	Audit(record AuditRecord)
+}
+
+type auditorKey struct{}
+
+// NewServer creates admin api server for given configuration
+func NewServer(cfg config.AdminConfig) *Server {
+	return &Server{
+		mux:   http.NewServeMux(),
+		cfg:   cfg,
+		token: []byte(cfg.Token),
+	}
+}
+
+// Handle registers handler for pattern. Patterns follow http.ServeMux syntax, e.g. "GET /sessions"
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers handler function for pattern
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// SetAuditor makes handlers record their actions with given auditor
+func (s *Server) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// ServeHTTP checks authorization and dispatches request to registered routes
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.traced(w, s.withAuditor(r), func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			s.logUnauthorized(r, time.Now())
+			WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		s.dispatch(w, r)
+	})
+}
+
+// serves request by registered routes. Changes have to be sent as json, which browsers
+// can't do cross-origin without preflight, so web pages can't forge them.
+func (s *Server) dispatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			WriteError(w, http.StatusUnsupportedMediaType, "changes have to be sent as application/json")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// logs rejected request, aggregating those that come in before log interval passes
+func (s *Server) logUnauthorized(r *http.Request, now time.Time) {
+	s.unauthorizedMu.Lock()
+	defer s.unauthorizedMu.Unlock()
+
+	s.unauthorized++
+	if now.Sub(s.unauthorizedLogged) < unauthorizedLogInterval {
+		return
+	}
+	logging.Warn.Printf("[ADMIN] %d unauthorized requests, latest %s %s from %s", s.unauthorized, r.Method, r.URL.Path, r.RemoteAddr)
+	s.unauthorized = 0
+	s.unauthorizedLogged = now
+}
+
+func (s *Server) withAuditor(r *http.Request) *http.Request {
+	if s.auditor == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), auditorKey{}, s.auditor))
+}
+
+// Audit records action done by request. Does nothing when audit log is not in use.
+func Audit(r *http.Request, action string, target string, before any, after any) {
+	auditor, ok := r.Context().Value(auditorKey{}).(Auditor)
+	if !ok {
+		return
+	}
+	auditor.Audit(AuditRecord{
+		Actor:  Actor(r),
+		Source: r.RemoteAddr,
+		Action: action,
+		Target: target,
+		Before: before,
+		After:  after,
+	})
+}
+
+// Actor returns operator named by request, "admin" when it doesn't name any
+func Actor(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get(ActorHeader)); actor != "" {
+		return actor
+	}
+	return "admin"
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.token) == 0 {
+		return false
+	}
+	provided, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), s.token) == 1
+}
+
+// Run serves admin api until context is cancelled
+func (s *Server) Run(ctx context.Context) {
+	httpServer := &http.Server{
+		Addr:              s.cfg.ListenAddress,
+		Handler:           s,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go shutdownOnDone(ctx, httpServer)
+
+	if len(s.token) == 0 {
+		logging.Error.Printf("[ADMIN] no token configured, not serving admin api on %s", s.cfg.ListenAddress)
+		return
+	}
+	logging.Info.Printf("[ADMIN] API listening on %s", s.cfg.ListenAddress)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Error.Printf("[ADMIN] API failed: %v", err)
+	}
+}
+
+// RunSocket serves admin api on unix socket until context is cancelled.
+// Anyone able to connect is authorized, so access is limited by permissions of socket file.
+func (s *Server) RunSocket(ctx context.Context) {
+	path := s.cfg.SocketPath
+	// socket left behind by instance that didn't shut down cleanly
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	mode := os.FileMode(0o600)
+	if s.cfg.SocketGroupAccess {
+		mode = 0o660
+	}
+	listener, err := listenSocket(path, mode)
+	if err != nil {
+		logging.Error.Printf("[ADMIN] control socket failed: %v", err)
+		return
+	}
+	defer os.Remove(path)
+
+	httpServer := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = "unix:" + path
+			s.traced(w, s.withAuditor(r), s.dispatch)
+		}),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go shutdownOnDone(ctx, httpServer)
+
+	logging.Info.Printf("[ADMIN] control socket listening on %s", path)
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Error.Printf("[ADMIN] control socket failed: %v", err)
+	}
+}
+
+func shutdownOnDone(ctx context.Context, httpServer *http.Server) {
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	httpServer.Shutdown(shutdownCtx)
+}
+
+// WriteJSON writes value as json response with given status code
+func WriteJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logging.Warn.Printf("[ADMIN] failed encoding response: %v", err)
+	}
+}
+
+// WriteError writes error message as json response
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,25 @@
+//go:build !unix
+
+package admin
+
+import (
+	"net"
+	"os"
+)
+
+// listens on unix socket at path with given permissions. There's no umask,
+// permissions of socket are only set after it's created.
+// Listener doesn't remove socket when closed.
+func listenSocket(path string, mode os.FileMode) (net.Listener, error) {
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return listener, nil
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package admin
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// listens on unix socket at path with given permissions. Socket is created in private directory
+// next to path, so nobody can connect before its permissions are restricted, and moved in place after.
+// Listener doesn't remove socket when closed.
+func listenSocket(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".admin-sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	private := filepath.Join(dir, "sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: private, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// socket is renamed, so listener can't unlink it by its original name
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(private, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(private, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
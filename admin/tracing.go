@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"ChromehoundsStatusServer/telemetry"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// records status code written by handler, for span of request
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// serves request within span named after matched route, when tracing is enabled
+func (s *Server) traced(w http.ResponseWriter, r *http.Request, serve func(http.ResponseWriter, *http.Request)) {
+	if !telemetry.Tracing() {
+		serve(w, r)
+		return
+	}
+	_, pattern := s.mux.Handler(r)
+	if pattern == "" {
+		pattern = r.Method + " unmatched"
+	}
+	ctx, span := telemetry.Tracer().Start(r.Context(), "admin "+pattern,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("admin.actor", Actor(r)),
+		),
+	)
+	defer span.End()
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	serve(recorder, r.WithContext(ctx))
+	span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+	if recorder.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(recorder.status))
+	}
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RegisterAdminRoutes exposes querying and verification of audit log on admin api
+func (l *Log) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /audit", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := Filter{
+			Actor:  query.Get("actor"),
+			Action: query.Get("action"),
+			Target: query.Get("target"),
+		}
+		var err error
+		if filter.Since, err = parseTime(query.Get("since")); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid since: "+err.Error())
+			return
+		}
+		if filter.Until, err = parseTime(query.Get("until")); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid until: "+err.Error())
+			return
+		}
+		if limit := query.Get("limit"); limit != "" {
+			if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+				admin.WriteError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+		}
+
+		entries, err := l.Query(filter)
+		switch {
+		case errors.Is(err, ErrTampered):
+			admin.WriteError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, entries)
+	})
+
+	a.HandleFunc("GET /audit/verify", func(w http.ResponseWriter, r *http.Request) {
+		checked, err := l.Verify()
+		switch {
+		case errors.Is(err, ErrTampered):
+			admin.WriteJSON(w, http.StatusConflict, map[string]any{"valid": false, "checked": checked, "error": err.Error()})
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.WriteJSON(w, http.StatusOK, map[string]any{"valid": true, "checked": checked})
+		}
+	})
+}
+
+// times are accepted as RFC 3339, empty means no bound
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/logging"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// hash chained to first entry of log
+const genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// longest line accepted when reading log back, before/after values of accounts and war state fit easily
+const maxLineSize = 1 << 20
+
+var ErrTampered = errors.New("audit log chain is broken")
+
+// Entry is single line of audit log. Hash covers all other fields including hash of previous entry.
+type Entry struct {
+	Seq      uint64          `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Actor    string          `json:"actor"`
+	Source   string          `json:"source,omitempty"`
+	Action   string          `json:"action"`
+	Target   string          `json:"target,omitempty"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Filter selects entries of log. Zero fields match everything.
+type Filter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int // keeps only the newest entries
+}
+
+func (f Filter) matches(e Entry) bool {
+	return (f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.Target == "" || e.Target == f.Target) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+// Log appends entries to JSON lines file, chaining each to the previous one. Safe for concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	seq      uint64
+	lastHash string
+}
+
+// Open opens log at path, creating it if needed. Appending continues after last entry,
+// a broken chain is only reported, so that actions keep being recorded.
+// Unterminated last line, left by crash in the middle of write, is dropped first.
+func Open(path string) (*Log, error) {
+	dropped, err := dropPartialLine(path)
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		logging.Error.Printf("[AUDIT] dropped %d bytes of unfinished entry at end of log", dropped)
+	}
+
+	l := &Log{path: path, lastHash: genesisHash}
+	checked, err := l.scan(func(e Entry) {
+		l.seq = e.Seq
+		l.lastHash = e.Hash
+	})
+	if errors.Is(err, ErrTampered) {
+		logging.Error.Printf("[AUDIT] %v after %d entries", err, checked)
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Close closes underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Audit implements admin.Auditor, failures are logged as there's nobody to return them to
+func (l *Log) Audit(record admin.AuditRecord) {
+	if _, err := l.Append(record, time.Now()); err != nil {
+		logging.Error.Printf("[AUDIT] failed recording %s by %s: %v", record.Action, record.Actor, err)
+	}
+}
+
+// Append writes record as new entry and syncs it to disk
+func (l *Log) Append(record admin.AuditRecord, now time.Time) (Entry, error) {
+	entry := Entry{
+		Time:   now.UTC(),
+		Actor:  record.Actor,
+		Source: record.Source,
+		Action: record.Action,
+		Target: record.Target,
+	}
+	var err error
+	if entry.Before, err = marshalValue(record.Before); err != nil {
+		return Entry{}, err
+	}
+	if entry.After, err = marshalValue(record.After); err != nil {
+		return Entry{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	entry.PrevHash = l.lastHash
+	if entry.Hash, err = entry.computeHash(); err != nil {
+		return Entry{}, err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return Entry{}, fmt.Errorf("writing audit log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return Entry{}, fmt.Errorf("syncing audit log: %w", err)
+	}
+	l.seq = entry.Seq
+	l.lastHash = entry.Hash
+	return entry, nil
+}
+
+// Query returns entries matching filter, oldest first. When chain is broken, entries before the break
+// are returned together with ErrTampered, as anything after it can't be trusted.
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []Entry{}
+	_, err := l.scan(func(e Entry) {
+		if filter.matches(e) {
+			entries = append(entries, e)
+		}
+	})
+	if err != nil && !errors.Is(err, ErrTampered) {
+		return nil, err
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, err
+}
+
+// Verify checks whole chain and returns number of entries that passed
+func (l *Log) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.scan(func(Entry) {})
+}
+
+// reads log from start, calling fn for every entry until chain breaks.
+// returns number of valid entries. caller has to hold lock, if log is open.
+func (l *Log) scan(fn func(Entry)) (int, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	previous := Entry{Hash: genesisHash}
+	checked := 0
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return checked, fmt.Errorf("%w: entry after seq %d is not valid json", ErrTampered, previous.Seq)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return checked, err
+		}
+		if entry.Seq != previous.Seq+1 || entry.PrevHash != previous.Hash || entry.Hash != hash {
+			return checked, fmt.Errorf("%w: at seq %d", ErrTampered, entry.Seq)
+		}
+		fn(entry)
+		previous = entry
+		checked++
+	}
+	if err := scanner.Err(); err != nil {
+		return checked, fmt.Errorf("reading audit log: %w", err)
+	}
+	return checked, nil
+}
+
+// truncates file after its last newline, returns number of bytes removed.
+// Missing or empty file is left as is.
+func dropPartialLine(path string) (int64, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	end := size
+	buffer := make([]byte, 4096)
+	for end > 0 {
+		start := max(end-int64(len(buffer)), 0)
+		chunk := buffer[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return 0, fmt.Errorf("reading audit log: %w", err)
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == size {
+		return 0, nil
+	}
+	if err := file.Truncate(end); err != nil {
+		return 0, fmt.Errorf("truncating audit log: %w", err)
+	}
+	return size - end, file.Sync()
+}
+
+func marshalValue(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encoding audited value: %w", err)
+	}
+	// typed nil pointers are as good as no value
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
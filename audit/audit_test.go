@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed opening log: %v", err)
+	}
+	now := time.Now()
+	log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.add", Target: "1", Before: map[string]bool{"banned": false}, After: map[string]bool{"banned": true}}, now)
+	log.Append(admin.AuditRecord{Actor: "bob", Action: "maintenance.schedule", Target: "m1"}, now.Add(time.Minute))
+	log.Close()
+
+	log, err = Open(path)
+	if err != nil {
+		t.Fatalf("Failed reopening log: %v", err)
+	}
+	defer log.Close()
+	entry, _ := log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.remove", Target: "1"}, now.Add(2*time.Minute))
+	if entry.Seq != 3 {
+		t.Errorf("Expected chain to continue with seq 3, got %d", entry.Seq)
+	}
+	if checked, err := log.Verify(); err != nil || checked != 3 {
+		t.Errorf("Expected 3 valid entries, got %d %v", checked, err)
+	}
+
+	entries, _ := log.Query(Filter{Actor: "alice"})
+	if len(entries) != 2 || string(entries[0].After) != `{"banned":true}` {
+		t.Errorf("Expected both entries of alice with after value, got %v", entries)
+	}
+	entries, _ = log.Query(Filter{Since: now.Add(time.Minute), Limit: 1})
+	if len(entries) != 1 || entries[0].Action != "ban.remove" {
+		t.Errorf("Expected newest entry only, got %v", entries)
+	}
+}
+
+func TestTamperDetection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, _ := Open(path)
+	log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.add", Target: "1"}, time.Now())
+	log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.add", Target: "2"}, time.Now())
+	log.Close()
+
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"target":"2"`, `"target":"3"`, 1)), 0o600)
+
+	log, _ = Open(path)
+	defer log.Close()
+	if checked, err := log.Verify(); !errors.Is(err, ErrTampered) || checked != 1 {
+		t.Errorf("Expected edited second entry detected, got %d %v", checked, err)
+	}
+	if entries, err := log.Query(Filter{}); !errors.Is(err, ErrTampered) || len(entries) != 1 {
+		t.Errorf("Expected query to report broken chain after first entry, got %d %v", len(entries), err)
+	}
+}
+
+func TestPartialLineDroppedOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, _ := Open(path)
+	log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.add", Target: "1"}, time.Now())
+	log.Close()
+
+	// crash in the middle of writing second entry
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	file.WriteString(`{"seq":2,"time":"20`)
+	file.Close()
+
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed reopening log: %v", err)
+	}
+	defer log.Close()
+	if entry, _ := log.Append(admin.AuditRecord{Actor: "alice", Action: "ban.remove", Target: "1"}, time.Now()); entry.Seq != 2 {
+		t.Errorf("Expected chain to continue with seq 2, got %d", entry.Seq)
+	}
+	if checked, err := log.Verify(); err != nil || checked != 2 {
+		t.Errorf("Expected 2 valid entries, got %d %v", checked, err)
+	}
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"ChromehoundsStatusServer/config"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: open-combas-server [command] [flags]
+
+commands:
+  serve                          run the server (default)
+  check-config                   validate config file
+  gen-config [-force]            write default config file
+  status                         show state of running server
+  maintenance schedule           schedule maintenance window
+  maintenance cancel <id>        cancel maintenance window
+  ban add <xuid> [-reason text]  ban player
+  ban remove <xuid>              lift ban of player
+  ban list                       list banned players
+  sessions                       dump active sessions
+  reload                         reload config of running server
+  verbose [<label> on|off]       show or toggle verbose logging of server
+  profile <kind> [-seconds n]    capture cpu, heap, goroutine or trace profile on server
+
+every command accepts -config <path>. Commands talking to running server use control socket
+when it exists, otherwise admin api. -socket <path>, or -admin <address> with -token <token>,
+override settings of config.
+`
+
+// ErrUsage is returned for invalid command lines, usage is printed already
+var ErrUsage = errors.New("invalid usage")
+
+// command run with remaining arguments
+type command func(args []string, stdout io.Writer, stderr io.Writer) error
+
+var commands = map[string]command{
+	"check-config": checkConfig,
+	"gen-config":   genConfig,
+	"status":       statusCommand,
+	"maintenance":  maintenanceCommand,
+	"ban":          banCommand,
+	"sessions":     sessionsCommand,
+	"reload":       reloadCommand,
+	"verbose":      verboseCommand,
+	"profile":      profileCommand,
+}
+
+// Run executes command given by arguments, except serve which is handled by main. Returns process exit code.
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	if err := cmd(args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, ErrUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parses flags that may be mixed with positional arguments, returning the positional ones
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, ErrUsage
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", config.DefaultPath, "path of config file")
+	return flags, configPath
+}
+
+func checkConfig(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, configPath := newFlagSet("check-config", stderr)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	cfg, warnings, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(stdout, "warning: %s\n", warning)
+	}
+	enabled := 0
+	for _, server := range cfg.Servers {
+		if server.Enabled {
+			enabled++
+		}
+	}
+	if len(warnings) > 0 {
+		return fmt.Errorf("%s needs fixing", *configPath)
+	}
+	fmt.Fprintf(stdout, "%s is valid, %d of %d servers enabled\n", *configPath, enabled, len(cfg.Servers))
+	return nil
+}
+
+func genConfig(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, configPath := newFlagSet("gen-config", stderr)
+	force := flags.Bool("force", false, "overwrite existing file")
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	if err := config.WriteDefault(*configPath, *force); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", *configPath)
+		}
+		return err
+	}
+	fmt.Fprintf(stdout, "default config written to %s\n", *configPath)
+	return nil
+}
+
+// flags of commands talking to running server
+type remoteFlags struct {
+	configPath *string
+	address    *string
+	token      *string
+	socket     *string
+}
+
+func newRemoteFlagSet(name string, stderr io.Writer) (*flag.FlagSet, remoteFlags) {
+	flags, configPath := newFlagSet(name, stderr)
+	return flags, remoteFlags{
+		configPath: configPath,
+		address:    flags.String("admin", "", "admin api address, taken from config by default"),
+		token:      flags.String("token", "", "admin api token, taken from config by default"),
+		socket:     flags.String("socket", "", "control socket path, taken from config by default"),
+	}
+}
+
+// creates client for admin api configured in config file, unless overridden by flags.
+// Control socket is preferred when it exists, as it needs no token.
+func (f remoteFlags) client() (*Client, error) {
+	if *f.socket != "" {
+		return NewSocketClient(*f.socket), nil
+	}
+	cfg, _, err := config.Load(*f.configPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		cfg = config.Default()
+	}
+	if *f.address == "" && cfg.Admin.SocketPath != "" {
+		if info, err := os.Stat(cfg.Admin.SocketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return NewSocketClient(cfg.Admin.SocketPath), nil
+		}
+	}
+	address, token := cfg.Admin.ListenAddress, cfg.Admin.Token
+	if *f.address != "" {
+		address = *f.address
+	}
+	if *f.token != "" {
+		token = *f.token
+	}
+	if address == "" {
+		return nil, errors.New("admin api address is not configured")
+	}
+	return NewClient(address, token), nil
+}
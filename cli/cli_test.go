@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestGenAndCheckConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	if code, _, stderr := run("gen-config", "-config", path); code != 0 {
+		t.Fatalf("Expected config generated, got %d %s", code, stderr)
+	}
+	if code, _, stderr := run("gen-config", "-config", path); code != 1 || !strings.Contains(stderr, "already exists") {
+		t.Errorf("Expected existing config kept, got %d %s", code, stderr)
+	}
+	if code, stdout, _ := run("check-config", "-config", path); code != 0 || !strings.Contains(stdout, "is valid") {
+		t.Errorf("Expected generated config valid, got %d %s", code, stdout)
+	}
+	if code, _, _ := run("check-config", "-config", filepath.Join(t.TempDir(), "missing.toml")); code != 1 {
+		t.Errorf("Expected missing config reported, got %d", code)
+	}
+}
+
+func TestBanCommands(t *testing.T) {
+	var requests []string
+	var actor string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		actor = r.Header.Get(admin.ActorHeader)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			admin.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[{"xuid":"123","flags":{"banned":true,"ban_reason":"cheating"}}]`))
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, map[string]any{})
+	}))
+	defer api.Close()
+	remote := []string{"-config", filepath.Join(t.TempDir(), "missing.toml"), "-admin", api.URL, "-token", "secret"}
+
+	if code, _, stderr := run(append([]string{"ban", "add", "123", "-reason", "cheating"}, remote...)...); code != 0 {
+		t.Fatalf("Expected ban added, got %d %s", code, stderr)
+	}
+	if requests[0] != "PUT /bans/123?reason=cheating" || actor == "" {
+		t.Errorf("Expected ban request with actor, got %q by %q", requests[0], actor)
+	}
+	if code, stdout, _ := run(append([]string{"ban", "list"}, remote...)...); code != 0 || !strings.Contains(stdout, "cheating") {
+		t.Errorf("Expected banned player listed, got %d %s", code, stdout)
+	}
+	if code, _, stderr := run("ban", "remove", "123", "-config", remote[1], "-admin", api.URL); code != 1 || !strings.Contains(stderr, "unauthorized") {
+		t.Errorf("Expected api error reported without token, got %d %s", code, stderr)
+	}
+	if code, _, _ := run("ban", "add"); code != 2 {
+		t.Errorf("Expected usage error without xuid, got %d", code)
+	}
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Client talks to admin api of running server
+type Client struct {
+	baseURL string
+	token   string
+	actor   string
+	http    *http.Client
+}
+
+// NewClient creates client for admin api at address, given either as host:port or as url
+func NewClient(address string, token string) *Client {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(address, "/"),
+		token:   token,
+		actor:   currentUser(),
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewSocketClient creates client for admin api served on unix socket at path
+func NewSocketClient(path string) *Client {
+	dialer := &net.Dialer{}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{
+		baseURL: "http://control",
+		actor:   currentUser(),
+		http:    &http.Client{Timeout: requestTimeout, Transport: transport},
+	}
+}
+
+// Do sends request with body encoded as json and decodes response into result, both may be nil.
+// Changes are always sent as json, admin api refuses them otherwise.
+func (c *Client) Do(method string, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil || method != http.MethodGet {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	request.Header.Set(admin.ActorHeader, c.actor)
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(response.Body).Decode(&apiError) == nil && apiError.Error != "" {
+			return fmt.Errorf("%s: %s", response.Status, apiError.Error)
+		}
+		return fmt.Errorf("%s", response.Status)
+	}
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// operator name recorded in audit log of server
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "cli"
+}
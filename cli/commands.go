@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"ChromehoundsStatusServer/accounts"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/server"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+)
+
+func statusCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("status", stderr)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	var s server.Status
+	if err := client.Do(http.MethodGet, "/status", nil, &s); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "started\t%s\n", s.Started.Format(time.RFC3339))
+	fmt.Fprintf(w, "uptime\t%s\n", time.Duration(s.UptimeSeconds)*time.Second)
+	fmt.Fprintf(w, "season\t%d\n", s.Season)
+	fmt.Fprintf(w, "online players\t%d\n", s.OnlinePlayers)
+	switch {
+	case s.NextMaintenance == nil:
+		fmt.Fprintf(w, "maintenance\tnone scheduled\n")
+	case s.Maintenance:
+		fmt.Fprintf(w, "maintenance\tactive until %s (%s)\n", s.NextMaintenance.End.Format(time.RFC3339), s.NextMaintenance.Reason)
+	default:
+		fmt.Fprintf(w, "maintenance\tnext %s\n", formatWindow(*s.NextMaintenance))
+	}
+	fmt.Fprintf(w, "\nserver\ttype\tport\tenabled\tstate\n")
+	for _, srv := range s.Servers {
+		state := string(srv.State)
+		if state == "" {
+			state = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\n", srv.Label, srv.Type, srv.Port, srv.Enabled, state)
+	}
+	return w.Flush()
+}
+
+func maintenanceCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "usage: maintenance schedule|cancel\n")
+		return ErrUsage
+	}
+	switch args[0] {
+	case "schedule":
+		return scheduleMaintenance(args[1:], stdout, stderr)
+	case "cancel":
+		return cancelMaintenance(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown maintenance command %q\n", args[0])
+		return ErrUsage
+	}
+}
+
+func scheduleMaintenance(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("maintenance schedule", stderr)
+	start := flags.String("start", "", "start of window as RFC 3339 time, now by default")
+	end := flags.String("end", "", "end of window as RFC 3339 time")
+	duration := flags.Duration("duration", 0, "length of window, alternative to -end")
+	reason := flags.String("reason", "", "reason shown to operators")
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	window := maintenance.Window{Start: time.Now(), Reason: *reason}
+	var err error
+	if *start != "" {
+		if window.Start, err = time.Parse(time.RFC3339, *start); err != nil {
+			return fmt.Errorf("invalid start: %w", err)
+		}
+	}
+	switch {
+	case *end != "" && *duration != 0:
+		return errors.New("use either -end or -duration")
+	case *end != "":
+		if window.End, err = time.Parse(time.RFC3339, *end); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	case *duration > 0:
+		window.End = window.Start.Add(*duration)
+	default:
+		return errors.New("end of window is required, use -end or -duration")
+	}
+
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	var scheduled maintenance.Window
+	if err := client.Do(http.MethodPost, "/maintenance", window, &scheduled); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "scheduled maintenance %s\n", formatWindow(scheduled))
+	return nil
+}
+
+func cancelMaintenance(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("maintenance cancel", stderr)
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fmt.Fprint(stderr, "usage: maintenance cancel <id>\n")
+		return ErrUsage
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	if err := client.Do(http.MethodDelete, "/maintenance/"+url.PathEscape(positional[0]), nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "cancelled maintenance %s\n", positional[0])
+	return nil
+}
+
+func banCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 || (args[0] != "add" && args[0] != "remove" && args[0] != "list") {
+		fmt.Fprint(stderr, "usage: ban add|remove|list\n")
+		return ErrUsage
+	}
+	flags, remote := newRemoteFlagSet("ban "+args[0], stderr)
+	reason := flags.String("reason", "", "reason of ban, only used by add")
+	positional, err := parseArgs(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if args[0] == "list" {
+		if len(positional) != 0 {
+			fmt.Fprint(stderr, "usage: ban list\n")
+			return ErrUsage
+		}
+	} else if len(positional) != 1 {
+		fmt.Fprintf(stderr, "usage: ban %s <xuid>\n", args[0])
+		return ErrUsage
+	}
+
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	xuid := ""
+	if len(positional) > 0 {
+		xuid = url.PathEscape(positional[0])
+	}
+
+	switch args[0] {
+	case "add":
+		if err := client.Do(http.MethodPut, "/bans/"+xuid+"?reason="+url.QueryEscape(*reason), nil, nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "banned %s\n", positional[0])
+	case "remove":
+		if err := client.Do(http.MethodDelete, "/bans/"+xuid, nil, nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "lifted ban of %s\n", positional[0])
+	case "list":
+		var banned []accounts.Account
+		if err := client.Do(http.MethodGet, "/bans", nil, &banned); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "xuid\tgamertag\treason\n")
+		for _, account := range banned {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", account.Xuid, account.Gamertag, account.Flags.BanReason)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func formatWindow(w maintenance.Window) string {
+	return fmt.Sprintf("%s: %s - %s (%s)", w.ID, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339), w.Reason)
+}
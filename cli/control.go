@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"ChromehoundsStatusServer/control"
+	"ChromehoundsStatusServer/session"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func sessionsCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("sessions", stderr)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	var sessions []session.Session
+	if err := client.Do(http.MethodGet, "/sessions", nil, &sessions); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "xuid\taddress\tbuild\tfirst seen\tlast seen\tpackets\n")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", s.Xuid, s.Addr, s.ClientBuild, s.FirstSeen.Format(time.RFC3339), s.LastSeen.Format(time.RFC3339), s.Packets)
+	}
+	return w.Flush()
+}
+
+func reloadCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("reload", stderr)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	var result control.ReloadResult
+	if err := client.Do(http.MethodPost, "/config/reload", nil, &result); err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(stdout, "warning: %s\n", warning)
+	}
+	fmt.Fprintf(stdout, "applied: %s\n", listOrNone(result.Applied))
+	fmt.Fprintf(stdout, "restart required: %s\n", listOrNone(result.RestartRequired))
+	return nil
+}
+
+func verboseCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("verbose", stderr)
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 && (len(positional) != 2 || (positional[1] != "on" && positional[1] != "off")) {
+		fmt.Fprint(stderr, "usage: verbose [<label> on|off]\n")
+		return ErrUsage
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+
+	var labels map[string]bool
+	if len(positional) == 0 {
+		err = client.Do(http.MethodGet, "/logging/verbose", nil, &labels)
+	} else {
+		body := map[string]bool{"enabled": positional[1] == "on"}
+		err = client.Do(http.MethodPut, "/logging/verbose/"+url.PathEscape(positional[0]), body, &labels)
+	}
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, label := range names {
+		state := "off"
+		if labels[label] {
+			state = "on"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, state)
+	}
+	return w.Flush()
+}
+
+func profileCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, remote := newRemoteFlagSet("profile", stderr)
+	seconds := flags.Int("seconds", 30, "length of cpu profile or trace")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fmt.Fprint(stderr, "usage: profile cpu|heap|goroutine|trace [-seconds n]\n")
+		return ErrUsage
+	}
+	client, err := remote.client()
+	if err != nil {
+		return err
+	}
+	// cpu profile and trace keep request open for their whole length
+	client.http.Timeout = time.Duration(*seconds)*time.Second + requestTimeout
+
+	var result struct {
+		Path string `json:"path"`
+	}
+	path := "/profiles/" + url.PathEscape(positional[0]) + "?seconds=" + strconv.Itoa(*seconds)
+	if err := client.Do(http.MethodPost, path, nil, &result); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "profile written to %s on server\n", result.Path)
+	return nil
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
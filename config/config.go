@@ -2,7 +2,11 @@ package config
 
 import (
 	"ChromehoundsStatusServer/logging"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -14,6 +18,25 @@ type Config struct {
 	Servers           []ServerConfig
 	Logging           LoggingConfig
 	Prometheus        PrometheusConfig
+	OpenTelemetry     OpenTelemetryConfig
+	Admin             AdminConfig
+	Sessions          SessionConfig
+	Presence          PresenceConfig
+	Accounts          AccountsConfig
+	Storage           StorageConfig
+	Lobby             LobbyConfig
+	Introducer        IntroducerConfig
+	Relay             RelayConfig
+	War               WarConfig
+	Squads            SquadsConfig
+	Results           ResultsConfig
+	Leaderboards      LeaderboardConfig
+	PublicApi         PublicApiConfig
+	Feed              FeedConfig
+	Audit             AuditConfig
+	Health            HealthConfig
+	Probe             ProbeConfig
+	Profiling         ProfilingConfig
 }
 
 // Definition of configuration for specific service running at a port.
@@ -38,57 +61,645 @@ type PrometheusConfig struct {
 	PrometheusHttpPath      string
 }
 
+// Export to OpenTelemetry collector over OTLP, Protocol is "grpc" or "http".
+// Exported metrics are the ones collected for prometheus, so they need Prometheus.Enabled.
+// Spans are recorded for packets of status and echo servers and for admin api calls,
+// TraceSampleRatio of them is exported.
+type OpenTelemetryConfig struct {
+	Enabled               bool
+	Protocol              string
+	Endpoint              string
+	Insecure              bool
+	ServiceName           string
+	ExportMetrics         bool
+	ExportTraces          bool
+	ExportIntervalSeconds int
+	TraceSampleRatio      float64
+}
+
+// Administration is done through unix socket at SocketPath, if set.
+// Socket is guarded by its file permissions instead of token: only owner of server process
+// can connect, with SocketGroupAccess also members of its group.
+// Same API is served over HTTP at ListenAddress only when Enabled and Token is set,
+// every request has to carry the token as a bearer token. Changes have to be sent as json.
+type AdminConfig struct {
+	Enabled           bool
+	ListenAddress     string
+	Token             string
+	SocketPath        string
+	SocketGroupAccess bool
+}
+
+// Per-client session tracking. Sessions without traffic for IdleTimeoutSeconds are dropped.
+// At most MaxSessions are tracked, and at most MaxAnonymousPerNetwork sessions without identity
+// from single /24 (/64 for IPv6) network, so spoofed sources can't grow the registry. 0 means unlimited.
+// Packets over the limits are still served, just not tracked.
+type SessionConfig struct {
+	IdleTimeoutSeconds     int
+	MaxSessions            int
+	MaxAnonymousPerNetwork int
+}
+
+// Online player counts served over http next to prometheus metrics.
+// ListPlayers exposes tags of players that opted in to be listed. Opt-ins persist through storage.
+// Players are only tracked when Enabled is set, at most MaxPlayers at once, 0 means unlimited.
+type PresenceConfig struct {
+	Enabled     bool
+	HttpPath    string
+	ListPlayers bool
+	MaxPlayers  int
+}
+
+// Player account registry, persisted through storage.
+// With AutoRegister, unknown players are let in and get account once status server received
+// RegisterAfterHellos hellos from them. At most MaxRegistrationsPerMinute accounts are created per minute,
+// the rest waits for next minute.
+type AccountsConfig struct {
+	Enabled                   bool
+	AutoRegister              bool
+	RegisterAfterHellos       int
+	MaxRegistrationsPerMinute int
+}
+
+// Persistent state of services. Backend is one of "bolt", "snapshot" or "memory".
+// Path is database file for bolt, json file for snapshot and unused for memory.
+type StorageConfig struct {
+	Backend string
+	Path    string
+}
+
+// Matchmaking lobby. Room members not heard from for MemberIdleTimeoutSeconds are dropped,
+// host timing out closes the room. Single ip can host at most MaxRoomsPerAddress rooms, 0 means unlimited.
+// Room list is only sent to clients with status session from the same address.
+type LobbyConfig struct {
+	MaxRoomCapacity          int
+	MemberIdleTimeoutSeconds int
+	MaxRoomsPerAddress       int
+}
+
+// NAT traversal introducer. Punch attempts without success reported within PunchTimeoutSeconds
+// fall back to relay at RelayAddress ("host:port"), or fail if it's empty.
+// RelayAddress has to be public address of relay server when it's run by this instance.
+type IntroducerConfig struct {
+	PunchTimeoutSeconds        int
+	RegistrationTimeoutSeconds int
+	RelayAddress               string
+}
+
+// Relay for clients that can't connect directly. Sessions without traffic for SessionIdleTimeoutSeconds are closed.
+// MaxBytesPerSecond limits bytes each session sends out to its members, 0 means unlimited.
+// Clients can hold at most MaxSessionsPerAddress sessions they allocated from single ip, 0 means unlimited.
+type RelayConfig struct {
+	MaxMembersPerSession      int
+	SessionIdleTimeoutSeconds int
+	MaxBytesPerSecond         uint64
+	MaxSessionsPerAddress     int
+}
+
+// Neroimus War campaign. Territory changes owner once attacker's match wins there
+// exceed owner's by CaptureMargin. Territories start each season owned by InitialOwner.
+// Seasons roll over automatically at their end, seasons missing in Seasons last SeasonLengthDays.
+// Rollover is announced to clients as maintenance RolloverNoticeHours ahead.
+type WarConfig struct {
+	Enabled                    bool
+	SeasonLengthDays           int
+	CaptureMargin              int
+	RolloverNoticeHours        int
+	RolloverMaintenanceMinutes int
+	Seasons                    []SeasonConfig
+	Territories                []TerritoryConfig
+}
+
+// Season with fixed dates, overriding default season length
+type SeasonConfig struct {
+	Number uint32
+	Start  time.Time
+	End    time.Time
+}
+
+// Territory of war map. InitialOwner is one of "Morskoj", "Rutania" or "Tarakia".
+type TerritoryConfig struct {
+	ID           string
+	Name         string
+	InitialOwner string
+}
+
+// Squads of players. Invites not answered within InviteExpiryHours lapse.
+type SquadsConfig struct {
+	Enabled           bool
+	MaxMembers        int
+	InviteExpiryHours int
+}
+
+// Match result reporting. Reports with more participants than MaxParticipants
+// or matches longer than MaxMatchMinutes are rejected, as are participants with more kills or deaths
+// than MaxKillsPerMinute, or contribution over MaxContributionPerMinute, for each started minute of match.
+// Result is recorded once second participant reports the same result,
+// reports not confirmed within ConfirmationTimeoutMinutes are dropped.
+type ResultsConfig struct {
+	Enabled                    bool
+	MaxParticipants            int
+	MaxMatchMinutes            int
+	ConfirmationTimeoutMinutes int
+	MaxKillsPerMinute          int
+	MaxContributionPerMinute   int
+}
+
+// Leaderboards served on prometheus listener under HttpPath. Kill ratio ranks only players
+// with at least MinMatchesForKillRatio matches in the window. Top ExportedEntries of each
+// leaderboard are exported as prometheus gauges.
+type LeaderboardConfig struct {
+	Enabled                bool
+	HttpPath               string
+	PageSize               int
+	MaxPageSize            int
+	MinMatchesForKillRatio int
+	ExportedEntries        int
+}
+
+// Read-only json api for community tools, served on prometheus listener under HttpPath.
+// Each client address may do RequestsPerMinute requests on average, with bursts up to Burst.
+type PublicApiConfig struct {
+	Enabled           bool
+	HttpPath          string
+	RequestsPerMinute int
+	Burst             int
+}
+
+// Live event feed served on prometheus listener, as server-sent events at HttpPath
+// and websocket at HttpPath/ws. XUIDs in player events are only sent when ListPlayers is set.
+// Clients that can't keep up lose events beyond SubscriberBuffer. At most MaxSubscribers
+// connections are served, MaxSubscribersPerClient of them from single address; more are refused.
+type FeedConfig struct {
+	Enabled                 bool
+	HttpPath                string
+	ListPlayers             bool
+	SubscriberBuffer        int
+	HeartbeatSeconds        int
+	MaxSubscribers          int
+	MaxSubscribersPerClient int
+}
+
+// Audit log of administrative actions, appended to Path as JSON lines.
+// Every entry carries hash of previous one, so edits and removals can be detected.
+type AuditConfig struct {
+	Enabled bool
+	Path    string
+}
+
+// Liveness and readiness checks served on prometheus listener.
+// Live fails when any enabled server failed to bind, ready fails until all of them serve.
+// With UnreadyDuringMaintenance, instance also reports not ready while maintenance is active.
+type HealthConfig struct {
+	Enabled                  bool
+	LivePath                 string
+	ReadyPath                string
+	UnreadyDuringMaintenance bool
+}
+
+// Synthetic monitoring of status and echo servers. Every IntervalSeconds, hello message is sent
+// to each of them at Address and response is validated. Empty Address probes ListeningAddress,
+// or loopback when listening on all interfaces. Probes from loopback are not recorded as sessions.
+type ProbeConfig struct {
+	Enabled         bool
+	Address         string
+	IntervalSeconds int
+	TimeoutMillis   int
+}
+
+// Profiling of running server. With EnablePprof, net/http/pprof is served on admin api at /debug/pprof/,
+// so it's only reachable with admin token or through control socket.
+// Profiles captured through admin api, or on SIGUSR1, are written to CaptureDir.
+// SIGUSR1 captures heap profile, then cpu profile and execution trace, each SignalCaptureSeconds long.
+//
+// With AutoCapture, resource usage is checked every AnomalyCheckSeconds and heap and cpu profiles are
+// captured into AutoCaptureDir when any threshold is crossed, at most once per AutoCaptureCooldownMinutes.
+// Only AutoCaptureRetain newest captures are kept. Threshold of 0 disables the check.
+// Processing time and error rate are only known with Logging.EnablePerformanceMonitoring.
+type ProfilingConfig struct {
+	EnablePprof          bool
+	CaptureDir           string
+	SignalCaptureSeconds int
+
+	AutoCapture                bool
+	AutoCaptureDir             string
+	AutoCaptureRetain          int
+	AutoCaptureSeconds         int
+	AutoCaptureCooldownMinutes int
+	AnomalyCheckSeconds        int
+	HeapGrowthMBPerMinute      float64 // growth of live heap
+	GCPerMinute                float64
+	P99ProcessingMillis        float64 // of any server
+	ErrorRatePercent           float64 // of any server, errors per packet received
+}
+
 type ServerType string
 
 const (
-	Echoing ServerType = "Echoing"
-	Status  ServerType = "Status"
+	Echoing    ServerType = "Echoing"
+	Status     ServerType = "Status"
+	Lobby      ServerType = "Lobby"
+	Introducer ServerType = "Introducer"
+	Relay      ServerType = "Relay"
+	Results    ServerType = "Results"
 )
 
-var configFilename = "config.toml"
+// DefaultPath is where config is looked up when no other path is given
+const DefaultPath = "config.toml"
 
-func LoadConfig() Config {
-	var conf Config
-	f, err := os.Open(configFilename)
+// LoadConfig reads and validates config at path. Without usable file, defaults are used.
+func LoadConfig(path string) Config {
+	conf, warnings, err := Load(path)
 	if err != nil {
-		conf = generateDefaultConfig()
-		if os.IsNotExist(err) {
-			logging.Info.Printf("[CONFIG] config file does not exist. generating")
-			f, err := os.Create(configFilename)
-			if err != nil {
-				logging.Error.Printf("[CONFIG] failed writing config to file!")
-			} else {
-				encoder := toml.NewEncoder(f)
-				encoder.Encode(conf)
-			}
+		if errors.Is(err, os.ErrNotExist) {
+			logging.Warn.Printf("[CONFIG] config file %s does not exist, run gen-config to create one", path)
 		} else {
-			logging.Error.Printf("[CONFIG] error opening config file: %s", err)
+			logging.Error.Printf("[CONFIG] error loading config file: %s", err)
 		}
 		logging.Warn.Printf("[CONFIG] fallback to default")
-	} else {
-		defer f.Close()
-		if _, err := toml.DecodeFile("config.toml", &conf); err != nil {
-			logging.Warn.Printf("[CONFIG] failed decoding config - fallback to default")
-			conf = generateDefaultConfig()
-		}
+		conf = Default()
+		warnings = validateAndFix(&conf)
+	}
+	for _, warning := range warnings {
+		logging.Warn.Printf("[CONFIG] %s", warning)
 	}
-	validateAndFix(&conf)
-
 	return conf
 }
 
-func validateAndFix(config *Config) {
+// Load reads config at path and validates it. Unknown keys and values that had to be fixed are returned as warnings.
+func Load(path string) (Config, []string, error) {
+	var conf Config
+	meta, err := toml.DecodeFile(path, &conf)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	var warnings []string
+	for _, key := range meta.Undecoded() {
+		warnings = append(warnings, fmt.Sprintf("unknown key %s", key))
+	}
+	return conf, append(warnings, validateAndFix(&conf)...), nil
+}
+
+// WriteDefault writes default config to path. Existing file is only replaced when overwrite is set.
+func WriteDefault(path string, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(f).Encode(Default()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fixes impossible values, returning what was fixed
+func validateAndFix(config *Config) []string {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
 	if config.Logging.PerformanceReportInterval <= 0 {
-		logging.Warn.Printf("[CONFIG] impossible value for reporting interval: %ds, fallback to 10s", config.Logging.PerformanceReportInterval)
+		warn("impossible value for reporting interval: %ds, fallback to 10s", config.Logging.PerformanceReportInterval)
 		config.Logging.PerformanceReportInterval = 10
 	}
 
+	if config.OpenTelemetry.Enabled {
+		otel := &config.OpenTelemetry
+		if otel.Protocol != "grpc" && otel.Protocol != "http" {
+			warn("unknown OTLP protocol %q, fallback to grpc", otel.Protocol)
+			otel.Protocol = "grpc"
+		}
+		if otel.Endpoint == "" {
+			otel.Endpoint = "localhost:4317"
+			if otel.Protocol == "http" {
+				otel.Endpoint = "localhost:4318"
+			}
+			warn("OTLP endpoint not set, fallback to %s", otel.Endpoint)
+		}
+		if otel.ServiceName == "" {
+			warn("OpenTelemetry service name not set, fallback to open-combas-server")
+			otel.ServiceName = "open-combas-server"
+		}
+		if otel.ExportIntervalSeconds <= 0 {
+			warn("impossible value for OTLP export interval: %ds, fallback to 30s", otel.ExportIntervalSeconds)
+			otel.ExportIntervalSeconds = 30
+		}
+		if otel.TraceSampleRatio < 0 || otel.TraceSampleRatio > 1 {
+			warn("impossible value for trace sample ratio: %g, fallback to 0.01", otel.TraceSampleRatio)
+			otel.TraceSampleRatio = 0.01
+		}
+		if otel.ExportMetrics && !config.Prometheus.Enabled {
+			warn("OTLP metrics export needs prometheus metrics, nothing will be exported")
+		}
+	}
+
+	if config.Admin.Enabled && config.Admin.Token == "" {
+		warn("admin api needs token, only control socket is served")
+		config.Admin.Enabled = false
+	}
+
+	if config.Accounts.RegisterAfterHellos <= 0 {
+		warn("impossible value for hellos before registration: %d, fallback to 3", config.Accounts.RegisterAfterHellos)
+		config.Accounts.RegisterAfterHellos = 3
+	}
+
+	if config.Accounts.MaxRegistrationsPerMinute <= 0 {
+		warn("impossible value for registrations per minute: %d, fallback to 60", config.Accounts.MaxRegistrationsPerMinute)
+		config.Accounts.MaxRegistrationsPerMinute = 60
+	}
+
+	if config.Sessions.IdleTimeoutSeconds <= 0 {
+		warn("impossible value for session idle timeout: %ds, fallback to 300s", config.Sessions.IdleTimeoutSeconds)
+		config.Sessions.IdleTimeoutSeconds = 300
+	}
+
+	if config.Sessions.MaxSessions < 0 {
+		warn("impossible value for max sessions: %d, fallback to 100000", config.Sessions.MaxSessions)
+		config.Sessions.MaxSessions = 100000
+	}
+
+	if config.Sessions.MaxAnonymousPerNetwork < 0 {
+		warn("impossible value for anonymous sessions per network: %d, fallback to 64", config.Sessions.MaxAnonymousPerNetwork)
+		config.Sessions.MaxAnonymousPerNetwork = 64
+	}
+
+	if config.Presence.Enabled && config.Presence.HttpPath == "" {
+		warn("presence http path not set, fallback to /presence")
+		config.Presence.HttpPath = "/presence"
+	}
+
+	if config.Presence.MaxPlayers < 0 {
+		warn("impossible value for presence max players: %d, fallback to 100000", config.Presence.MaxPlayers)
+		config.Presence.MaxPlayers = 100000
+	}
+
+	if config.Storage.Backend == "" {
+		warn("storage backend not set, fallback to bolt")
+		config.Storage.Backend = "bolt"
+	}
+
+	if config.Storage.Path == "" && config.Storage.Backend != "memory" {
+		warn("storage path not set, fallback to server.db")
+		config.Storage.Path = "server.db"
+	}
+
+	if config.Lobby.MaxRoomCapacity < 2 || config.Lobby.MaxRoomCapacity > 255 {
+		warn("impossible value for lobby room capacity: %d, fallback to 8", config.Lobby.MaxRoomCapacity)
+		config.Lobby.MaxRoomCapacity = 8
+	}
+
+	if config.Lobby.MemberIdleTimeoutSeconds <= 0 {
+		warn("impossible value for lobby idle timeout: %ds, fallback to 60s", config.Lobby.MemberIdleTimeoutSeconds)
+		config.Lobby.MemberIdleTimeoutSeconds = 60
+	}
+
+	if config.Lobby.MaxRoomsPerAddress < 0 {
+		warn("impossible value for lobby rooms per address: %d, fallback to 4", config.Lobby.MaxRoomsPerAddress)
+		config.Lobby.MaxRoomsPerAddress = 4
+	}
+
+	if config.Introducer.PunchTimeoutSeconds <= 0 {
+		warn("impossible value for punch timeout: %ds, fallback to 10s", config.Introducer.PunchTimeoutSeconds)
+		config.Introducer.PunchTimeoutSeconds = 10
+	}
+
+	if config.Introducer.RegistrationTimeoutSeconds <= 0 {
+		warn("impossible value for introducer registration timeout: %ds, fallback to 120s", config.Introducer.RegistrationTimeoutSeconds)
+		config.Introducer.RegistrationTimeoutSeconds = 120
+	}
+
+	if config.Relay.MaxMembersPerSession < 2 {
+		warn("impossible value for relay session members: %d, fallback to 8", config.Relay.MaxMembersPerSession)
+		config.Relay.MaxMembersPerSession = 8
+	}
+
+	if config.Relay.SessionIdleTimeoutSeconds <= 0 {
+		warn("impossible value for relay idle timeout: %ds, fallback to 60s", config.Relay.SessionIdleTimeoutSeconds)
+		config.Relay.SessionIdleTimeoutSeconds = 60
+	}
+
+	if config.Relay.MaxSessionsPerAddress < 0 {
+		warn("impossible value for relay sessions per address: %d, fallback to 4", config.Relay.MaxSessionsPerAddress)
+		config.Relay.MaxSessionsPerAddress = 4
+	}
+
+	if config.War.SeasonLengthDays <= 0 {
+		warn("impossible value for season length: %d days, fallback to 28", config.War.SeasonLengthDays)
+		config.War.SeasonLengthDays = 28
+	}
+
+	if config.War.CaptureMargin <= 0 {
+		warn("impossible value for capture margin: %d, fallback to 10", config.War.CaptureMargin)
+		config.War.CaptureMargin = 10
+	}
+
+	if config.War.RolloverNoticeHours <= 0 {
+		warn("impossible value for rollover notice: %dh, fallback to 48h", config.War.RolloverNoticeHours)
+		config.War.RolloverNoticeHours = 48
+	}
+
+	if config.War.RolloverMaintenanceMinutes <= 0 {
+		warn("impossible value for rollover maintenance: %dm, fallback to 30m", config.War.RolloverMaintenanceMinutes)
+		config.War.RolloverMaintenanceMinutes = 30
+	}
+
+	seasons := config.War.Seasons[:0]
+	for _, season := range config.War.Seasons {
+		if !season.End.After(season.Start) {
+			warn("season %d ends before it starts, ignoring it", season.Number)
+			continue
+		}
+		seasons = append(seasons, season)
+	}
+	config.War.Seasons = seasons
+
+	if config.Squads.MaxMembers < 2 {
+		warn("impossible value for squad members: %d, fallback to 16", config.Squads.MaxMembers)
+		config.Squads.MaxMembers = 16
+	}
+
+	if config.Squads.InviteExpiryHours <= 0 {
+		warn("impossible value for squad invite expiry: %dh, fallback to 72h", config.Squads.InviteExpiryHours)
+		config.Squads.InviteExpiryHours = 72
+	}
+
+	if config.Results.MaxParticipants < 2 {
+		warn("impossible value for match participants: %d, fallback to 12", config.Results.MaxParticipants)
+		config.Results.MaxParticipants = 12
+	}
+
+	if config.Results.MaxMatchMinutes <= 0 {
+		warn("impossible value for match length: %dm, fallback to 120m", config.Results.MaxMatchMinutes)
+		config.Results.MaxMatchMinutes = 120
+	}
+
+	if config.Results.ConfirmationTimeoutMinutes <= 0 {
+		warn("impossible value for result confirmation timeout: %dm, fallback to 30m", config.Results.ConfirmationTimeoutMinutes)
+		config.Results.ConfirmationTimeoutMinutes = 30
+	}
+
+	if config.Results.MaxKillsPerMinute <= 0 {
+		warn("impossible value for kills per minute: %d, fallback to 5", config.Results.MaxKillsPerMinute)
+		config.Results.MaxKillsPerMinute = 5
+	}
+
+	if config.Results.MaxContributionPerMinute <= 0 {
+		warn("impossible value for contribution per minute: %d, fallback to 50", config.Results.MaxContributionPerMinute)
+		config.Results.MaxContributionPerMinute = 50
+	}
+
+	if config.Leaderboards.HttpPath == "" {
+		warn("leaderboard http path not set, fallback to /leaderboards")
+		config.Leaderboards.HttpPath = "/leaderboards"
+	}
+
+	if config.Leaderboards.PageSize <= 0 {
+		warn("impossible value for leaderboard page size: %d, fallback to 25", config.Leaderboards.PageSize)
+		config.Leaderboards.PageSize = 25
+	}
+
+	if config.Leaderboards.MaxPageSize < config.Leaderboards.PageSize {
+		warn("impossible value for leaderboard max page size: %d, fallback to %d", config.Leaderboards.MaxPageSize, config.Leaderboards.PageSize)
+		config.Leaderboards.MaxPageSize = config.Leaderboards.PageSize
+	}
+
+	if config.Leaderboards.MinMatchesForKillRatio < 0 {
+		warn("impossible value for kill ratio minimum matches: %d, fallback to 0", config.Leaderboards.MinMatchesForKillRatio)
+		config.Leaderboards.MinMatchesForKillRatio = 0
+	}
+
+	if config.Leaderboards.ExportedEntries < 0 {
+		warn("impossible value for exported leaderboard entries: %d, fallback to 0", config.Leaderboards.ExportedEntries)
+		config.Leaderboards.ExportedEntries = 0
+	}
+
+	if config.PublicApi.HttpPath == "" {
+		warn("public api http path not set, fallback to /api")
+		config.PublicApi.HttpPath = "/api"
+	}
+
+	if config.PublicApi.RequestsPerMinute <= 0 {
+		warn("impossible value for public api rate limit: %d/min, fallback to 60/min", config.PublicApi.RequestsPerMinute)
+		config.PublicApi.RequestsPerMinute = 60
+	}
+
+	if config.PublicApi.Burst <= 0 {
+		warn("impossible value for public api burst: %d, fallback to 10", config.PublicApi.Burst)
+		config.PublicApi.Burst = 10
+	}
+
+	if config.Feed.HttpPath == "" {
+		warn("feed http path not set, fallback to /events")
+		config.Feed.HttpPath = "/events"
+	}
+
+	if config.Feed.SubscriberBuffer <= 0 {
+		warn("impossible value for feed subscriber buffer: %d, fallback to 64", config.Feed.SubscriberBuffer)
+		config.Feed.SubscriberBuffer = 64
+	}
+
+	if config.Feed.HeartbeatSeconds <= 0 {
+		warn("impossible value for feed heartbeat: %ds, fallback to 15s", config.Feed.HeartbeatSeconds)
+		config.Feed.HeartbeatSeconds = 15
+	}
+
+	if config.Feed.MaxSubscribers <= 0 {
+		warn("impossible value for feed subscriber limit: %d, fallback to 256", config.Feed.MaxSubscribers)
+		config.Feed.MaxSubscribers = 256
+	}
+
+	if config.Feed.MaxSubscribersPerClient <= 0 {
+		warn("impossible value for feed subscriber limit per client: %d, fallback to 4", config.Feed.MaxSubscribersPerClient)
+		config.Feed.MaxSubscribersPerClient = 4
+	}
+
+	if config.Audit.Enabled && config.Audit.Path == "" {
+		warn("audit log path not set, fallback to audit.jsonl")
+		config.Audit.Path = "audit.jsonl"
+	}
+
+	if config.Health.LivePath == "" {
+		warn("liveness http path not set, fallback to /healthz")
+		config.Health.LivePath = "/healthz"
+	}
+
+	if config.Health.ReadyPath == "" {
+		warn("readiness http path not set, fallback to /readyz")
+		config.Health.ReadyPath = "/readyz"
+	}
+
+	if config.Profiling.CaptureDir == "" {
+		warn("profile capture directory not set, fallback to profiles")
+		config.Profiling.CaptureDir = "profiles"
+	}
+
+	if config.Profiling.SignalCaptureSeconds <= 0 {
+		warn("impossible value for signal capture length: %ds, fallback to 30s", config.Profiling.SignalCaptureSeconds)
+		config.Profiling.SignalCaptureSeconds = 30
+	}
+
+	if config.Profiling.AutoCaptureDir == "" {
+		warn("automatic capture directory not set, fallback to profiles/auto")
+		config.Profiling.AutoCaptureDir = "profiles/auto"
+	}
+
+	if config.Profiling.AutoCaptureRetain <= 0 {
+		warn("impossible value for retained automatic captures: %d, fallback to 10", config.Profiling.AutoCaptureRetain)
+		config.Profiling.AutoCaptureRetain = 10
+	}
+
+	if config.Profiling.AutoCaptureSeconds <= 0 {
+		warn("impossible value for automatic capture length: %ds, fallback to 10s", config.Profiling.AutoCaptureSeconds)
+		config.Profiling.AutoCaptureSeconds = 10
+	}
+
+	if config.Profiling.AutoCaptureCooldownMinutes < 0 {
+		warn("impossible value for automatic capture cooldown: %dm, fallback to 15m", config.Profiling.AutoCaptureCooldownMinutes)
+		config.Profiling.AutoCaptureCooldownMinutes = 15
+	}
+
+	if config.Profiling.AnomalyCheckSeconds <= 0 {
+		warn("impossible value for anomaly check interval: %ds, fallback to 10s", config.Profiling.AnomalyCheckSeconds)
+		config.Profiling.AnomalyCheckSeconds = 10
+	}
+
+	if config.Profiling.HeapGrowthMBPerMinute < 0 || config.Profiling.GCPerMinute < 0 || config.Profiling.P99ProcessingMillis < 0 || config.Profiling.ErrorRatePercent < 0 {
+		warn("negative anomaly threshold, fallback to 0 (disabled)")
+		config.Profiling.HeapGrowthMBPerMinute = max(config.Profiling.HeapGrowthMBPerMinute, 0)
+		config.Profiling.GCPerMinute = max(config.Profiling.GCPerMinute, 0)
+		config.Profiling.P99ProcessingMillis = max(config.Profiling.P99ProcessingMillis, 0)
+		config.Profiling.ErrorRatePercent = max(config.Profiling.ErrorRatePercent, 0)
+	}
+
+	if config.Probe.IntervalSeconds <= 0 {
+		warn("impossible value for probe interval: %ds, fallback to 30s", config.Probe.IntervalSeconds)
+		config.Probe.IntervalSeconds = 30
+	}
+
+	if config.Probe.TimeoutMillis <= 0 {
+		warn("impossible value for probe timeout: %dms, fallback to 1000ms", config.Probe.TimeoutMillis)
+		config.Probe.TimeoutMillis = 1000
+	}
+
+	if config.Probe.Address != "" && net.ParseIP(config.Probe.Address) == nil {
+		warn("invalid probe address %q, fallback to listening address", config.Probe.Address)
+		config.Probe.Address = ""
+	}
+
 	if len(config.Servers) == 0 {
-		logging.Warn.Printf("[CONFIG] No servers declared!")
+		warn("No servers declared!")
 	}
+	return warnings
 }
 
-func generateDefaultConfig() Config {
+// Default returns config server runs with when there's no config file
+func Default() Config {
 	return Config{
 		ListeningAddress:  "0.0.0.0",
 		DefaultBufferSize: 4000,
@@ -111,6 +722,30 @@ func generateDefaultConfig() Config {
 				Enabled: true,
 				Type:    Status,
 			},
+			{
+				Label:   "LOBBY",
+				Port:    1230,
+				Enabled: false,
+				Type:    Lobby,
+			},
+			{
+				Label:   "INTRODUCER",
+				Port:    1231,
+				Enabled: false,
+				Type:    Introducer,
+			},
+			{
+				Label:   "RELAY",
+				Port:    1232,
+				Enabled: false,
+				Type:    Relay,
+			},
+			{
+				Label:   "RESULTS",
+				Port:    1233,
+				Enabled: false,
+				Type:    Results,
+			},
 		},
 		Logging: LoggingConfig{
 			Verbose:                     false,
@@ -123,5 +758,146 @@ func generateDefaultConfig() Config {
 			PrometheusListenAddress: "0.0.0.0:9090",
 			PrometheusHttpPath:      "/metrics",
 		},
+		OpenTelemetry: OpenTelemetryConfig{
+			Enabled:               false,
+			Protocol:              "grpc",
+			Endpoint:              "localhost:4317",
+			Insecure:              true,
+			ServiceName:           "open-combas-server",
+			ExportMetrics:         true,
+			ExportTraces:          true,
+			ExportIntervalSeconds: 30,
+			TraceSampleRatio:      0.01,
+		},
+		Admin: AdminConfig{
+			Enabled:           false,
+			ListenAddress:     "127.0.0.1:9091",
+			Token:             "",
+			SocketPath:        "admin.sock",
+			SocketGroupAccess: false,
+		},
+		Sessions: SessionConfig{
+			IdleTimeoutSeconds:     300,
+			MaxSessions:            100000,
+			MaxAnonymousPerNetwork: 64,
+		},
+		Presence: PresenceConfig{
+			Enabled:     true,
+			HttpPath:    "/presence",
+			ListPlayers: false,
+			MaxPlayers:  100000,
+		},
+		Accounts: AccountsConfig{
+			Enabled:                   true,
+			AutoRegister:              true,
+			RegisterAfterHellos:       3,
+			MaxRegistrationsPerMinute: 60,
+		},
+		Storage: StorageConfig{
+			Backend: "bolt",
+			Path:    "server.db",
+		},
+		Lobby: LobbyConfig{
+			MaxRoomCapacity:          8,
+			MemberIdleTimeoutSeconds: 60,
+			MaxRoomsPerAddress:       4,
+		},
+		Introducer: IntroducerConfig{
+			PunchTimeoutSeconds:        10,
+			RegistrationTimeoutSeconds: 120,
+			RelayAddress:               "",
+		},
+		Relay: RelayConfig{
+			MaxMembersPerSession:      8,
+			SessionIdleTimeoutSeconds: 60,
+			MaxBytesPerSecond:         1024 * 1024,
+			MaxSessionsPerAddress:     4,
+		},
+		War: WarConfig{
+			Enabled:                    true,
+			SeasonLengthDays:           28,
+			CaptureMargin:              10,
+			RolloverNoticeHours:        48,
+			RolloverMaintenanceMinutes: 30,
+			Territories: []TerritoryConfig{
+				{ID: "MOR-1", Name: "Morskoj Heartland", InitialOwner: "Morskoj"},
+				{ID: "MOR-2", Name: "Morskoj Frontier", InitialOwner: "Morskoj"},
+				{ID: "RUT-1", Name: "Rutania Heartland", InitialOwner: "Rutania"},
+				{ID: "RUT-2", Name: "Rutania Frontier", InitialOwner: "Rutania"},
+				{ID: "TAR-1", Name: "Tarakia Heartland", InitialOwner: "Tarakia"},
+				{ID: "TAR-2", Name: "Tarakia Frontier", InitialOwner: "Tarakia"},
+				{ID: "NEU-1", Name: "Neroimus Basin", InitialOwner: "Morskoj"},
+				{ID: "NEU-2", Name: "Neroimus Highlands", InitialOwner: "Rutania"},
+				{ID: "NEU-3", Name: "Neroimus Coast", InitialOwner: "Tarakia"},
+			},
+		},
+		Squads: SquadsConfig{
+			Enabled:           true,
+			MaxMembers:        16,
+			InviteExpiryHours: 72,
+		},
+		Results: ResultsConfig{
+			Enabled:                    true,
+			MaxParticipants:            12,
+			MaxMatchMinutes:            120,
+			ConfirmationTimeoutMinutes: 30,
+			MaxKillsPerMinute:          5,
+			MaxContributionPerMinute:   50,
+		},
+		Leaderboards: LeaderboardConfig{
+			Enabled:                true,
+			HttpPath:               "/leaderboards",
+			PageSize:               25,
+			MaxPageSize:            100,
+			MinMatchesForKillRatio: 5,
+			ExportedEntries:        10,
+		},
+		PublicApi: PublicApiConfig{
+			Enabled:           true,
+			HttpPath:          "/api",
+			RequestsPerMinute: 60,
+			Burst:             10,
+		},
+		Feed: FeedConfig{
+			Enabled:                 true,
+			HttpPath:                "/events",
+			ListPlayers:             false,
+			SubscriberBuffer:        64,
+			HeartbeatSeconds:        15,
+			MaxSubscribers:          256,
+			MaxSubscribersPerClient: 4,
+		},
+		Audit: AuditConfig{
+			Enabled: true,
+			Path:    "audit.jsonl",
+		},
+		Health: HealthConfig{
+			Enabled:                  true,
+			LivePath:                 "/healthz",
+			ReadyPath:                "/readyz",
+			UnreadyDuringMaintenance: false,
+		},
+		Probe: ProbeConfig{
+			Enabled:         false,
+			Address:         "",
+			IntervalSeconds: 30,
+			TimeoutMillis:   1000,
+		},
+		Profiling: ProfilingConfig{
+			EnablePprof:          false,
+			CaptureDir:           "profiles",
+			SignalCaptureSeconds: 30,
+
+			AutoCapture:                false,
+			AutoCaptureDir:             "profiles/auto",
+			AutoCaptureRetain:          10,
+			AutoCaptureSeconds:         10,
+			AutoCaptureCooldownMinutes: 15,
+			AnomalyCheckSeconds:        10,
+			HeapGrowthMBPerMinute:      64,
+			GCPerMinute:                120,
+			P99ProcessingMillis:        5,
+			ErrorRatePercent:           10,
+		},
 	}
 }
@@ -0,0 +1,84 @@
+package control
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/logging/profiling"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+)
+
+const defaultCaptureSeconds = 30
+
+// RegisterAdminRoutes exposes config reload, verbose logging switches and profile capture on admin api,
+// and net/http/pprof when it's enabled
+func (c *Control) RegisterAdminRoutes(a *admin.Server) {
+	if c.running.Profiling.EnablePprof {
+		a.HandleFunc("GET /debug/pprof/", pprof.Index)
+		a.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+		a.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+		a.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+		a.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	}
+
+	a.HandleFunc("POST /config/reload", func(w http.ResponseWriter, r *http.Request) {
+		result, err := c.Reload()
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.Audit(r, "config.reload", c.configPath, nil, result)
+		admin.WriteJSON(w, http.StatusOK, result)
+	})
+
+	a.HandleFunc("GET /logging/verbose", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, logging.VerboseLabels())
+	})
+
+	a.HandleFunc("PUT /logging/verbose/{label}", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+		label := r.PathValue("label")
+		before := logging.VerboseLabels()[label]
+		if !logging.SetVerbose(label, request.Enabled) {
+			admin.WriteError(w, http.StatusNotFound, "no server with label "+label)
+			return
+		}
+		admin.Audit(r, "logging.verbose", label, before, request.Enabled)
+		admin.WriteJSON(w, http.StatusOK, logging.VerboseLabels())
+	})
+
+	// blocks for duration of cpu profile or trace
+	a.HandleFunc("POST /profiles/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		seconds := defaultCaptureSeconds
+		if value := r.URL.Query().Get("seconds"); value != "" {
+			var err error
+			if seconds, err = strconv.Atoi(value); err != nil || seconds <= 0 {
+				admin.WriteError(w, http.StatusBadRequest, "invalid seconds")
+				return
+			}
+		}
+		kind := r.PathValue("kind")
+		path, err := profiling.Capture(kind, time.Duration(seconds)*time.Second, c.running.Profiling.CaptureDir)
+		switch {
+		case errors.Is(err, profiling.ErrUnknownProfile):
+			admin.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, profiling.ErrCaptureInProgress):
+			admin.WriteError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.Audit(r, "profile.capture", kind, nil, path)
+			admin.WriteJSON(w, http.StatusOK, map[string]string{"path": path})
+		}
+	})
+}
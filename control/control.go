@@ -0,0 +1,59 @@
+package control
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"reflect"
+	"sync"
+)
+
+// ReloadResult tells which settings of reloaded config took effect
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"` // config sections that differ from running ones
+	Warnings        []string `json:"warnings"`
+}
+
+// Control holds operations on running instance that don't belong to any subsystem.
+// Safe for concurrent use.
+type Control struct {
+	configPath string
+
+	mu      sync.Mutex
+	running config.Config // config servers were started with, with reloaded settings applied
+}
+
+// New creates control of instance started with given config, read from configPath
+func New(configPath string, running config.Config) *Control {
+	return &Control{configPath: configPath, running: running}
+}
+
+// Reload reads config file again and applies settings that can change at runtime.
+// Currently that's verbose logging, which is switched for all servers.
+func (c *Control) Reload() (ReloadResult, error) {
+	loaded, warnings, err := config.Load(c.configPath)
+	if err != nil {
+		return ReloadResult{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := ReloadResult{Applied: []string{}, RestartRequired: []string{}, Warnings: warnings}
+	if loaded.Logging.Verbose != c.running.Logging.Verbose {
+		logging.SetVerboseAll(loaded.Logging.Verbose)
+		c.running.Logging.Verbose = loaded.Logging.Verbose
+		result.Applied = append(result.Applied, "Logging.Verbose")
+	}
+
+	running, next := reflect.ValueOf(c.running), reflect.ValueOf(loaded)
+	for i := 0; i < running.NumField(); i++ {
+		if !reflect.DeepEqual(running.Field(i).Interface(), next.Field(i).Interface()) {
+			result.RestartRequired = append(result.RestartRequired, running.Type().Field(i).Name)
+		}
+	}
+	if len(result.Applied) > 0 || len(result.RestartRequired) > 0 {
+		logging.Info.Printf("[CONTROL] config reloaded, applied %v, restart required for %v", result.Applied, result.RestartRequired)
+	}
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package control
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := config.WriteDefault(path, false); err != nil {
+		t.Fatalf("Failed writing config: %v", err)
+	}
+	running, _, _ := config.Load(path)
+	control := New(path, running)
+	verbose := logging.VerboseSwitch("RELOAD_TEST", running.Logging.Verbose)
+
+	if result, err := control.Reload(); err != nil || len(result.Applied) != 0 || len(result.RestartRequired) != 0 {
+		t.Fatalf("Expected nothing to change for same file, got %v %v", result, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	edited := strings.Replace(string(data), "Verbose = false", "Verbose = true", 1)
+	edited = strings.Replace(edited, "MaxMembers = 16", "MaxMembers = 20", 1)
+	os.WriteFile(path, []byte(edited), 0o600)
+
+	result, err := control.Reload()
+	if err != nil {
+		t.Fatalf("Failed reloading: %v", err)
+	}
+	if len(result.Applied) != 1 || result.Applied[0] != "Logging.Verbose" || !verbose.Load() {
+		t.Errorf("Expected verbose logging applied, got %v", result.Applied)
+	}
+	if len(result.RestartRequired) != 1 || result.RestartRequired[0] != "Squads" {
+		t.Errorf("Expected squads to require restart, got %v", result.RestartRequired)
+	}
+}
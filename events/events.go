@@ -0,0 +1,213 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type of event
+type Type string
+
+const (
+	ServerStarted        Type = "server_started"
+	ServerStopped        Type = "server_stopped"
+	MaintenanceScheduled Type = "maintenance_scheduled"
+	MaintenanceCancelled Type = "maintenance_cancelled"
+	PlayerOnline         Type = "player_online"
+	PlayerOffline        Type = "player_offline"
+	TerritoryChanged     Type = "territory_changed"
+	SessionStarted       Type = "session_started"
+	PacketReceived       Type = "packet_received"   // published from hot loops, subscribe only when needed
+	ValidationFailed     Type = "validation_failed" // published from hot loops, subscribe only when needed
+)
+
+// Types lists all event types published on bus
+var Types = []Type{
+	ServerStarted, ServerStopped,
+	MaintenanceScheduled, MaintenanceCancelled,
+	PlayerOnline, PlayerOffline,
+	TerritoryChanged,
+	SessionStarted,
+	PacketReceived, ValidationFailed,
+}
+
+// Event is published on bus. Data is payload specific to event type.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Server is payload of server started and stopped events
+type Server struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Port  int    `json:"port"`
+}
+
+// Player is payload of player online and offline events
+type Player struct {
+	Xuid string `json:"xuid"`
+}
+
+// Maintenance is payload of maintenance scheduled and cancelled events
+type Maintenance struct {
+	ID     string    `json:"id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason"`
+}
+
+// Territory is payload of territory changed event
+type Territory struct {
+	ID            string `json:"id"`
+	PreviousOwner string `json:"previous_owner"`
+	NewOwner      string `json:"new_owner"`
+	Season        uint32 `json:"season"`
+}
+
+// Session is payload of session started event. Xuid is empty for clients known only by address.
+type Session struct {
+	Xuid    string `json:"xuid,omitempty"`
+	Addr    string `json:"addr"`
+	Service string `json:"service"`
+}
+
+// Packet is payload of packet received event, published for packets that passed validation
+type Packet struct {
+	Label          string        `json:"label"`
+	Addr           string        `json:"addr"`
+	Size           int           `json:"size"`
+	ProcessingTime time.Duration `json:"processing_time"`
+}
+
+// ValidationFailure is payload of validation failed event
+type ValidationFailure struct {
+	Label  string `json:"label"`
+	Addr   string `json:"addr"`
+	Reason string `json:"reason"`
+	Size   int    `json:"size"`
+}
+
+// counters of single event type
+type typeStats struct {
+	subscribers atomic.Int64
+	published   atomic.Uint64
+	dropped     atomic.Uint64
+}
+
+// Bus delivers published events to subscribers of their type. Safe for concurrent use.
+// Publishing never blocks, events are dropped for subscribers that fall behind.
+// Publishing to nil bus does nothing, so publishers don't need to check whether events are in use.
+type Bus struct {
+	stats map[Type]*typeStats // fixed after creation, so read without lock
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives events of its types from bus until closed
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	bus     *Bus
+	types   map[Type]bool // nil means all types
+	dropped atomic.Uint64
+}
+
+func NewBus() *Bus {
+	b := &Bus{
+		stats:       make(map[Type]*typeStats, len(Types)),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+	for _, t := range Types {
+		b.stats[t] = &typeStats{}
+	}
+	return b
+}
+
+// Subscribed reports whether anyone listens to given type of events.
+// Hot loops check it before building payload to avoid allocating it for nobody.
+func (b *Bus) Subscribed(eventType Type) bool {
+	if b == nil {
+		return false
+	}
+	stats, ok := b.stats[eventType]
+	return ok && stats.subscribers.Load() > 0
+}
+
+// Publish sends event to all subscribers of its type
+func (b *Bus) Publish(eventType Type, data any) {
+	if b == nil {
+		return
+	}
+	stats := b.stats[eventType]
+	if stats != nil {
+		stats.published.Add(1)
+		if stats.subscribers.Load() == 0 {
+			return
+		}
+	}
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscribers {
+		if s.types != nil && !s.types[eventType] {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			s.dropped.Add(1)
+			if stats != nil {
+				stats.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Subscribe creates subscription buffering up to given number of events of given types.
+// Without types, subscription receives every event.
+func (b *Bus) Subscribe(buffer int, types ...Type) *Subscription {
+	ch := make(chan Event, buffer)
+	s := &Subscription{C: ch, ch: ch, bus: b}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[s] = struct{}{}
+	b.countSubscriber(s, 1)
+	return s
+}
+
+// caller has to hold lock
+func (b *Bus) countSubscriber(s *Subscription, delta int64) {
+	for t, stats := range b.stats {
+		if s.types == nil || s.types[t] {
+			stats.subscribers.Add(delta)
+		}
+	}
+}
+
+// Dropped returns number of events subscription missed because its buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivery and closes channel of subscription
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		s.bus.countSubscriber(s, -1)
+		close(s.ch)
+	}
+}
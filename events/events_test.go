@@ -0,0 +1,65 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestPublishAndDrop(t *testing.T) {
+	bus := NewBus()
+	subscription := bus.Subscribe(1)
+
+	bus.Publish(PlayerOnline, Player{Xuid: "A"})
+	bus.Publish(PlayerOnline, Player{Xuid: "B"})
+
+	event := <-subscription.C
+	if event.Type != PlayerOnline || event.Data.(Player).Xuid != "A" {
+		t.Errorf("Expected first event delivered, got %v", event)
+	}
+	select {
+	case event := <-subscription.C:
+		t.Errorf("Expected second event dropped for full subscriber, got %v", event)
+	default:
+	}
+
+	subscription.Close()
+	subscription.Close()
+	if _, ok := <-subscription.C; ok {
+		t.Errorf("Expected channel closed after Close")
+	}
+	bus.Publish(PlayerOffline, Player{Xuid: "A"})
+
+	var nilBus *Bus
+	nilBus.Publish(PlayerOffline, nil)
+}
+
+func TestSubscriptionTypes(t *testing.T) {
+	bus := NewBus()
+	if bus.Subscribed(PacketReceived) {
+		t.Errorf("Expected no subscribers on new bus")
+	}
+	packets := bus.Subscribe(1, PacketReceived)
+	all := bus.Subscribe(4)
+	if !bus.Subscribed(PacketReceived) || bus.stats[ValidationFailed].subscribers.Load() != 1 {
+		t.Errorf("Expected typed and catch-all subscribers counted")
+	}
+
+	bus.Publish(ValidationFailed, ValidationFailure{Reason: "too small"})
+	bus.Publish(PacketReceived, Packet{Size: 1})
+	bus.Publish(PacketReceived, Packet{Size: 2})
+
+	if event := <-packets.C; event.Data.(Packet).Size != 1 {
+		t.Errorf("Expected first packet, got %v", event)
+	}
+	if packets.Dropped() != 1 || bus.stats[PacketReceived].dropped.Load() != 1 {
+		t.Errorf("Expected one dropped packet, got %d", packets.Dropped())
+	}
+	if len(all.C) != 3 {
+		t.Errorf("Expected catch-all subscriber to get every event, got %d", len(all.C))
+	}
+
+	packets.Close()
+	all.Close()
+	if bus.Subscribed(PacketReceived) {
+		t.Errorf("Expected no subscribers after closing")
+	}
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	publishedDesc = prometheus.NewDesc(
+		"events_published_total",
+		"Total number of events published on bus",
+		[]string{"type"}, nil,
+	)
+	droppedDesc = prometheus.NewDesc(
+		"events_dropped_total",
+		"Total number of events dropped for subscribers with full buffer",
+		[]string{"type"}, nil,
+	)
+	subscribersDesc = prometheus.NewDesc(
+		"events_subscribers",
+		"Number of subscribers receiving events of type",
+		[]string{"type"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (b *Bus) Describe(ch chan<- *prometheus.Desc) {
+	ch <- publishedDesc
+	ch <- droppedDesc
+	ch <- subscribersDesc
+}
+
+// Collect implements prometheus.Collector
+func (b *Bus) Collect(ch chan<- prometheus.Metric) {
+	for _, t := range Types {
+		stats := b.stats[t]
+		ch <- prometheus.MustNewConstMetric(publishedDesc, prometheus.CounterValue, float64(stats.published.Load()), string(t))
+		ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(stats.dropped.Load()), string(t))
+		ch <- prometheus.MustNewConstMetric(subscribersDesc, prometheus.GaugeValue, float64(stats.subscribers.Load()), string(t))
+	}
+}
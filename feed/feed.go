@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/events"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// events shown on feed, packet level events stay internal
+var publicTypes = []events.Type{
+	events.ServerStarted, events.ServerStopped,
+	events.MaintenanceScheduled, events.MaintenanceCancelled,
+	events.PlayerOnline, events.PlayerOffline,
+	events.TerritoryChanged,
+}
+
+// Feed streams events from bus to http clients. Every client gets its own subscription.
+type Feed struct {
+	bus          *events.Bus
+	buffer       int
+	heartbeat    time.Duration
+	listPlayers  bool
+	maxTotal     int
+	maxPerClient int
+
+	mu          sync.Mutex
+	subscribers int
+	perClient   map[string]int // by client address
+}
+
+func New(bus *events.Bus, cfg config.FeedConfig) *Feed {
+	return &Feed{
+		bus:          bus,
+		buffer:       cfg.SubscriberBuffer,
+		heartbeat:    time.Duration(cfg.HeartbeatSeconds) * time.Second,
+		listPlayers:  cfg.ListPlayers,
+		maxTotal:     cfg.MaxSubscribers,
+		maxPerClient: cfg.MaxSubscribersPerClient,
+		perClient:    make(map[string]int),
+	}
+}
+
+// Handler serves server-sent events at / and websocket at /ws,
+// has to be mounted with its path prefix stripped
+func (f *Feed) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", f.limited(f.serveSSE))
+	mux.HandleFunc("GET /ws", f.limited(f.serveWebSocket))
+	return mux
+}
+
+// rejects connections beyond subscriber limits, connection counts as subscriber until handler returns
+func (f *Feed) limited(serve http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		if status := f.acquire(client); status != http.StatusOK {
+			http.Error(w, "too many subscribers", status)
+			return
+		}
+		defer f.release(client)
+		serve(w, r)
+	}
+}
+
+// counts new subscriber of client, returns error status when limit is reached
+func (f *Feed) acquire(client string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case f.maxPerClient > 0 && f.perClient[client] >= f.maxPerClient:
+		return http.StatusTooManyRequests
+	case f.maxTotal > 0 && f.subscribers >= f.maxTotal:
+		return http.StatusServiceUnavailable
+	}
+	f.subscribers++
+	f.perClient[client]++
+	return http.StatusOK
+}
+
+func (f *Feed) release(client string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subscribers--
+	if f.perClient[client]--; f.perClient[client] <= 0 {
+		delete(f.perClient, client)
+	}
+}
+
+func (f *Feed) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	subscription := f.bus.Subscribe(f.buffer, publicTypes...)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(f.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			// comment line keeps proxies from closing idle connection
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			data, err := f.encode(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// encodes event as json, stripping player identity unless players are public
+func (f *Feed) encode(event events.Event) ([]byte, error) {
+	if _, isPlayer := event.Data.(events.Player); isPlayer && !f.listPlayers {
+		event.Data = nil
+	}
+	return json.Marshal(event)
+}
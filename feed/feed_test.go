@@ -0,0 +1,128 @@
+package feed
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/events"
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(bus *events.Bus, listPlayers bool) *httptest.Server {
+	f := New(bus, config.FeedConfig{SubscriberBuffer: 8, HeartbeatSeconds: 60, ListPlayers: listPlayers})
+	return httptest.NewServer(f.Handler())
+}
+
+// publishes until subscriber of handler shows up, subscription happens after request is accepted
+func publishUntilRead(bus *events.Bus, done <-chan struct{}, eventType events.Type, data any) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(10 * time.Millisecond):
+			bus.Publish(eventType, data)
+		}
+	}
+}
+
+func TestServerSentEvents(t *testing.T) {
+	bus := events.NewBus()
+	server := newTestServer(bus, false)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed connecting to feed: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %q", contentType)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go publishUntilRead(bus, done, events.PlayerOnline, events.Player{Xuid: "secret"})
+
+	reader := bufio.NewReader(response.Body)
+	line, _ := reader.ReadString('\n')
+	if line != "event: player_online\n" {
+		t.Errorf("Expected player_online event, got %q", line)
+	}
+	line, _ = reader.ReadString('\n')
+	if !strings.HasPrefix(line, "data: ") || strings.Contains(line, "secret") {
+		t.Errorf("Expected data without xuid, got %q", line)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	bus := events.NewBus()
+	server := newTestServer(bus, true)
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed connecting to feed: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected upgrade with RFC accept key, got %d %q", response.StatusCode, response.Header.Get("Sec-WebSocket-Accept"))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go publishUntilRead(bus, done, events.PlayerOnline, events.Player{Xuid: "A"})
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(response.Body, header); err != nil || header[0] != finalFrame|opText {
+		t.Fatalf("Expected text frame, got %x %v", header, err)
+	}
+	payload := make([]byte, header[1]&0x7F)
+	io.ReadFull(response.Body, payload)
+	if !strings.Contains(string(payload), `"xuid":"A"`) {
+		t.Errorf("Expected player in payload, got %s", payload)
+	}
+}
+
+func TestWebSocketRejectsPlainRequest(t *testing.T) {
+	server := newTestServer(events.NewBus(), false)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatalf("Failed connecting to feed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected bad request without upgrade, got %d", response.StatusCode)
+	}
+}
+
+func TestSubscriberLimits(t *testing.T) {
+	f := New(events.NewBus(), config.FeedConfig{MaxSubscribers: 3, MaxSubscribersPerClient: 2})
+
+	for range 2 {
+		if status := f.acquire("10.0.0.1"); status != http.StatusOK {
+			t.Fatalf("Expected subscriber accepted, got status %d", status)
+		}
+	}
+	if status := f.acquire("10.0.0.1"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected per client limit, got status %d", status)
+	}
+	f.acquire("10.0.0.2")
+	if status := f.acquire("10.0.0.3"); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected global limit, got status %d", status)
+	}
+	f.release("10.0.0.1")
+	if status := f.acquire("10.0.0.3"); status != http.StatusOK {
+		t.Errorf("Expected subscriber accepted after release, got status %d", status)
+	}
+}
@@ -0,0 +1,209 @@
+package feed
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minimal server side of RFC 6455, feed only ever sends text messages and answers control frames
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opText  byte = 0x1
+	opClose byte = 0x8
+	opPing  byte = 0x9
+	opPong  byte = 0xA
+
+	finalFrame = 0x80
+
+	// control frames can't carry more than this, anything bigger from client is protocol error
+	maxControlPayload = 125
+	// clients have nothing to tell us, bigger messages are refused
+	maxClientPayload = 4096
+
+	writeTimeout = 10 * time.Second
+)
+
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex // guards writes
+}
+
+func (f *Feed) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "websocket upgrade expected", http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ws := &websocketConn{conn: conn, reader: buffered.Reader}
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if err := ws.write([]byte(response)); err != nil {
+		return
+	}
+
+	subscription := f.bus.Subscribe(f.buffer, publicTypes...)
+	defer subscription.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.readLoop()
+	}()
+
+	heartbeat := time.NewTicker(f.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := ws.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		case event, ok := <-subscription.C:
+			if !ok {
+				ws.writeFrame(opClose, nil)
+				return
+			}
+			data, err := f.encode(event)
+			if err != nil {
+				continue
+			}
+			if err := ws.writeFrame(opText, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reads client frames until connection is closed, answering pings and close requests
+func (ws *websocketConn) readLoop() {
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case opClose:
+			ws.writeFrame(opClose, payload)
+			return
+		case opPing:
+			if ws.writeFrame(opPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (ws *websocketConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	// clients must mask their frames
+	if !masked || length > maxClientPayload || (opcode >= opClose && length > maxControlPayload) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// writes single unmasked frame, server frames are never fragmented
+func (ws *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, finalFrame|opcode)
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	return ws.write(append(frame, payload...))
+}
+
+func (ws *websocketConn) write(data []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := ws.conn.Write(data)
+	return err
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// checks comma separated header values for token, ignoring case
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, candidate := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(candidate), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
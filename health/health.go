@@ -0,0 +1,109 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// State of service listener
+type State string
+
+const (
+	Starting State = "starting" // configured, not bound yet
+	Bound    State = "bound"    // socket bound, not handling packets yet
+	Serving  State = "serving"
+	Failed   State = "failed"
+	Stopped  State = "stopped"
+)
+
+var States = []State{Starting, Bound, Serving, Failed, Stopped}
+
+// Service is state of single service, by its label
+type Service struct {
+	Label string    `json:"label"`
+	State State     `json:"state"`
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// Registry keeps state of every enabled service. Safe for concurrent use,
+// setting state on nil registry does nothing.
+type Registry struct {
+	mu       sync.RWMutex
+	services map[string]*Service
+}
+
+func NewRegistry() *Registry {
+	return &Registry{services: make(map[string]*Service)}
+}
+
+// Register adds service in starting state. Services not registered are not reported.
+func (r *Registry) Register(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[label] = &Service{Label: label, State: Starting, Since: time.Now()}
+}
+
+// Set changes state of service, err is kept as reason of failure
+func (r *Registry) Set(label string, state State, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.services[label]
+	if !ok {
+		s = &Service{Label: label}
+		r.services[label] = s
+	}
+	s.State = state
+	s.Since = time.Now()
+	s.Error = ""
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// Get returns state of service
+func (r *Registry) Get(label string) (Service, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.services[label]
+	if !ok {
+		return Service{}, false
+	}
+	return *s, true
+}
+
+// Snapshot returns states of all services ordered by label
+func (r *Registry) Snapshot() []Service {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	services := make([]Service, 0, len(r.services))
+	for _, s := range r.services {
+		services = append(services, *s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Label < services[j].Label })
+	return services
+}
+
+// Live reports whether no service has failed. Restarting the process is the way to recover a failed listener.
+func (r *Registry) Live() bool {
+	for _, s := range r.Snapshot() {
+		if s.State == Failed {
+			return false
+		}
+	}
+	return true
+}
+
+// Ready reports whether every service is serving
+func (r *Registry) Ready() bool {
+	for _, s := range r.Snapshot() {
+		if s.State != Serving {
+			return false
+		}
+	}
+	return true
+}
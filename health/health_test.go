@@ -0,0 +1,90 @@
+package health
+
+import (
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func get(handler http.HandlerFunc) (int, report) {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body report
+	json.NewDecoder(recorder.Body).Decode(&body)
+	return recorder.Code, body
+}
+
+func TestReadyOnlyWhenAllServing(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("status")
+	registry.Register("echo")
+	checks := NewChecks(registry, nil, false)
+
+	if code, _ := get(checks.Ready); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before servers start, got %d", code)
+	}
+	if code, _ := get(checks.Live); code != http.StatusOK {
+		t.Errorf("Expected starting servers to be live, got %d", code)
+	}
+
+	registry.Set("status", Serving, nil)
+	registry.Set("echo", Bound, nil)
+	if code, _ := get(checks.Ready); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while echo is only bound, got %d", code)
+	}
+
+	registry.Set("echo", Serving, nil)
+	code, body := get(checks.Ready)
+	if code != http.StatusOK || body.Status != "ok" {
+		t.Errorf("Expected 200 ok, got %d %s", code, body.Status)
+	}
+	if len(body.Services) != 2 || body.Services[0].Label != "echo" {
+		t.Errorf("Expected services ordered by label, got %+v", body.Services)
+	}
+}
+
+func TestFailedServiceIsNotLive(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("status")
+	registry.Set("status", Failed, errors.New("address already in use"))
+
+	code, body := get(NewChecks(registry, nil, false).Live)
+	if code != http.StatusServiceUnavailable || body.Status != "failed" {
+		t.Errorf("Expected 503 failed, got %d %s", code, body.Status)
+	}
+	if body.Services[0].Error != "address already in use" {
+		t.Errorf("Expected bind error in body, got %q", body.Services[0].Error)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	schedule, err := maintenance.Open(storage.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := schedule.Add(now.Add(-time.Minute), now.Add(time.Hour), "test"); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry()
+	registry.Register("status")
+	registry.Set("status", Serving, nil)
+
+	code, body := get(NewChecks(registry, schedule, false).Ready)
+	if code != http.StatusOK || !body.Maintenance {
+		t.Errorf("Expected ready with maintenance reported, got %d %v", code, body.Maintenance)
+	}
+	if code, _ := get(NewChecks(registry, schedule, true).Ready); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 during maintenance, got %d", code)
+	}
+}
+
+func TestNilRegistryIgnoresSet(t *testing.T) {
+	var registry *Registry
+	registry.Set("status", Serving, nil)
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/maintenance"
+	"net/http"
+	"time"
+)
+
+type report struct {
+	Status      string    `json:"status"` // "ok", "failed" or "not_ready"
+	Maintenance bool      `json:"maintenance"`
+	Services    []Service `json:"services"`
+}
+
+// Checks serves liveness and readiness of services for orchestrators and load balancers.
+// Both answer 200 when healthy and 503 otherwise, with states of all services in body.
+type Checks struct {
+	registry           *Registry
+	maintenance        *maintenance.Schedule // nil when maintenance scheduling is disabled
+	unreadyMaintenance bool
+}
+
+// NewChecks creates checks of registry. With unreadyDuringMaintenance, active maintenance makes instance not ready.
+func NewChecks(registry *Registry, schedule *maintenance.Schedule, unreadyDuringMaintenance bool) *Checks {
+	return &Checks{registry: registry, maintenance: schedule, unreadyMaintenance: unreadyDuringMaintenance}
+}
+
+// Live answers whether process should keep running
+func (c *Checks) Live(w http.ResponseWriter, r *http.Request) {
+	c.write(w, c.registry.Live(), "failed")
+}
+
+// Ready answers whether instance should receive traffic
+func (c *Checks) Ready(w http.ResponseWriter, r *http.Request) {
+	ready := c.registry.Ready() && !(c.unreadyMaintenance && c.inMaintenance())
+	c.write(w, ready, "not_ready")
+}
+
+func (c *Checks) inMaintenance() bool {
+	return c.maintenance != nil && c.maintenance.Active(time.Now())
+}
+
+func (c *Checks) write(w http.ResponseWriter, healthy bool, unhealthyStatus string) {
+	response := report{Status: "ok", Maintenance: c.inMaintenance(), Services: c.registry.Snapshot()}
+	code := http.StatusOK
+	if !healthy {
+		response.Status = unhealthyStatus
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	admin.WriteJSON(w, code, response)
+}
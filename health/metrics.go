@@ -0,0 +1,29 @@
+package health
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var stateDesc = prometheus.NewDesc(
+	"service_state",
+	"State of service listener, 1 for the current state",
+	[]string{"label", "state"}, nil,
+)
+
+// Describe implements prometheus.Collector
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- stateDesc
+}
+
+// Collect implements prometheus.Collector
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range r.Snapshot() {
+		for _, state := range States {
+			value := 0.0
+			if s.State == state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, value, s.Label, string(state))
+		}
+	}
+}
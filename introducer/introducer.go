@@ -0,0 +1,246 @@
+package introducer
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownPeer    = errors.New("peer not registered")
+	ErrUnknownAttempt = errors.New("punch attempt not found")
+	ErrXuidTaken      = errors.New("xuid registered from another address")
+	ErrBanned         = errors.New("player is banned")
+)
+
+// peer is client that registered its public endpoint
+type peer struct {
+	xuid     string
+	addr     *net.UDPAddr
+	lastSeen time.Time
+}
+
+// attempt tracks single hole punch between host and joining player
+type attempt struct {
+	id      uint32
+	host    peer
+	joiner  peer
+	started time.Time
+}
+
+// Outgoing is a message the server has to send, not necessarily to the client that sent the request
+type Outgoing struct {
+	Addr  *net.UDPAddr
+	Frame protocol.Frame
+}
+
+// RelayAllocator hands out relay sessions for peers that failed to connect directly
+type RelayAllocator interface {
+	// Allocate returns endpoint of relay and token both peers use to join the session
+	Allocate(xuids []string, now time.Time) (protocol.Endpoint, uint32, error)
+}
+
+// StaticRelay points failed peers to fixed relay endpoint without allocating session
+type StaticRelay struct {
+	Endpoint protocol.Endpoint
+}
+
+func (r StaticRelay) Allocate(xuids []string, now time.Time) (protocol.Endpoint, uint32, error) {
+	return r.Endpoint, 0, nil
+}
+
+// Introducer learns public endpoints of clients and brokers hole punching between them.
+// Safe for concurrent use.
+type Introducer struct {
+	mu                  sync.Mutex
+	punchTimeout        time.Duration
+	registrationTimeout time.Duration
+	relay               RelayAllocator
+	peers               map[string]peer
+	attempts            map[uint32]*attempt
+	stats               Stats
+	banned              func(xuid string) bool
+}
+
+// Stats counts outcomes of punch attempts
+type Stats struct {
+	Started uint64
+	Punched uint64
+	Relayed uint64
+	Failed  uint64
+	Pending int
+	Known   int
+}
+
+// New creates introducer. Attempts without success reported within punchTimeout fall back to relay,
+// registrations not refreshed within registrationTimeout are forgotten. relay can be nil.
+func New(punchTimeout time.Duration, registrationTimeout time.Duration, relay RelayAllocator) *Introducer {
+	return &Introducer{
+		punchTimeout:        punchTimeout,
+		registrationTimeout: registrationTimeout,
+		relay:               relay,
+		peers:               make(map[string]peer),
+		attempts:            make(map[uint32]*attempt),
+	}
+}
+
+// SetBanned sets check of banned players, who can't register or connect
+func (in *Introducer) SetBanned(banned func(xuid string) bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.banned = banned
+}
+
+// Register records public endpoint of client as seen by server.
+// Xuid registered from another address is refused until that registration expires,
+// so endpoint of player can't be taken over by anyone knowing their xuid.
+func (in *Introducer) Register(xuid string, addr *net.UDPAddr, now time.Time) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	_, err := in.register(xuid, addr, now)
+	return err
+}
+
+// Connect starts punch attempt of joiner towards host. Both get the other's endpoint.
+func (in *Introducer) Connect(joinerXuid string, joinerAddr *net.UDPAddr, hostXuid string, now time.Time) ([]Outgoing, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	host, ok := in.peers[hostXuid]
+	if !ok {
+		return nil, ErrUnknownPeer
+	}
+	joiner, err := in.register(joinerXuid, joinerAddr, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := in.newAttemptID()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &attempt{
+		id:      id,
+		host:    host,
+		joiner:  joiner,
+		started: now,
+	}
+	in.attempts[a.id] = a
+	in.stats.Started++
+
+	return []Outgoing{
+		punchMessage(a.id, host.addr, joiner),
+		punchMessage(a.id, joiner.addr, host),
+	}, nil
+}
+
+// Report handles punch result sent from address of either side. Success finishes the attempt,
+// failure makes both peers fall back to relay. Reports from anyone else are treated as unknown attempt.
+func (in *Introducer) Report(attemptID uint32, addr *net.UDPAddr, success bool, now time.Time) ([]Outgoing, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	a, ok := in.attempts[attemptID]
+	if !ok || !(sameAddr(a.host.addr, addr) || sameAddr(a.joiner.addr, addr)) {
+		return nil, ErrUnknownAttempt
+	}
+	delete(in.attempts, attemptID)
+
+	if success {
+		in.stats.Punched++
+		return nil, nil
+	}
+	return in.fallback(a, now), nil
+}
+
+// Expire falls back to relay for attempts that timed out and forgets stale registrations.
+// Returned messages have to be sent by caller.
+func (in *Introducer) Expire(now time.Time) []Outgoing {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var outgoing []Outgoing
+	for id, a := range in.attempts {
+		if now.Sub(a.started) > in.punchTimeout {
+			delete(in.attempts, id)
+			outgoing = append(outgoing, in.fallback(a, now)...)
+		}
+	}
+	for xuid, p := range in.peers {
+		if now.Sub(p.lastSeen) > in.registrationTimeout {
+			delete(in.peers, xuid)
+		}
+	}
+	return outgoing
+}
+
+// Stats returns counters of attempt outcomes
+func (in *Introducer) Stats() Stats {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	stats := in.stats
+	stats.Pending = len(in.attempts)
+	stats.Known = len(in.peers)
+	return stats
+}
+
+// caller has to hold lock
+func (in *Introducer) register(xuid string, addr *net.UDPAddr, now time.Time) (peer, error) {
+	if in.banned != nil && in.banned(xuid) {
+		return peer{}, ErrBanned
+	}
+	if existing, ok := in.peers[xuid]; ok && !sameAddr(existing.addr, addr) && now.Sub(existing.lastSeen) <= in.registrationTimeout {
+		return peer{}, ErrXuidTaken
+	}
+	p := peer{xuid: xuid, addr: addr, lastSeen: now}
+	in.peers[xuid] = p
+	return p, nil
+}
+
+// attempt ids are random, so they can't be guessed by third party. caller has to hold lock
+func (in *Introducer) newAttemptID() (uint32, error) {
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		id := binary.LittleEndian.Uint32(buf[:])
+		if _, taken := in.attempts[id]; id != 0 && !taken {
+			return id, nil
+		}
+	}
+}
+
+func sameAddr(a *net.UDPAddr, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.IP.Equal(b.IP) && a.Port == b.Port
+}
+
+// caller has to hold lock
+func (in *Introducer) fallback(a *attempt, now time.Time) []Outgoing {
+	if in.relay == nil {
+		in.stats.Failed++
+		return []Outgoing{
+			failedMessage(a.id, a.host.addr),
+			failedMessage(a.id, a.joiner.addr),
+		}
+	}
+
+	endpoint, token, err := in.relay.Allocate([]string{a.host.xuid, a.joiner.xuid}, now)
+	if err != nil {
+		in.stats.Failed++
+		return []Outgoing{
+			failedMessage(a.id, a.host.addr),
+			failedMessage(a.id, a.joiner.addr),
+		}
+	}
+
+	in.stats.Relayed++
+	return []Outgoing{
+		relayMessage(a.id, a.host.addr, endpoint, token),
+		relayMessage(a.id, a.joiner.addr, endpoint, token),
+	}
+}
@@ -0,0 +1,135 @@
+package introducer
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	hostAddr   = &net.UDPAddr{IP: net.ParseIP("203.0.113.10"), Port: 40000}
+	joinerAddr = &net.UDPAddr{IP: net.ParseIP("198.51.100.20"), Port: 50000}
+	relayAddr  = &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1232}
+)
+
+func startAttempt(t *testing.T, in *Introducer, now time.Time) uint32 {
+	in.Register("HOST", hostAddr, now)
+	outgoing, err := in.Connect("JOINER", joinerAddr, "HOST", now)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if len(outgoing) != 2 {
+		t.Fatalf("Expected punch message to both peers, got %d messages", len(outgoing))
+	}
+
+	var toHost PunchMessage
+	outgoing[0].Frame.DecodePayload(&toHost)
+	if outgoing[0].Addr != hostAddr || outgoing[0].Frame.Opcode != OpPunch {
+		t.Errorf("Expected first punch message to go to host")
+	}
+	if peer := toHost.PeerEndpoint.UDPAddr(); !peer.IP.Equal(joinerAddr.IP) || peer.Port != joinerAddr.Port {
+		t.Errorf("Expected host to learn joiner endpoint, got %v", peer)
+	}
+	return toHost.AttemptID
+}
+
+func TestConnectUnknownHost(t *testing.T) {
+	in := New(time.Second, time.Minute, nil)
+	if _, err := in.Connect("JOINER", joinerAddr, "HOST", time.Now()); err != ErrUnknownPeer {
+		t.Errorf("Expected ErrUnknownPeer, got %v", err)
+	}
+}
+
+func TestPunchSuccess(t *testing.T) {
+	in := New(time.Second, time.Minute, nil)
+	now := time.Now()
+	id := startAttempt(t, in, now)
+
+	outgoing, err := in.Report(id, hostAddr, true, now)
+	if err != nil || len(outgoing) != 0 {
+		t.Errorf("Expected success to finish silently, got %v (err: %v)", outgoing, err)
+	}
+	if _, err := in.Report(id, hostAddr, true, now); err != ErrUnknownAttempt {
+		t.Errorf("Expected finished attempt to be forgotten, got %v", err)
+	}
+	if stats := in.Stats(); stats.Punched != 1 || stats.Pending != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestPunchFailureFallsBackToRelay(t *testing.T) {
+	relay := StaticRelay{Endpoint: protocol.EndpointFromUDPAddr(relayAddr)}
+	in := New(time.Second, time.Minute, relay)
+	now := time.Now()
+	id := startAttempt(t, in, now)
+
+	outgoing, _ := in.Report(id, hostAddr, false, now)
+	if len(outgoing) != 2 {
+		t.Fatalf("Expected relay message to both peers, got %d", len(outgoing))
+	}
+	for _, msg := range outgoing {
+		var relayMsg UseRelayMessage
+		msg.Frame.DecodePayload(&relayMsg)
+		if msg.Frame.Opcode != OpUseRelay || relayMsg.AttemptID != id || relayMsg.RelayEndpoint.UDPAddr().Port != relayAddr.Port {
+			t.Errorf("Unexpected relay message: opcode %#x %+v", msg.Frame.Opcode, relayMsg)
+		}
+	}
+}
+
+func TestPunchTimeout(t *testing.T) {
+	in := New(time.Second, time.Minute, nil)
+	now := time.Now()
+	startAttempt(t, in, now)
+
+	if outgoing := in.Expire(now.Add(500 * time.Millisecond)); len(outgoing) != 0 {
+		t.Errorf("Expected attempt to still be pending")
+	}
+	outgoing := in.Expire(now.Add(2 * time.Second))
+	if len(outgoing) != 2 || outgoing[0].Frame.Opcode != OpPunchFailed {
+		t.Errorf("Expected both peers told about failure without relay, got %v", outgoing)
+	}
+	if stats := in.Stats(); stats.Failed != 1 {
+		t.Errorf("Expected failed attempt counted, got %+v", stats)
+	}
+
+	// registrations go stale too
+	in.Expire(now.Add(2 * time.Minute))
+	if stats := in.Stats(); stats.Known != 0 {
+		t.Errorf("Expected stale peers forgotten, got %d", stats.Known)
+	}
+}
+
+func TestRegistrationBoundToAddress(t *testing.T) {
+	in := New(time.Second, time.Minute, nil)
+	now := time.Now()
+	other := &net.UDPAddr{IP: net.ParseIP("192.0.2.99"), Port: 1234}
+
+	in.Register("HOST", hostAddr, now)
+	if err := in.Register("HOST", other, now); err != ErrXuidTaken {
+		t.Errorf("Expected ErrXuidTaken for registration from other address, got %v", err)
+	}
+	if _, err := in.Connect("HOST", other, "HOST", now); err != ErrXuidTaken {
+		t.Errorf("Expected ErrXuidTaken for connect as registered xuid, got %v", err)
+	}
+	if err := in.Register("HOST", hostAddr, now.Add(30*time.Second)); err != nil {
+		t.Errorf("Expected refresh from same address, got %v", err)
+	}
+	if err := in.Register("HOST", other, now.Add(2*time.Minute)); err != nil {
+		t.Errorf("Expected expired registration to be replaceable, got %v", err)
+	}
+}
+
+func TestReportOnlyFromPeers(t *testing.T) {
+	in := New(time.Second, time.Minute, nil)
+	now := time.Now()
+	id := startAttempt(t, in, now)
+	other := &net.UDPAddr{IP: net.ParseIP("192.0.2.99"), Port: 1234}
+
+	if _, err := in.Report(id, other, true, now); err != ErrUnknownAttempt {
+		t.Errorf("Expected report from third party to be refused, got %v", err)
+	}
+	if _, err := in.Report(id, joinerAddr, true, now); err != nil {
+		t.Errorf("Expected report from joiner, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package introducer
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/status"
+	"errors"
+	"net"
+	"time"
+)
+
+// Opcodes of introducer service. Requests are sent by clients, responses by server.
+const (
+	OpRegister    uint8 = 0x01
+	OpConnect     uint8 = 0x02
+	OpPunchResult uint8 = 0x03
+
+	OpRegistered  uint8 = 0x81
+	OpPunch       uint8 = 0x82
+	OpUseRelay    uint8 = 0x83
+	OpPunchFailed uint8 = 0x84
+	OpError       uint8 = 0xFF
+)
+
+// Error codes sent in OpError response
+const (
+	ErrorCodeMalformed uint8 = iota + 1
+	ErrorCodeUnknownPeer
+	ErrorCodeUnknownAttempt
+	ErrorCodeUnknownOpcode
+	ErrorCodeXuidTaken
+	ErrorCodeBanned
+)
+
+// 15 bytes
+type RegisterRequest struct {
+	Xuid [15]byte
+}
+
+// 18 bytes, public endpoint of client as seen by server
+type RegisteredResponse struct {
+	Endpoint protocol.Endpoint
+}
+
+// 30 bytes
+type ConnectRequest struct {
+	Xuid     [15]byte
+	HostXuid [15]byte
+}
+
+// 37 bytes, tells client whom to send punch packets to
+type PunchMessage struct {
+	AttemptID    uint32
+	PeerXuid     [15]byte
+	PeerEndpoint protocol.Endpoint
+}
+
+// 5 bytes
+type PunchResultRequest struct {
+	AttemptID uint32
+	Success   uint8
+}
+
+// 26 bytes, relay to use after punching failed. Token identifies relay session.
+type UseRelayMessage struct {
+	AttemptID     uint32
+	RelayEndpoint protocol.Endpoint
+	Token         uint32
+}
+
+// 4 bytes
+type PunchFailedMessage struct {
+	AttemptID uint32
+}
+
+// 2 bytes
+type ErrorResponse struct {
+	RequestOpcode uint8
+	Code          uint8
+}
+
+// HandleFrame executes introducer request. Returned messages may be addressed to other peers than the requester.
+func (in *Introducer) HandleFrame(frame protocol.Frame, addr *net.UDPAddr, now time.Time) []Outgoing {
+	switch frame.Opcode {
+	case OpRegister:
+		var request RegisterRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorMessage(addr, frame.Opcode, ErrorCodeMalformed)
+		}
+		if err := in.Register(status.XuidString(request.Xuid), addr, now); err != nil {
+			return errorMessage(addr, frame.Opcode, errorCode(err))
+		}
+		return []Outgoing{message(addr, OpRegistered, RegisteredResponse{Endpoint: protocol.EndpointFromUDPAddr(addr)})}
+
+	case OpConnect:
+		var request ConnectRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorMessage(addr, frame.Opcode, ErrorCodeMalformed)
+		}
+		outgoing, err := in.Connect(status.XuidString(request.Xuid), addr, status.XuidString(request.HostXuid), now)
+		if err != nil {
+			return errorMessage(addr, frame.Opcode, errorCode(err))
+		}
+		return outgoing
+
+	case OpPunchResult:
+		var request PunchResultRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorMessage(addr, frame.Opcode, ErrorCodeMalformed)
+		}
+		outgoing, err := in.Report(request.AttemptID, addr, request.Success != 0, now)
+		if err != nil {
+			return errorMessage(addr, frame.Opcode, errorCode(err))
+		}
+		return outgoing
+
+	default:
+		return errorMessage(addr, frame.Opcode, ErrorCodeUnknownOpcode)
+	}
+}
+
+func errorCode(err error) uint8 {
+	switch {
+	case errors.Is(err, ErrUnknownAttempt):
+		return ErrorCodeUnknownAttempt
+	case errors.Is(err, ErrXuidTaken):
+		return ErrorCodeXuidTaken
+	case errors.Is(err, ErrBanned):
+		return ErrorCodeBanned
+	default:
+		return ErrorCodeUnknownPeer
+	}
+}
+
+func punchMessage(attemptID uint32, to *net.UDPAddr, other peer) Outgoing {
+	msg := PunchMessage{
+		AttemptID:    attemptID,
+		PeerEndpoint: protocol.EndpointFromUDPAddr(other.addr),
+	}
+	copy(msg.PeerXuid[:], other.xuid)
+	return message(to, OpPunch, msg)
+}
+
+func relayMessage(attemptID uint32, to *net.UDPAddr, relay protocol.Endpoint, token uint32) Outgoing {
+	return message(to, OpUseRelay, UseRelayMessage{AttemptID: attemptID, RelayEndpoint: relay, Token: token})
+}
+
+func failedMessage(attemptID uint32, to *net.UDPAddr) Outgoing {
+	return message(to, OpPunchFailed, PunchFailedMessage{AttemptID: attemptID})
+}
+
+func errorMessage(to *net.UDPAddr, requestOpcode uint8, code uint8) []Outgoing {
+	return []Outgoing{message(to, OpError, ErrorResponse{RequestOpcode: requestOpcode, Code: code})}
+}
+
+// all messages are fixed size structs, encoding them can't fail
+func message(to *net.UDPAddr, opcode uint8, payload any) Outgoing {
+	frame, _ := protocol.NewFrame(protocol.ServiceIntroducer, opcode, payload)
+	return Outgoing{Addr: to, Frame: frame}
+}
@@ -0,0 +1,53 @@
+package leaderboard
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Handler serves public leaderboards as /{kind}/{window}/{metric}?page=&size=&season=,
+// has to be mounted with its path prefix stripped
+func (b *Board) Handler(defaultPageSize int, maxPageSize int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{kind}/{window}/{metric}", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		page, ok := intParam(query.Get("page"), 1)
+		if !ok || page < 1 {
+			admin.WriteError(w, http.StatusBadRequest, "invalid page")
+			return
+		}
+		size, ok := intParam(query.Get("size"), defaultPageSize)
+		if !ok || size < 1 || size > maxPageSize {
+			admin.WriteError(w, http.StatusBadRequest, "page size has to be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+		season, ok := intParam(query.Get("season"), int(b.CurrentSeason()))
+		if !ok || season < 0 {
+			admin.WriteError(w, http.StatusBadRequest, "invalid season")
+			return
+		}
+
+		result, err := b.Query(Kind(r.PathValue("kind")), Window(r.PathValue("window")), Metric(r.PathValue("metric")), uint32(season), page, size, time.Now())
+		switch {
+		case errors.Is(err, ErrUnknownKind), errors.Is(err, ErrUnknownWindow), errors.Is(err, ErrUnknownMetric):
+			admin.WriteError(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.WriteJSON(w, http.StatusOK, result)
+		}
+	})
+	return mux
+}
+
+// parses optional integer query parameter
+func intParam(value string, fallback int) (int, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
@@ -0,0 +1,335 @@
+package leaderboard
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/results"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/war"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kind of ranked subjects
+type Kind string
+
+const (
+	Players Kind = "players" // ranked by xuid
+	Squads  Kind = "squads"  // ranked by tag
+)
+
+var Kinds = []Kind{Players, Squads}
+
+// Window of time leaderboard covers
+type Window string
+
+const (
+	AllTime Window = "all-time"
+	Season  Window = "season"
+	Weekly  Window = "weekly" // last 7 days, counted in whole UTC days including today
+)
+
+var Windows = []Window{AllTime, Season, Weekly}
+
+// Metric leaderboard is ranked by
+type Metric string
+
+const (
+	Wins         Metric = "wins"
+	KillRatio    Metric = "kill-ratio"
+	Contribution Metric = "contribution"
+)
+
+var Metrics = []Metric{Wins, KillRatio, Contribution}
+
+const weekDays = 7
+
+var (
+	ErrUnknownKind   = errors.New("unknown leaderboard kind")
+	ErrUnknownWindow = errors.New("unknown leaderboard window")
+	ErrUnknownMetric = errors.New("unknown leaderboard metric")
+)
+
+// totals of player or squad within window
+type totals struct {
+	Matches      int
+	Wins         int
+	Kills        int
+	Deaths       int
+	Contribution int
+}
+
+func (t *totals) add(o totals) {
+	t.Matches += o.Matches
+	t.Wins += o.Wins
+	t.Kills += o.Kills
+	t.Deaths += o.Deaths
+	t.Contribution += o.Contribution
+}
+
+func (t totals) value(metric Metric) float64 {
+	switch metric {
+	case Wins:
+		return float64(t.Wins)
+	case KillRatio:
+		return float64(t.Kills) / float64(max(t.Deaths, 1))
+	default:
+		return float64(t.Contribution)
+	}
+}
+
+// Entry is ranked player or squad. Entries with equal value share rank.
+type Entry struct {
+	Rank         int     `json:"rank"`
+	ID           string  `json:"id"`
+	Value        float64 `json:"value"`
+	Matches      int     `json:"matches"`
+	Wins         int     `json:"wins"`
+	Kills        int     `json:"kills"`
+	Deaths       int     `json:"deaths"`
+	Contribution int     `json:"contribution"`
+}
+
+// Page of leaderboard
+type Page struct {
+	Kind    Kind    `json:"kind"`
+	Window  Window  `json:"window"`
+	Metric  Metric  `json:"metric"`
+	Season  uint32  `json:"season,omitempty"`
+	Page    int     `json:"page"`
+	Size    int     `json:"size"`
+	Total   int     `json:"total"`
+	Entries []Entry `json:"entries"`
+}
+
+type rankingKey struct {
+	kind   Kind
+	window Window
+	metric Metric
+	season uint32
+	day    int64
+}
+
+// windows holds totals of one kind of subjects by their id
+type windows struct {
+	allTime map[string]*totals
+	seasons map[uint32]map[string]*totals
+	days    map[int64]map[string]*totals // by days since unix epoch
+}
+
+func newWindows() *windows {
+	return &windows{
+		allTime: make(map[string]*totals),
+		seasons: make(map[uint32]map[string]*totals),
+		days:    make(map[int64]map[string]*totals),
+	}
+}
+
+func (w *windows) add(id string, season uint32, day int64, t totals) {
+	if w.seasons[season] == nil {
+		w.seasons[season] = make(map[string]*totals)
+	}
+	if w.days[day] == nil {
+		w.days[day] = make(map[string]*totals)
+	}
+	for _, window := range []map[string]*totals{w.allTime, w.seasons[season], w.days[day]} {
+		if window[id] == nil {
+			window[id] = &totals{}
+		}
+		window[id].add(t)
+	}
+}
+
+// sums daily totals of week ending with given day
+func (w *windows) week(today int64) map[string]*totals {
+	result := make(map[string]*totals)
+	for day := today - weekDays + 1; day <= today; day++ {
+		for id, t := range w.days[day] {
+			if result[id] == nil {
+				result[id] = &totals{}
+			}
+			result[id].add(*t)
+		}
+	}
+	return result
+}
+
+// drops daily totals that fell out of weekly window
+func (w *windows) prune(now time.Time) {
+	oldest := dayOf(now) - weekDays + 1
+	for day := range w.days {
+		if day < oldest {
+			delete(w.days, day)
+		}
+	}
+}
+
+// Board keeps player and squad totals per window, updated incrementally with every recorded result.
+// Rankings are sorted on first query after change. Safe for concurrent use.
+type Board struct {
+	campaign           *war.Campaign // nil means default season
+	minMatchesForRatio int
+
+	mu       sync.Mutex
+	kinds    map[Kind]*windows
+	rankings map[rankingKey][]Entry // cleared on every change
+}
+
+// New builds leaderboards from results stored so far and keeps them updated with new ones.
+// Has to be called before results start coming in, otherwise some could be counted twice.
+func New(recorder *results.Recorder, campaign *war.Campaign, cfg config.LeaderboardConfig) (*Board, error) {
+	b := &Board{
+		campaign:           campaign,
+		minMatchesForRatio: cfg.MinMatchesForKillRatio,
+		kinds:              map[Kind]*windows{Players: newWindows(), Squads: newWindows()},
+		rankings:           make(map[rankingKey][]Entry),
+	}
+	if err := recorder.ForEach(b.Add); err != nil {
+		return nil, fmt.Errorf("loading results: %w", err)
+	}
+	for _, w := range b.kinds {
+		w.prune(time.Now())
+	}
+	recorder.AddListener(b.Add)
+	return b, nil
+}
+
+// Add counts result into all windows it belongs to. Squad takes part in match once,
+// with totals of all its members.
+func (b *Board) Add(result results.Result) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	day := dayOf(result.Reported)
+	squads := make(map[string]totals)
+	for _, p := range result.Participants {
+		t := totals{Matches: 1, Kills: p.Kills, Deaths: p.Deaths, Contribution: p.Contribution}
+		if p.Nation == result.Winner {
+			t.Wins = 1
+		}
+		b.kinds[Players].add(p.Xuid, result.Season, day, t)
+
+		if p.Squad != "" {
+			squad := squads[p.Squad]
+			squad.Matches, squad.Wins = 1, t.Wins
+			squad.Kills += t.Kills
+			squad.Deaths += t.Deaths
+			squad.Contribution += t.Contribution
+			squads[p.Squad] = squad
+		}
+	}
+	for tag, t := range squads {
+		b.kinds[Squads].add(tag, result.Season, day, t)
+	}
+	clear(b.rankings)
+}
+
+// CurrentSeason returns season used when querying season window without season number
+func (b *Board) CurrentSeason() uint32 {
+	if b.campaign != nil {
+		return b.campaign.Season()
+	}
+	return status.DefaultGameSeason
+}
+
+// Query returns page of leaderboard. Pages are numbered from 1. Season is ignored outside season window.
+func (b *Board) Query(kind Kind, window Window, metric Metric, season uint32, page int, size int, now time.Time) (Page, error) {
+	ranking, err := b.ranking(kind, window, metric, season, now)
+	if err != nil {
+		return Page{}, err
+	}
+	result := Page{Kind: kind, Window: window, Metric: metric, Page: page, Size: size, Total: len(ranking), Entries: []Entry{}}
+	if window == Season {
+		result.Season = season
+	}
+	// pages past the end are empty, checked before multiplying so that huge page can't overflow
+	if page > 0 && size > 0 && page-1 <= len(ranking)/size {
+		start := (page - 1) * size
+		result.Entries = ranking[start:min(start+size, len(ranking))]
+	}
+	return result, nil
+}
+
+// Top returns first n entries of leaderboard
+func (b *Board) Top(kind Kind, window Window, metric Metric, season uint32, n int, now time.Time) []Entry {
+	ranking, _ := b.ranking(kind, window, metric, season, now)
+	return ranking[:min(n, len(ranking))]
+}
+
+// returns whole sorted ranking, shared with cache so it must not be modified
+func (b *Board) ranking(kind Kind, window Window, metric Metric, season uint32, now time.Time) ([]Entry, error) {
+	w, ok := b.kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	switch metric {
+	case Wins, KillRatio, Contribution:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMetric, metric)
+	}
+	key := rankingKey{kind: kind, window: window, metric: metric}
+	switch window {
+	case AllTime:
+	case Season:
+		key.season = season
+	case Weekly:
+		key.day = dayOf(now)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownWindow, window)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ranking, ok := b.rankings[key]; ok {
+		return ranking, nil
+	}
+
+	var subjects map[string]*totals
+	switch window {
+	case AllTime:
+		subjects = w.allTime
+	case Season:
+		subjects = w.seasons[season]
+	case Weekly:
+		w.prune(now)
+		subjects = w.week(key.day)
+	}
+
+	ranking := make([]Entry, 0, len(subjects))
+	for id, t := range subjects {
+		if metric == KillRatio && t.Matches < b.minMatchesForRatio {
+			continue
+		}
+		ranking = append(ranking, Entry{
+			ID:           id,
+			Value:        t.value(metric),
+			Matches:      t.Matches,
+			Wins:         t.Wins,
+			Kills:        t.Kills,
+			Deaths:       t.Deaths,
+			Contribution: t.Contribution,
+		})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Value != ranking[j].Value {
+			return ranking[i].Value > ranking[j].Value
+		}
+		return ranking[i].ID < ranking[j].ID
+	})
+	for i := range ranking {
+		if i > 0 && ranking[i].Value == ranking[i-1].Value {
+			ranking[i].Rank = ranking[i-1].Rank
+		} else {
+			ranking[i].Rank = i + 1
+		}
+	}
+
+	b.rankings[key] = ranking
+	return ranking, nil
+}
+
+func dayOf(t time.Time) int64 {
+	return t.Unix() / int64(24*time.Hour/time.Second)
+}
@@ -0,0 +1,129 @@
+package leaderboard
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/results"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/war"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func match(id string, reported time.Time, season uint32, winner string, loser string, winnerKills int) results.Result {
+	return results.Result{
+		MatchID:  id,
+		Season:   season,
+		Winner:   war.Morskoj,
+		Reported: reported,
+		Participants: []results.Participant{
+			{Xuid: winner, Nation: war.Morskoj, Kills: winnerKills, Deaths: 1, Contribution: 10},
+			{Xuid: loser, Nation: war.Rutania, Kills: 1, Deaths: winnerKills, Contribution: 5},
+		},
+	}
+}
+
+func newTestBoard(t *testing.T, minMatches int) *Board {
+	recorder, err := results.Open(storage.NewMemoryStore(), config.ResultsConfig{MaxParticipants: 12, MaxMatchMinutes: 60}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed opening recorder: %v", err)
+	}
+	board, err := New(recorder, nil, config.LeaderboardConfig{MinMatchesForKillRatio: minMatches})
+	if err != nil {
+		t.Fatalf("Failed creating board: %v", err)
+	}
+	return board
+}
+
+func TestRankingWindows(t *testing.T) {
+	board := newTestBoard(t, 0)
+	now := time.Now()
+
+	board.Add(match("1", now.Add(-30*24*time.Hour), 2, "A", "B", 2))
+	board.Add(match("2", now.Add(-30*24*time.Hour), 2, "A", "B", 2))
+	board.Add(match("3", now, 3, "B", "A", 5))
+
+	allTime, _ := board.Query(Players, AllTime, Wins, 0, 1, 10, now)
+	if allTime.Total != 2 || allTime.Entries[0].ID != "A" || allTime.Entries[0].Value != 2 {
+		t.Errorf("Expected A leading all-time wins with 2, got %v", allTime.Entries)
+	}
+
+	season, _ := board.Query(Players, Season, Wins, 3, 1, 10, now)
+	if season.Entries[0].ID != "B" || season.Entries[1].Value != 0 {
+		t.Errorf("Expected B leading season 3, got %v", season.Entries)
+	}
+
+	weekly, _ := board.Query(Players, Weekly, KillRatio, 0, 1, 10, now)
+	if weekly.Entries[0].ID != "B" || weekly.Entries[0].Value != 5 {
+		t.Errorf("Expected B leading weekly kill ratio with 5, got %v", weekly.Entries)
+	}
+
+	board.Add(match("4", now, 3, "A", "B", 1))
+	season, _ = board.Query(Players, Season, Wins, 3, 1, 10, now)
+	if season.Entries[0].Rank != 1 || season.Entries[1].Rank != 1 {
+		t.Errorf("Expected shared first rank after incremental update, got %v", season.Entries)
+	}
+}
+
+func TestQueryPaginationAndErrors(t *testing.T) {
+	board := newTestBoard(t, 2)
+	now := time.Now()
+	board.Add(match("1", now, 3, "A", "B", 3))
+	board.Add(match("2", now, 3, "C", "D", 3))
+
+	page, _ := board.Query(Players, AllTime, Contribution, 0, 2, 3, now)
+	if page.Total != 4 || len(page.Entries) != 1 || page.Entries[0].Rank != 3 {
+		t.Errorf("Expected last entry on second page, got %v", page)
+	}
+	for _, p := range []int{3, math.MaxInt/2 + 2} {
+		if page, _ := board.Query(Players, AllTime, Contribution, 0, p, 2, now); len(page.Entries) != 0 {
+			t.Errorf("Expected page %d past the end to be empty, got %v", p, page.Entries)
+		}
+	}
+	if page, _ := board.Query(Players, AllTime, KillRatio, 0, 1, 10, now); page.Total != 0 {
+		t.Errorf("Expected players below minimum matches excluded from kill ratio, got %v", page.Entries)
+	}
+	if _, err := board.Query(Players, "monthly", Wins, 0, 1, 10, now); !errors.Is(err, ErrUnknownWindow) {
+		t.Errorf("Expected ErrUnknownWindow, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	board := newTestBoard(t, 0)
+	board.Add(match("1", time.Now(), 3, "A", "B", 3))
+	handler := board.Handler(1, 5)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/players/all-time/wins?page=2", nil))
+	var page Page
+	json.NewDecoder(recorder.Body).Decode(&page)
+	if recorder.Code != http.StatusOK || page.Size != 1 || len(page.Entries) != 1 || page.Entries[0].ID != "B" {
+		t.Errorf("Expected B alone on second page, got %d %v", recorder.Code, page)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/players/all-time/wins?size=10", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected bad request for oversized page, got %d", recorder.Code)
+	}
+}
+
+func TestSquadRanking(t *testing.T) {
+	board := newTestBoard(t, 0)
+	now := time.Now()
+	result := match("1", now, 3, "A", "B", 3)
+	result.Participants = append(result.Participants, results.Participant{Xuid: "C", Nation: war.Morskoj, Kills: 2, Contribution: 20})
+	result.Participants[0].Squad = "HND"
+	result.Participants[2].Squad = "HND"
+	result.Participants[1].Squad = "FOX"
+	board.Add(result)
+
+	page, _ := board.Query(Squads, AllTime, Contribution, 0, 1, 10, now)
+	if page.Total != 2 || page.Entries[0].ID != "HND" || page.Entries[0].Value != 30 || page.Entries[0].Matches != 1 || page.Entries[0].Wins != 1 {
+		t.Errorf("Expected HND with one won match and 30 points first, got %v", page.Entries)
+	}
+}
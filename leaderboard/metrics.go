@@ -0,0 +1,45 @@
+package leaderboard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var topDesc = prometheus.NewDesc(
+	"leaderboard_top_value",
+	"Value of top leaderboard entries, season window covers current season",
+	[]string{"kind", "window", "metric", "position", "id"}, nil,
+)
+
+// Collector exports top entries of every leaderboard
+type Collector struct {
+	board   *Board
+	entries int
+}
+
+// NewCollector exports first entries of every leaderboard
+func NewCollector(board *Board, entries int) *Collector {
+	return &Collector{board: board, entries: entries}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- topDesc
+}
+
+// Collect implements prometheus.Collector. Entries are labelled by position, as shared ranks would collide.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	season := c.board.CurrentSeason()
+	for _, kind := range Kinds {
+		for _, window := range Windows {
+			for _, metric := range Metrics {
+				for i, entry := range c.board.Top(kind, window, metric, season, c.entries, now) {
+					ch <- prometheus.MustNewConstMetric(topDesc, prometheus.GaugeValue, entry.Value, string(kind), string(window), string(metric), strconv.Itoa(i+1), entry.ID)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package lobby
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrRoomFull        = errors.New("room is full")
+	ErrAlreadyInRoom   = errors.New("player already in a room")
+	ErrNotInRoom       = errors.New("player not in room")
+	ErrInvalidCapacity = errors.New("invalid room capacity")
+	ErrWrongAddress    = errors.New("player is in room from another address")
+	ErrTooManyRooms    = errors.New("too many rooms hosted from address")
+	ErrBanned          = errors.New("player is banned")
+)
+
+// Member is a player in a room, with the address it joined from.
+// Requests on behalf of member are only accepted from that address, so xuid sent
+// by someone else can't be used to redirect or remove them.
+type Member struct {
+	Xuid     string
+	Addr     *net.UDPAddr
+	LastSeen time.Time
+}
+
+// Room is a match being assembled. Host is always the first member.
+type Room struct {
+	ID       uint32
+	Name     string
+	Capacity int
+	Members  []Member
+	Created  time.Time
+}
+
+// Host returns member hosting the room
+func (r *Room) Host() Member {
+	return r.Members[0]
+}
+
+// Full reports whether room reached its capacity
+func (r *Room) Full() bool {
+	return len(r.Members) >= r.Capacity
+}
+
+// Manager holds lobby state: rooms and which room each player is in.
+// Safe for concurrent use.
+type Manager struct {
+	mu                 sync.Mutex
+	maxCapacity        int
+	maxRoomsPerAddress int
+	idleTimeout        time.Duration
+	nextID             uint32
+	rooms              map[uint32]*Room
+	playerRoom         map[string]uint32
+	hostedRooms        map[string]int // open rooms by ip of their host
+	banned             func(xuid string) bool
+}
+
+// NewManager creates lobby allowing rooms of up to maxCapacity players, and at most
+// maxRoomsPerAddress rooms hosted from single ip, 0 meaning unlimited.
+// Members not heard from for idleTimeout are dropped from their rooms.
+func NewManager(maxCapacity int, maxRoomsPerAddress int, idleTimeout time.Duration) *Manager {
+	return &Manager{
+		maxCapacity:        maxCapacity,
+		maxRoomsPerAddress: maxRoomsPerAddress,
+		idleTimeout:        idleTimeout,
+		nextID:             1,
+		rooms:              make(map[uint32]*Room),
+		playerRoom:         make(map[string]uint32),
+		hostedRooms:        make(map[string]int),
+	}
+}
+
+// SetBanned sets check of banned players, who can't create or join rooms
+func (m *Manager) SetBanned(banned func(xuid string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.banned = banned
+}
+
+// Create opens new room hosted by player
+func (m *Manager) Create(hostXuid string, addr *net.UDPAddr, name string, capacity int, now time.Time) (Room, error) {
+	if capacity < 2 || capacity > m.maxCapacity {
+		return Room{}, ErrInvalidCapacity
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isBanned(hostXuid) {
+		return Room{}, ErrBanned
+	}
+	if _, ok := m.playerRoom[hostXuid]; ok {
+		return Room{}, ErrAlreadyInRoom
+	}
+	ip := addr.IP.String()
+	if m.maxRoomsPerAddress > 0 && m.hostedRooms[ip] >= m.maxRoomsPerAddress {
+		return Room{}, ErrTooManyRooms
+	}
+
+	room := &Room{
+		ID:       m.nextID,
+		Name:     name,
+		Capacity: capacity,
+		Members:  []Member{{Xuid: hostXuid, Addr: addr, LastSeen: now}},
+		Created:  now,
+	}
+	m.nextID++
+	m.rooms[room.ID] = room
+	m.playerRoom[hostXuid] = room.ID
+	m.hostedRooms[ip]++
+	return room.copy(), nil
+}
+
+// Join adds player to room
+func (m *Manager) Join(roomID uint32, xuid string, addr *net.UDPAddr, now time.Time) (Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isBanned(xuid) {
+		return Room{}, ErrBanned
+	}
+	room, ok := m.rooms[roomID]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	if current, ok := m.playerRoom[xuid]; ok {
+		if current == roomID {
+			// rejoin, e.g. client retrying after lost response
+			if err := m.touch(room, xuid, addr, now); err != nil {
+				return Room{}, err
+			}
+			return room.copy(), nil
+		}
+		return Room{}, ErrAlreadyInRoom
+	}
+	if room.Full() {
+		return Room{}, ErrRoomFull
+	}
+
+	room.Members = append(room.Members, Member{Xuid: xuid, Addr: addr, LastSeen: now})
+	m.playerRoom[xuid] = roomID
+	return room.copy(), nil
+}
+
+// Leave removes player at addr from room. When host leaves, room is closed.
+// Returns state of the room after leaving and whether it still exists.
+func (m *Manager) Leave(roomID uint32, xuid string, addr *net.UDPAddr) (Room, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[roomID]
+	if !ok {
+		return Room{}, false, ErrRoomNotFound
+	}
+	if m.playerRoom[xuid] != roomID {
+		return Room{}, false, ErrNotInRoom
+	}
+	if member, _ := room.member(xuid); !sameAddr(member.Addr, addr) {
+		return Room{}, false, ErrWrongAddress
+	}
+
+	if room.Host().Xuid == xuid {
+		m.close(room)
+		return room.copy(), false, nil
+	}
+	m.removeMember(room, xuid)
+	return room.copy(), true, nil
+}
+
+// Get returns room by id
+func (m *Manager) Get(roomID uint32) (Room, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[roomID]
+	if !ok {
+		return Room{}, false
+	}
+	return room.copy(), true
+}
+
+// List returns all open rooms ordered by id
+func (m *Manager) List() []Room {
+	m.mu.Lock()
+	result := make([]Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		result = append(result, room.copy())
+	}
+	m.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Touch refreshes last activity of player at addr, keeping them from expiring. Returns state of their room.
+func (m *Manager) Touch(roomID uint32, xuid string, addr *net.UDPAddr, now time.Time) (Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[roomID]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	if m.playerRoom[xuid] != roomID {
+		return Room{}, ErrNotInRoom
+	}
+	if err := m.touch(room, xuid, addr, now); err != nil {
+		return Room{}, err
+	}
+	return room.copy(), nil
+}
+
+// Expire drops members idle for longer than timeout. Rooms whose host expired are closed.
+// Returns number of closed rooms.
+func (m *Manager) Expire(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	closed := 0
+	for _, room := range m.rooms {
+		if now.Sub(room.Host().LastSeen) > m.idleTimeout {
+			m.close(room)
+			closed++
+			continue
+		}
+		for _, member := range room.Members[1:] {
+			if now.Sub(member.LastSeen) > m.idleTimeout {
+				m.removeMember(room, member.Xuid)
+			}
+		}
+	}
+	return closed
+}
+
+// caller has to hold lock
+func (m *Manager) isBanned(xuid string) bool {
+	return m.banned != nil && m.banned(xuid)
+}
+
+// caller has to hold lock
+func (m *Manager) touch(room *Room, xuid string, addr *net.UDPAddr, now time.Time) error {
+	member, i := room.member(xuid)
+	if i < 0 {
+		return ErrNotInRoom
+	}
+	if !sameAddr(member.Addr, addr) {
+		return ErrWrongAddress
+	}
+	room.Members[i].LastSeen = now
+	return nil
+}
+
+// caller has to hold lock
+func (m *Manager) removeMember(room *Room, xuid string) {
+	for i, member := range room.Members {
+		if member.Xuid == xuid {
+			room.Members = append(room.Members[:i:i], room.Members[i+1:]...)
+			break
+		}
+	}
+	delete(m.playerRoom, xuid)
+}
+
+// caller has to hold lock
+func (m *Manager) close(room *Room) {
+	for _, member := range room.Members {
+		delete(m.playerRoom, member.Xuid)
+	}
+	ip := room.Host().Addr.IP.String()
+	if m.hostedRooms[ip]--; m.hostedRooms[ip] <= 0 {
+		delete(m.hostedRooms, ip)
+	}
+	delete(m.rooms, room.ID)
+}
+
+// member returns member with given xuid and its index, -1 if there's none
+func (r *Room) member(xuid string) (Member, int) {
+	for i, member := range r.Members {
+		if member.Xuid == xuid {
+			return member, i
+		}
+	}
+	return Member{}, -1
+}
+
+func sameAddr(a *net.UDPAddr, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.IP.Equal(b.IP) && a.Port == b.Port
+}
+
+func (r *Room) copy() Room {
+	c := *r
+	c.Members = make([]Member, len(r.Members))
+	copy(c.Members, r.Members)
+	return c
+}
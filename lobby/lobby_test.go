@@ -0,0 +1,199 @@
+package lobby
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"net"
+	"testing"
+	"time"
+)
+
+func testAddr(port int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+}
+
+func TestCreateJoinLeave(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	now := time.Now()
+
+	room, err := manager.Create("HOST", testAddr(1), "Tarakia only", 3, now)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if room.Host().Xuid != "HOST" || room.Name != "Tarakia only" {
+		t.Errorf("Unexpected room: %+v", room)
+	}
+
+	if _, err := manager.Create("HOST", testAddr(1), "second", 2, now); err != ErrAlreadyInRoom {
+		t.Errorf("Expected ErrAlreadyInRoom for host creating second room, got %v", err)
+	}
+
+	if _, err := manager.Join(room.ID, "PEER1", testAddr(2), now); err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	// retried join is idempotent
+	if _, err := manager.Join(room.ID, "PEER1", testAddr(2), now); err != nil {
+		t.Errorf("Expected rejoin to succeed, got %v", err)
+	}
+	room, err = manager.Join(room.ID, "PEER2", testAddr(3), now)
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if !room.Full() {
+		t.Errorf("Expected room to be full with %d members", len(room.Members))
+	}
+	if _, err := manager.Join(room.ID, "PEER3", testAddr(4), now); err != ErrRoomFull {
+		t.Errorf("Expected ErrRoomFull, got %v", err)
+	}
+
+	room, open, err := manager.Leave(room.ID, "PEER1", testAddr(2))
+	if err != nil || !open {
+		t.Fatalf("Expected peer to leave open room, got open=%v err=%v", open, err)
+	}
+	if len(room.Members) != 2 {
+		t.Errorf("Expected 2 members after leave, got %d", len(room.Members))
+	}
+	if _, _, err := manager.Leave(room.ID, "PEER1", testAddr(2)); err != ErrNotInRoom {
+		t.Errorf("Expected ErrNotInRoom, got %v", err)
+	}
+
+	// host leaving closes the room and frees everyone
+	if _, open, _ := manager.Leave(room.ID, "HOST", testAddr(1)); open {
+		t.Errorf("Expected room to close when host leaves")
+	}
+	if _, ok := manager.Get(room.ID); ok {
+		t.Errorf("Expected room to be gone")
+	}
+	if _, err := manager.Create("PEER2", testAddr(3), "new", 2, now); err != nil {
+		t.Errorf("Expected member of closed room to be free to host, got %v", err)
+	}
+}
+
+func TestRequestsBoundToJoinAddress(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	now := time.Now()
+
+	room, _ := manager.Create("HOST", testAddr(1), "", 4, now)
+	manager.Join(room.ID, "PEER", testAddr(2), now)
+
+	if _, err := manager.Join(room.ID, "PEER", testAddr(9), now); err != ErrWrongAddress {
+		t.Errorf("Expected ErrWrongAddress for rejoin from other address, got %v", err)
+	}
+	if _, err := manager.Touch(room.ID, "PEER", testAddr(9), now); err != ErrWrongAddress {
+		t.Errorf("Expected ErrWrongAddress for keepalive from other address, got %v", err)
+	}
+	if _, _, err := manager.Leave(room.ID, "HOST", testAddr(9)); err != ErrWrongAddress {
+		t.Errorf("Expected ErrWrongAddress for host leave from other address, got %v", err)
+	}
+	room, _ = manager.Get(room.ID)
+	if room.Members[1].Addr.Port != 2 {
+		t.Errorf("Expected member address unchanged, got %v", room.Members[1].Addr)
+	}
+}
+
+func TestRoomsPerAddress(t *testing.T) {
+	manager := NewManager(4, 2, time.Minute)
+	now := time.Now()
+
+	first, _ := manager.Create("HOST1", testAddr(1), "", 2, now)
+	if _, err := manager.Create("HOST2", testAddr(2), "", 2, now); err != nil {
+		t.Fatalf("Expected second room from same ip, got %v", err)
+	}
+	if _, err := manager.Create("HOST3", testAddr(3), "", 2, now); err != ErrTooManyRooms {
+		t.Errorf("Expected ErrTooManyRooms, got %v", err)
+	}
+	manager.Leave(first.ID, "HOST1", testAddr(1))
+	if _, err := manager.Create("HOST3", testAddr(3), "", 2, now); err != nil {
+		t.Errorf("Expected closed room to free its slot, got %v", err)
+	}
+}
+
+func TestBannedPlayers(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	manager.SetBanned(func(xuid string) bool { return xuid == "BANNED" })
+	now := time.Now()
+
+	if _, err := manager.Create("BANNED", testAddr(1), "", 2, now); err != ErrBanned {
+		t.Errorf("Expected ErrBanned on create, got %v", err)
+	}
+	room, err := manager.Create("HOST", testAddr(2), "", 2, now)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := manager.Join(room.ID, "BANNED", testAddr(1), now); err != ErrBanned {
+		t.Errorf("Expected ErrBanned on join, got %v", err)
+	}
+}
+
+func TestInvalidCapacity(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	for _, capacity := range []int{0, 1, 5} {
+		if _, err := manager.Create("HOST", testAddr(1), "", capacity, time.Now()); err != ErrInvalidCapacity {
+			t.Errorf("Capacity %d: expected ErrInvalidCapacity, got %v", capacity, err)
+		}
+	}
+}
+
+func TestExpire(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	now := time.Now()
+
+	first, _ := manager.Create("HOST1", testAddr(1), "", 4, now)
+	second, _ := manager.Create("HOST2", testAddr(2), "", 4, now)
+	manager.Join(first.ID, "PEER", testAddr(3), now)
+
+	later := now.Add(2 * time.Minute)
+	manager.Touch(first.ID, "HOST1", testAddr(1), later)
+
+	if closed := manager.Expire(later); closed != 1 {
+		t.Errorf("Expected 1 room closed, got %d", closed)
+	}
+	if _, ok := manager.Get(second.ID); ok {
+		t.Errorf("Expected room with idle host to be closed")
+	}
+	room, ok := manager.Get(first.ID)
+	if !ok || len(room.Members) != 1 {
+		t.Errorf("Expected idle peer dropped from active room, got %+v", room)
+	}
+}
+
+func TestHandleFrame(t *testing.T) {
+	manager := NewManager(4, 0, time.Minute)
+	now := time.Now()
+
+	create := CreateRequest{Capacity: 2, Name: encodeName("room")}
+	copy(create.Xuid[:], "HOST")
+	request, _ := protocol.NewFrame(protocol.ServiceLobby, OpCreate, create)
+
+	response, err := manager.HandleFrame(request, testAddr(1), now)
+	if err != nil {
+		t.Fatalf("HandleFrame failed: %v", err)
+	}
+	if response.Opcode != OpRoomState {
+		t.Fatalf("Expected room state response, got opcode %#x", response.Opcode)
+	}
+	var header RoomStateHeader
+	if err := response.DecodePayload(&header); err != nil {
+		t.Fatalf("Failed decoding response: %v", err)
+	}
+	if header.MemberCount != 1 || decodeName(header.Name) != "room" {
+		t.Errorf("Unexpected room state: %+v", header)
+	}
+
+	join := RoomRequest{RoomID: 999}
+	copy(join.Xuid[:], "PEER")
+	request, _ = protocol.NewFrame(protocol.ServiceLobby, OpJoin, join)
+	response, _ = manager.HandleFrame(request, testAddr(2), now)
+
+	var errResponse ErrorResponse
+	response.DecodePayload(&errResponse)
+	if response.Opcode != OpError || errResponse.Code != ErrorCodeRoomNotFound || errResponse.RequestOpcode != OpJoin {
+		t.Errorf("Expected room not found error, got opcode %#x %+v", response.Opcode, errResponse)
+	}
+
+	request = protocol.Frame{Service: protocol.ServiceLobby, Opcode: OpJoin, Payload: []byte{1, 2}}
+	response, _ = manager.HandleFrame(request, testAddr(2), now)
+	response.DecodePayload(&errResponse)
+	if errResponse.Code != ErrorCodeMalformed {
+		t.Errorf("Expected malformed error for short payload, got %+v", errResponse)
+	}
+}
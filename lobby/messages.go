@@ -0,0 +1,224 @@
+package lobby
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/status"
+	"bytes"
+	"errors"
+	"net"
+	"time"
+)
+
+// Opcodes of lobby service. Requests are sent by clients, responses by server.
+const (
+	OpCreate    uint8 = 0x01
+	OpJoin      uint8 = 0x02
+	OpLeave     uint8 = 0x03
+	OpList      uint8 = 0x04
+	OpKeepAlive uint8 = 0x05
+
+	OpRoomState uint8 = 0x81
+	OpRoomList  uint8 = 0x82
+	OpLeft      uint8 = 0x83
+	OpError     uint8 = 0xFF
+)
+
+// Error codes sent in OpError response
+const (
+	ErrorCodeMalformed uint8 = iota + 1
+	ErrorCodeRoomNotFound
+	ErrorCodeRoomFull
+	ErrorCodeAlreadyInRoom
+	ErrorCodeNotInRoom
+	ErrorCodeInvalidCapacity
+	ErrorCodeUnknownOpcode
+	ErrorCodeWrongAddress
+	ErrorCodeTooManyRooms
+	ErrorCodeBanned
+)
+
+const roomNameSize = 32
+
+// 48 bytes
+type CreateRequest struct {
+	Xuid     [15]byte
+	Capacity uint8
+	Name     [roomNameSize]byte
+}
+
+// 19 bytes, used for join, leave and keepalive
+type RoomRequest struct {
+	Xuid   [15]byte
+	RoomID uint32
+}
+
+// 15 bytes
+type ListRequest struct {
+	Xuid [15]byte
+}
+
+// 38 bytes, followed by MemberEntry for each member, host first
+type RoomStateHeader struct {
+	RoomID      uint32
+	Capacity    uint8
+	MemberCount uint8
+	Name        [roomNameSize]byte
+}
+
+// 33 bytes
+type MemberEntry struct {
+	Xuid     [15]byte
+	Endpoint protocol.Endpoint
+}
+
+// 38 bytes, RoomListHeader is followed by one entry per room
+type RoomListEntry struct {
+	RoomID      uint32
+	Capacity    uint8
+	MemberCount uint8
+	Name        [roomNameSize]byte
+}
+
+// 1 byte
+type RoomListHeader struct {
+	RoomCount uint8
+}
+
+// 2 bytes
+type ErrorResponse struct {
+	RequestOpcode uint8
+	Code          uint8
+}
+
+// maximum rooms listed in single response, keeps it well within one datagram
+const maxListedRooms = 32
+
+// HandleFrame executes lobby request and builds response frame for the client
+func (m *Manager) HandleFrame(frame protocol.Frame, addr *net.UDPAddr, now time.Time) (protocol.Frame, error) {
+	switch frame.Opcode {
+	case OpCreate:
+		var request CreateRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorFrame(frame.Opcode, ErrorCodeMalformed)
+		}
+		room, err := m.Create(status.XuidString(request.Xuid), addr, decodeName(request.Name), int(request.Capacity), now)
+		return roomResponse(frame.Opcode, room, err)
+
+	case OpJoin:
+		var request RoomRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorFrame(frame.Opcode, ErrorCodeMalformed)
+		}
+		room, err := m.Join(request.RoomID, status.XuidString(request.Xuid), addr, now)
+		return roomResponse(frame.Opcode, room, err)
+
+	case OpLeave:
+		var request RoomRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorFrame(frame.Opcode, ErrorCodeMalformed)
+		}
+		if _, _, err := m.Leave(request.RoomID, status.XuidString(request.Xuid), addr); err != nil {
+			return errorFrame(frame.Opcode, errorCode(err))
+		}
+		return protocol.NewFrame(protocol.ServiceLobby, OpLeft, RoomRequest{Xuid: request.Xuid, RoomID: request.RoomID})
+
+	case OpKeepAlive:
+		var request RoomRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorFrame(frame.Opcode, ErrorCodeMalformed)
+		}
+		room, err := m.Touch(request.RoomID, status.XuidString(request.Xuid), addr, now)
+		return roomResponse(frame.Opcode, room, err)
+
+	case OpList:
+		return listResponse(m.List())
+
+	default:
+		return errorFrame(frame.Opcode, ErrorCodeUnknownOpcode)
+	}
+}
+
+func roomResponse(requestOpcode uint8, room Room, err error) (protocol.Frame, error) {
+	if err != nil {
+		return errorFrame(requestOpcode, errorCode(err))
+	}
+
+	header := RoomStateHeader{
+		RoomID:      room.ID,
+		Capacity:    uint8(room.Capacity),
+		MemberCount: uint8(len(room.Members)),
+		Name:        encodeName(room.Name),
+	}
+	response, err := protocol.NewFrame(protocol.ServiceLobby, OpRoomState, header)
+	if err != nil {
+		return protocol.Frame{}, err
+	}
+	for _, member := range room.Members {
+		entry := MemberEntry{Endpoint: protocol.EndpointFromUDPAddr(member.Addr)}
+		copy(entry.Xuid[:], member.Xuid)
+		if err := response.AppendPayload(entry); err != nil {
+			return protocol.Frame{}, err
+		}
+	}
+	return response, nil
+}
+
+func listResponse(rooms []Room) (protocol.Frame, error) {
+	if len(rooms) > maxListedRooms {
+		rooms = rooms[:maxListedRooms]
+	}
+
+	response, err := protocol.NewFrame(protocol.ServiceLobby, OpRoomList, RoomListHeader{RoomCount: uint8(len(rooms))})
+	if err != nil {
+		return protocol.Frame{}, err
+	}
+	for _, room := range rooms {
+		entry := RoomListEntry{
+			RoomID:      room.ID,
+			Capacity:    uint8(room.Capacity),
+			MemberCount: uint8(len(room.Members)),
+			Name:        encodeName(room.Name),
+		}
+		if err := response.AppendPayload(entry); err != nil {
+			return protocol.Frame{}, err
+		}
+	}
+	return response, nil
+}
+
+func errorFrame(requestOpcode uint8, code uint8) (protocol.Frame, error) {
+	return protocol.NewFrame(protocol.ServiceLobby, OpError, ErrorResponse{RequestOpcode: requestOpcode, Code: code})
+}
+
+func errorCode(err error) uint8 {
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		return ErrorCodeRoomNotFound
+	case errors.Is(err, ErrRoomFull):
+		return ErrorCodeRoomFull
+	case errors.Is(err, ErrAlreadyInRoom):
+		return ErrorCodeAlreadyInRoom
+	case errors.Is(err, ErrNotInRoom):
+		return ErrorCodeNotInRoom
+	case errors.Is(err, ErrInvalidCapacity):
+		return ErrorCodeInvalidCapacity
+	case errors.Is(err, ErrWrongAddress):
+		return ErrorCodeWrongAddress
+	case errors.Is(err, ErrTooManyRooms):
+		return ErrorCodeTooManyRooms
+	case errors.Is(err, ErrBanned):
+		return ErrorCodeBanned
+	default:
+		return ErrorCodeMalformed
+	}
+}
+
+func decodeName(name [roomNameSize]byte) string {
+	return string(bytes.TrimRight(name[:], "\x00"))
+}
+
+func encodeName(name string) [roomNameSize]byte {
+	var encoded [roomNameSize]byte
+	copy(encoded[:], name)
+	return encoded
+}
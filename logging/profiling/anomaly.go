@@ -0,0 +1,244 @@
+package profiling
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Triggers of automatic capture
+const (
+	TriggerHeapGrowth     = "heap_growth"
+	TriggerGCFrequency    = "gc_frequency"
+	TriggerProcessingTime = "processing_time_p99"
+	TriggerErrorRate      = "error_rate"
+)
+
+// MetadataFile describes automatic capture, it's written next to captured profiles
+const MetadataFile = "metadata.json"
+
+// servers with less traffic within check are not checked for processing time and error rate,
+// as few slow or broken packets would look like anomaly
+const minAnomalyPackets = 100
+
+// Anomaly is threshold crossed within single check
+type Anomaly struct {
+	Trigger   string  `json:"trigger"`
+	Label     string  `json:"label,omitempty"` // server, for checks done per server
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+func (a Anomaly) String() string {
+	if a.Label != "" {
+		return fmt.Sprintf("%s of %s %.2f > %.2f", a.Trigger, a.Label, a.Value, a.Threshold)
+	}
+	return fmt.Sprintf("%s %.2f > %.2f", a.Trigger, a.Value, a.Threshold)
+}
+
+// CaptureMetadata is content of MetadataFile
+type CaptureMetadata struct {
+	Time      time.Time   `json:"time"`
+	Anomalies []Anomaly   `json:"anomalies"`
+	Memory    MemoryStats `json:"memory"`
+	Profiles  []string    `json:"profiles"` // file names within capture directory
+	Errors    []string    `json:"errors,omitempty"`
+}
+
+// Watchdog periodically compares resource usage and performance of servers against thresholds
+// and captures heap and cpu profiles when any of them is crossed
+type Watchdog struct {
+	cfg             config.ProfilingConfig
+	interval        time.Duration
+	cooldown        time.Duration
+	captureDuration time.Duration
+
+	lastMemory  MemoryStats
+	lastStats   map[string]Stats
+	lastCapture time.Time
+
+	mu       sync.Mutex
+	captures map[string]uint64 // by trigger
+}
+
+// NewWatchdog creates watchdog with thresholds of cfg
+func NewWatchdog(cfg config.ProfilingConfig) *Watchdog {
+	return &Watchdog{
+		cfg:             cfg,
+		interval:        time.Duration(cfg.AnomalyCheckSeconds) * time.Second,
+		cooldown:        time.Duration(cfg.AutoCaptureCooldownMinutes) * time.Minute,
+		captureDuration: time.Duration(cfg.AutoCaptureSeconds) * time.Second,
+		lastStats:       make(map[string]Stats),
+		captures:        make(map[string]uint64),
+	}
+}
+
+// Run checks for anomalies every interval until ctx is done
+func (w *Watchdog) Run(ctx context.Context) {
+	w.Check()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			anomalies := w.Check()
+			if len(anomalies) == 0 {
+				continue
+			}
+			if !w.lastCapture.IsZero() && now.Sub(w.lastCapture) < w.cooldown {
+				logging.Warn.Printf("[PROFILE] anomaly %v, capture skipped during cooldown", anomalies)
+				continue
+			}
+			w.lastCapture = now
+			dir, err := w.capture(anomalies, now)
+			if err != nil {
+				logging.Error.Printf("[PROFILE] automatic capture failed: %v", err)
+				continue
+			}
+			logging.Warn.Printf("[PROFILE] anomaly %v, profiles captured into %s", anomalies, dir)
+		}
+	}
+}
+
+// Check takes memory snapshot and statistics of servers and returns thresholds crossed since previous check.
+// First check only records baseline.
+func (w *Watchdog) Check() []Anomaly {
+	memory := RecordMemorySnapshot()
+	previous := w.lastMemory
+	w.lastMemory = memory
+
+	var anomalies []Anomaly
+	if !previous.Timestamp.IsZero() {
+		minutes := memory.Timestamp.Sub(previous.Timestamp).Minutes()
+		if minutes > 0 && memory.HeapLive > previous.HeapLive {
+			growth := float64(memory.HeapLive-previous.HeapLive) / 1024 / 1024 / minutes
+			anomalies = appendCrossed(anomalies, TriggerHeapGrowth, "", growth, w.cfg.HeapGrowthMBPerMinute)
+		}
+		if minutes > 0 {
+			gcs := float64(memory.NumGC-previous.NumGC) / minutes
+			anomalies = appendCrossed(anomalies, TriggerGCFrequency, "", gcs, w.cfg.GCPerMinute)
+		}
+	}
+
+	for _, pm := range Monitors() {
+		stats := pm.Stats()
+		earlier, known := w.lastStats[stats.Label]
+		w.lastStats[stats.Label] = stats
+		if !known {
+			continue
+		}
+		delta := stats.Since(earlier)
+		if delta.PacketsProcessed >= minAnomalyPackets {
+			p99 := float64(delta.ProcessingTimePercentile(0.99)) / float64(time.Millisecond)
+			anomalies = appendCrossed(anomalies, TriggerProcessingTime, stats.Label, p99, w.cfg.P99ProcessingMillis)
+		}
+		if handled := delta.PacketsProcessed + delta.Errors; handled >= minAnomalyPackets {
+			rate := float64(delta.Errors) / float64(handled) * 100
+			anomalies = appendCrossed(anomalies, TriggerErrorRate, stats.Label, rate, w.cfg.ErrorRatePercent)
+		}
+	}
+	return anomalies
+}
+
+// threshold of 0 disables check
+func appendCrossed(anomalies []Anomaly, trigger string, label string, value float64, threshold float64) []Anomaly {
+	if threshold <= 0 || value <= threshold {
+		return anomalies
+	}
+	return append(anomalies, Anomaly{Trigger: trigger, Label: label, Value: value, Threshold: threshold})
+}
+
+// captures heap and cpu profile into new directory named after time and first anomaly, then drops old captures
+func (w *Watchdog) capture(anomalies []Anomaly, now time.Time) (string, error) {
+	dir := filepath.Join(w.cfg.AutoCaptureDir, now.UTC().Format("20060102T150405")+"-"+anomalies[0].Trigger)
+	metadata := CaptureMetadata{Time: now, Anomalies: anomalies, Memory: w.lastMemory, Profiles: []string{}}
+	for _, kind := range []string{Heap, CPU} {
+		path, err := Capture(kind, w.captureDuration, dir)
+		if err != nil {
+			metadata.Errors = append(metadata.Errors, fmt.Sprintf("%s: %v", kind, err))
+			continue
+		}
+		metadata.Profiles = append(metadata.Profiles, filepath.Base(path))
+	}
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, MetadataFile), data, 0o640); err != nil {
+		return "", err
+	}
+
+	w.mu.Lock()
+	for _, a := range anomalies {
+		w.captures[a.Trigger]++
+	}
+	w.mu.Unlock()
+
+	if err := pruneCaptures(w.cfg.AutoCaptureDir, w.cfg.AutoCaptureRetain); err != nil {
+		logging.Warn.Printf("[PROFILE] failed removing old captures: %v", err)
+	}
+	return dir, nil
+}
+
+// removes all but newest retain captures in dir. Only directories with metadata are considered,
+// so nothing else is removed if dir is shared.
+func pruneCaptures(dir string, retain int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var captures []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), MetadataFile)); err == nil {
+			captures = append(captures, entry.Name())
+		}
+	}
+	// names start with capture time
+	sort.Strings(captures)
+	for len(captures) > retain {
+		if err := os.RemoveAll(filepath.Join(dir, captures[0])); err != nil {
+			return err
+		}
+		captures = captures[1:]
+	}
+	return nil
+}
+
+var capturesDesc = prometheus.NewDesc(
+	"profiling_auto_captures_total",
+	"Total number of automatic profile captures by anomaly that triggered them",
+	[]string{"trigger"}, nil,
+)
+
+// Describe implements prometheus.Collector
+func (w *Watchdog) Describe(ch chan<- *prometheus.Desc) {
+	ch <- capturesDesc
+}
+
+// Collect implements prometheus.Collector
+func (w *Watchdog) Collect(ch chan<- prometheus.Metric) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, trigger := range []string{TriggerHeapGrowth, TriggerGCFrequency, TriggerProcessingTime, TriggerErrorRate} {
+		ch <- prometheus.MustNewConstMetric(capturesDesc, prometheus.CounterValue, float64(w.captures[trigger]), trigger)
+	}
+}
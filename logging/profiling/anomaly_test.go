@@ -0,0 +1,119 @@
+package profiling
+
+import (
+	"ChromehoundsStatusServer/config"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessingTimePercentile(t *testing.T) {
+	pm := NewPerformanceMonitor("PERCENTILE")
+	for i := 0; i < 98; i++ {
+		pm.RecordPacketProcessed(31, 20*time.Microsecond)
+	}
+	pm.RecordPacketProcessed(31, 3*time.Millisecond)
+	pm.RecordPacketProcessed(31, 2*time.Second)
+
+	s := pm.Stats()
+	if p50 := s.ProcessingTimePercentile(0.5); p50 != 25*time.Microsecond {
+		t.Errorf("Expected p50 of 25µs, got %s", p50)
+	}
+	if p99 := s.ProcessingTimePercentile(0.99); p99 != 5*time.Millisecond {
+		t.Errorf("Expected p99 of 5ms, got %s", p99)
+	}
+	if p100 := s.ProcessingTimePercentile(1); p100 != time.Second {
+		t.Errorf("Expected slowest bucket to count as 1s, got %s", p100)
+	}
+}
+
+func hasAnomaly(anomalies []Anomaly, trigger string, label string) bool {
+	for _, a := range anomalies {
+		if a.Trigger == trigger && a.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckFindsSlowAndFailingServers(t *testing.T) {
+	w := NewWatchdog(config.ProfilingConfig{P99ProcessingMillis: 5, ErrorRatePercent: 10})
+	slow := Monitor("ANOMALY_SLOW")
+	failing := Monitor("ANOMALY_FAILING")
+	w.Check()
+
+	for i := 0; i < 200; i++ {
+		duration := 100 * time.Microsecond
+		if i%50 == 0 {
+			duration = 30 * time.Millisecond
+		}
+		slow.RecordPacketProcessed(31, duration)
+		failing.RecordPacketProcessed(31, 100*time.Microsecond)
+		if i%4 == 0 {
+			failing.RecordError()
+		}
+	}
+
+	anomalies := w.Check()
+	if !hasAnomaly(anomalies, TriggerProcessingTime, "ANOMALY_SLOW") {
+		t.Errorf("Expected slow processing of ANOMALY_SLOW, got %v", anomalies)
+	}
+	if !hasAnomaly(anomalies, TriggerErrorRate, "ANOMALY_FAILING") {
+		t.Errorf("Expected error rate of ANOMALY_FAILING, got %v", anomalies)
+	}
+	if hasAnomaly(anomalies, TriggerProcessingTime, "ANOMALY_FAILING") || hasAnomaly(anomalies, TriggerErrorRate, "ANOMALY_SLOW") {
+		t.Errorf("Expected no other anomalies of these servers, got %v", anomalies)
+	}
+
+	// nothing happened since previous check
+	if anomalies := w.Check(); hasAnomaly(anomalies, TriggerProcessingTime, "ANOMALY_SLOW") {
+		t.Errorf("Expected anomaly to be reported only once, got %v", anomalies)
+	}
+}
+
+func TestCaptureWritesMetadataAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "unrelated"), 0o750)
+	w := NewWatchdog(config.ProfilingConfig{AutoCaptureDir: dir, AutoCaptureRetain: 2, AutoCaptureSeconds: 0})
+	w.Check()
+
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	var captured []string
+	for i := 0; i < 3; i++ {
+		anomaly := Anomaly{Trigger: TriggerGCFrequency, Value: 200, Threshold: 120}
+		capture, err := w.capture([]Anomaly{anomaly}, start.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed capturing: %v", err)
+		}
+		captured = append(captured, capture)
+	}
+
+	if _, err := os.Stat(captured[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected oldest capture to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unrelated")); err != nil {
+		t.Errorf("Expected directory without metadata to be kept, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(captured[2], MetadataFile))
+	if err != nil {
+		t.Fatalf("Failed reading metadata: %v", err)
+	}
+	var metadata CaptureMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Failed decoding metadata: %v", err)
+	}
+	if len(metadata.Anomalies) != 1 || metadata.Anomalies[0].Trigger != TriggerGCFrequency {
+		t.Errorf("Expected gc frequency anomaly in metadata, got %+v", metadata.Anomalies)
+	}
+	if len(metadata.Profiles) != 2 {
+		t.Errorf("Expected heap and cpu profiles, got %v (errors %v)", metadata.Profiles, metadata.Errors)
+	}
+	for _, profile := range metadata.Profiles {
+		if _, err := os.Stat(filepath.Join(captured[2], profile)); err != nil {
+			t.Errorf("Expected profile %s next to metadata, got %v", profile, err)
+		}
+	}
+}
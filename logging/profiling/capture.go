@@ -0,0 +1,88 @@
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+// MaxCaptureDuration bounds time-based captures, so a forgotten capture can't run for hours
+const MaxCaptureDuration = 5 * time.Minute
+
+// kinds of profiles that can be captured
+const (
+	CPU       = "cpu"
+	Heap      = "heap"
+	Goroutine = "goroutine"
+	Trace     = "trace" // runtime execution trace, viewed with go tool trace
+)
+
+var (
+	ErrUnknownProfile    = errors.New("unknown profile kind")
+	ErrCaptureInProgress = errors.New("another cpu profile or trace is being captured")
+)
+
+// cpu profile and trace can't run twice at once, so time-based captures are serialized
+var capturing atomic.Bool
+
+// Capture writes profile of given kind into dir and returns path of written file.
+// CPU profile and trace are recorded for given duration, other kinds are snapshots.
+func Capture(kind string, duration time.Duration, dir string) (string, error) {
+	extension := "pprof"
+	switch kind {
+	case CPU, Trace:
+		if !capturing.CompareAndSwap(false, true) {
+			return "", ErrCaptureInProgress
+		}
+		defer capturing.Store(false)
+		if kind == Trace {
+			extension = "trace"
+		}
+	case Heap, Goroutine:
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownProfile, kind)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.%s", kind, time.Now().UTC().Format("20060102T150405.000"), extension))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	switch kind {
+	case CPU:
+		if err := pprof.StartCPUProfile(f); err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		time.Sleep(min(duration, MaxCaptureDuration))
+		pprof.StopCPUProfile()
+	case Trace:
+		if err := trace.Start(f); err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		time.Sleep(min(duration, MaxCaptureDuration))
+		trace.Stop()
+	case Heap:
+		// up to date statistics instead of ones from last collection
+		runtime.GC()
+		err = pprof.WriteHeapProfile(f)
+	case Goroutine:
+		err = pprof.Lookup("goroutine").WriteTo(f, 0)
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
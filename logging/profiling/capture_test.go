@@ -0,0 +1,48 @@
+package profiling
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureTrace(t *testing.T) {
+	dir := t.TempDir()
+	path, err := Capture(Trace, 10*time.Millisecond, dir)
+	if err != nil {
+		t.Fatalf("Failed capturing trace: %v", err)
+	}
+	if !strings.HasSuffix(path, ".trace") {
+		t.Errorf("Expected .trace file, got %s", path)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("Expected non-empty trace at %s, got %v", path, err)
+	}
+}
+
+func TestCaptureIsSerialized(t *testing.T) {
+	dir := t.TempDir()
+	done := make(chan error)
+	go func() {
+		_, err := Capture(CPU, 200*time.Millisecond, dir)
+		done <- err
+	}()
+	// wait for cpu capture to start
+	for !capturing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := Capture(Trace, time.Millisecond, dir); !errors.Is(err, ErrCaptureInProgress) {
+		t.Errorf("Expected trace to be refused during cpu capture, got %v", err)
+	}
+	if _, err := Capture(Heap, 0, dir); err != nil {
+		t.Errorf("Expected heap snapshot during cpu capture, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Failed capturing cpu profile: %v", err)
+	}
+	if _, err := Capture("threads", 0, dir); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("Expected unknown profile, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package profiling
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	packetsDesc = prometheus.NewDesc(
+		"performance_packets_processed_total",
+		"Total number of packets processed, as recorded by performance monitor",
+		[]string{"server_name"}, nil,
+	)
+	bytesDesc = prometheus.NewDesc(
+		"performance_bytes_processed_total",
+		"Total size of packets processed, as recorded by performance monitor",
+		[]string{"server_name"}, nil,
+	)
+	processingDesc = prometheus.NewDesc(
+		"performance_processing_seconds_total",
+		"Total time spent processing packets, as recorded by performance monitor",
+		[]string{"server_name"}, nil,
+	)
+	errorsDesc = prometheus.NewDesc(
+		"performance_errors_total",
+		"Total number of errors, as recorded by performance monitor",
+		[]string{"server_name"}, nil,
+	)
+)
+
+type collector struct{}
+
+// Collector exposes counters of all performance monitors
+func Collector() prometheus.Collector {
+	return collector{}
+}
+
+// Describe implements prometheus.Collector
+func (collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- packetsDesc
+	ch <- bytesDesc
+	ch <- processingDesc
+	ch <- errorsDesc
+}
+
+// Collect implements prometheus.Collector
+func (collector) Collect(ch chan<- prometheus.Metric) {
+	for _, pm := range Monitors() {
+		s := pm.Stats()
+		ch <- prometheus.MustNewConstMetric(packetsDesc, prometheus.CounterValue, float64(s.PacketsProcessed), s.Label)
+		ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, float64(s.BytesProcessed), s.Label)
+		ch <- prometheus.MustNewConstMetric(processingDesc, prometheus.CounterValue, s.TotalProcessingTime.Seconds(), s.Label)
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue, float64(s.Errors), s.Label)
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"ChromehoundsStatusServer/config"
 	"ChromehoundsStatusServer/logging"
 	"fmt"
+	"math"
+	"os"
 	"runtime"
+	"runtime/metrics"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 )
 
@@ -17,38 +23,100 @@ type MemoryStats struct {
 	Mallocs     uint64
 	Frees       uint64
 	HeapObjects uint64
+	HeapLive    uint64 // heap marked live by last garbage collection
 	Timestamp   time.Time
 }
 
-// PerformanceMonitor tracks server performance metrics
+// PerformanceMonitor tracks performance of single service.
+// Counters are atomic, so it can be updated from packet loop without locking.
 type PerformanceMonitor struct {
-	mu                  sync.RWMutex
+	label               string
 	startTime           time.Time
-	packetsProcessed    uint64
-	bytesProcessed      uint64
-	totalProcessingTime time.Duration
-	errorCount          uint64
-	memorySnapshots     []MemoryStats
-	lastMemorySnapshot  time.Time
+	packetsProcessed    atomic.Uint64
+	bytesProcessed      atomic.Uint64
+	totalProcessingTime atomic.Int64 // nanoseconds
+	errorCount          atomic.Uint64
+	latency             [len(latencyBounds) + 1]atomic.Uint64 // packets by processing time, last one above all bounds
 }
 
-var globalPerfMonitor = &PerformanceMonitor{
-	startTime: time.Now(),
+// upper bounds of processing time buckets, used to estimate percentiles
+var latencyBounds = [...]time.Duration{
+	10 * time.Microsecond, 25 * time.Microsecond, 50 * time.Microsecond,
+	100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, time.Second,
 }
 
-// NewPerformanceMonitor creates a new performance monitor
-func NewPerformanceMonitor() *PerformanceMonitor {
+// Stats is point in time view of performance monitor
+type Stats struct {
+	Label               string
+	Uptime              time.Duration
+	PacketsProcessed    uint64
+	BytesProcessed      uint64
+	TotalProcessingTime time.Duration
+	Errors              uint64
+	Latency             [len(latencyBounds) + 1]uint64
+}
+
+// maximum number of memory snapshots kept
+const maxMemorySnapshots = 100
+
+var (
+	monitorsMu sync.RWMutex
+	monitors   = make(map[string]*PerformanceMonitor)
+
+	memoryMu        sync.Mutex
+	memorySnapshots []MemoryStats
+)
+
+// NewPerformanceMonitor creates a new performance monitor, not shared with other services
+func NewPerformanceMonitor(label string) *PerformanceMonitor {
 	return &PerformanceMonitor{
+		label:     label,
 		startTime: time.Now(),
 	}
 }
 
+// Monitor returns performance monitor of service with given label, creating it on first use
+func Monitor(label string) *PerformanceMonitor {
+	monitorsMu.RLock()
+	pm, ok := monitors[label]
+	monitorsMu.RUnlock()
+	if ok {
+		return pm
+	}
+
+	monitorsMu.Lock()
+	defer monitorsMu.Unlock()
+	if pm, ok := monitors[label]; ok {
+		return pm
+	}
+	pm = NewPerformanceMonitor(label)
+	monitors[label] = pm
+	return pm
+}
+
+// Monitors returns monitors of all services ordered by label
+func Monitors() []*PerformanceMonitor {
+	monitorsMu.RLock()
+	defer monitorsMu.RUnlock()
+	list := make([]*PerformanceMonitor, 0, len(monitors))
+	for _, pm := range monitors {
+		list = append(list, pm)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].label < list[j].label })
+	return list
+}
+
 // GetCurrentMemoryStats returns current memory statistics
 func GetCurrentMemoryStats() MemoryStats {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
+	live := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	metrics.Read(live)
 
-	return MemoryStats{
+	stats := MemoryStats{
 		Alloc:       m.Alloc,
 		TotalAlloc:  m.TotalAlloc,
 		NumGC:       m.NumGC,
@@ -57,125 +125,178 @@ func GetCurrentMemoryStats() MemoryStats {
 		HeapObjects: m.HeapObjects,
 		Timestamp:   time.Now(),
 	}
+	if live[0].Value.Kind() == metrics.KindUint64 {
+		stats.HeapLive = live[0].Value.Uint64()
+	}
+	return stats
+}
+
+// RecordMemorySnapshot stores current memory statistics, keeping only the latest ones.
+// Memory is shared by all services, so it's sampled periodically instead of per packet.
+func RecordMemorySnapshot() MemoryStats {
+	snapshot := GetCurrentMemoryStats()
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	memorySnapshots = append(memorySnapshots, snapshot)
+	if len(memorySnapshots) > maxMemorySnapshots {
+		memorySnapshots = memorySnapshots[1:]
+	}
+	return snapshot
+}
+
+// GetMemoryTrend returns memory usage trend over time
+func GetMemoryTrend() []MemoryStats {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	// Return a copy to prevent data races
+	trend := make([]MemoryStats, len(memorySnapshots))
+	copy(trend, memorySnapshots)
+	return trend
 }
 
 // RecordPacketProcessed records metrics for a processed packet
 func (pm *PerformanceMonitor) RecordPacketProcessed(bytes int, processingTime time.Duration) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	pm.packetsProcessed++
-	pm.bytesProcessed += uint64(bytes)
-	pm.totalProcessingTime += processingTime
-
-	// Take memory snapshot every 100 packets or every 10 seconds
-	now := time.Now()
-	if pm.packetsProcessed%100 == 0 || now.Sub(pm.lastMemorySnapshot) > 10*time.Second {
-		pm.memorySnapshots = append(pm.memorySnapshots, GetCurrentMemoryStats())
-		pm.lastMemorySnapshot = now
-
-		// Keep only last 100 snapshots to prevent memory leak
-		if len(pm.memorySnapshots) > 100 {
-			pm.memorySnapshots = pm.memorySnapshots[1:]
-		}
+	pm.packetsProcessed.Add(1)
+	pm.bytesProcessed.Add(uint64(bytes))
+	pm.totalProcessingTime.Add(int64(processingTime))
+	bucket := 0
+	for bucket < len(latencyBounds) && processingTime > latencyBounds[bucket] {
+		bucket++
 	}
+	pm.latency[bucket].Add(1)
 }
 
 // RecordError records an error occurrence
 func (pm *PerformanceMonitor) RecordError() {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-	pm.errorCount++
-}
-
-// GetStats returns current performance statistics
-func (pm *PerformanceMonitor) GetStats() map[string]interface{} {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	uptime := time.Since(pm.startTime)
-	avgProcessingTime := time.Duration(0)
-	if pm.packetsProcessed > 0 {
-		avgProcessingTime = pm.totalProcessingTime / time.Duration(pm.packetsProcessed)
-	}
-
-	packetsPerSecond := float64(0)
-	if uptime.Seconds() > 0 {
-		packetsPerSecond = float64(pm.packetsProcessed) / uptime.Seconds()
-	}
-
-	currentMem := GetCurrentMemoryStats()
-
-	return map[string]interface{}{
-		"uptime_seconds":         uptime.Seconds(),
-		"packets_processed":      pm.packetsProcessed,
-		"bytes_processed":        pm.bytesProcessed,
-		"packets_per_second":     packetsPerSecond,
-		"avg_processing_time_ns": avgProcessingTime.Nanoseconds(),
-		"error_count":            pm.errorCount,
-		"current_alloc_mb":       float64(currentMem.Alloc) / 1024 / 1024,
-		"total_alloc_mb":         float64(currentMem.TotalAlloc) / 1024 / 1024,
-		"num_gc":                 currentMem.NumGC,
-		"heap_objects":           currentMem.HeapObjects,
-		"mallocs":                currentMem.Mallocs,
-		"frees":                  currentMem.Frees,
-	}
-}
-
-// PrintStats prints current performance statistics
-func (pm *PerformanceMonitor) PrintStats() {
-	stats := pm.GetStats()
-
-	fmt.Printf("\n=== Performance Statistics ===\n")
-	fmt.Printf("Uptime: %.2f seconds\n", stats["uptime_seconds"])
-	fmt.Printf("Packets Processed: %d\n", stats["packets_processed"])
-	fmt.Printf("Bytes Processed: %d\n", stats["bytes_processed"])
-	fmt.Printf("Packets/Second: %.2f\n", stats["packets_per_second"])
-	fmt.Printf("Avg Processing Time: %d ns\n", stats["avg_processing_time_ns"])
-	fmt.Printf("Errors: %d\n", stats["error_count"])
-	fmt.Printf("Current Memory: %.2f MB\n", stats["current_alloc_mb"])
-	fmt.Printf("Total Allocated: %.2f MB\n", stats["total_alloc_mb"])
-	fmt.Printf("GC Cycles: %d\n", stats["num_gc"])
-	fmt.Printf("Heap Objects: %d\n", stats["heap_objects"])
-	fmt.Printf("Total Mallocs: %d\n", stats["mallocs"])
-	fmt.Printf("Total Frees: %d\n", stats["frees"])
-	fmt.Printf("==============================\n\n")
+	pm.errorCount.Add(1)
 }
 
-// GetMemoryTrend returns memory usage trend over time
-func (pm *PerformanceMonitor) GetMemoryTrend() []MemoryStats {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+// Label of service monitored
+func (pm *PerformanceMonitor) Label() string {
+	return pm.label
+}
 
-	// Return a copy to prevent data races
-	trend := make([]MemoryStats, len(pm.memorySnapshots))
-	copy(trend, pm.memorySnapshots)
-	return trend
+// Stats returns current performance statistics.
+// Counters are read one by one, so they may be off by a packet in progress.
+func (pm *PerformanceMonitor) Stats() Stats {
+	s := Stats{
+		Label:               pm.label,
+		Uptime:              time.Since(pm.startTime),
+		PacketsProcessed:    pm.packetsProcessed.Load(),
+		BytesProcessed:      pm.bytesProcessed.Load(),
+		TotalProcessingTime: time.Duration(pm.totalProcessingTime.Load()),
+		Errors:              pm.errorCount.Load(),
+	}
+	for i := range pm.latency {
+		s.Latency[i] = pm.latency[i].Load()
+	}
+	return s
 }
 
-// StartPeriodicReporting starts periodic performance reporting
-func (pm *PerformanceMonitor) StartPeriodicReporting(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	go func() {
-		for range ticker.C {
-			logging.LogPerformanceMetric("MONITOR", "periodic_stats", pm.GetStats())
+// Since returns statistics of what happened after earlier was taken
+func (s Stats) Since(earlier Stats) Stats {
+	delta := Stats{
+		Label:               s.Label,
+		Uptime:              s.Uptime - earlier.Uptime,
+		PacketsProcessed:    s.PacketsProcessed - earlier.PacketsProcessed,
+		BytesProcessed:      s.BytesProcessed - earlier.BytesProcessed,
+		TotalProcessingTime: s.TotalProcessingTime - earlier.TotalProcessingTime,
+		Errors:              s.Errors - earlier.Errors,
+	}
+	for i := range s.Latency {
+		delta.Latency[i] = s.Latency[i] - earlier.Latency[i]
+	}
+	return delta
+}
+
+// ProcessingTimePercentile estimates processing time below which given fraction of packets fall,
+// as upper bound of bucket holding it. Packets slower than all buckets count as slowest bound.
+func (s Stats) ProcessingTimePercentile(fraction float64) time.Duration {
+	var total uint64
+	for _, count := range s.Latency {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(fraction * float64(total)))
+	var cumulative uint64
+	for i, count := range s.Latency {
+		cumulative += count
+		if cumulative >= rank && i < len(latencyBounds) {
+			return latencyBounds[i]
 		}
-	}()
+	}
+	return latencyBounds[len(latencyBounds)-1]
 }
 
-func RecordPacketProcessed(bytes int, processingTime time.Duration) {
-	globalPerfMonitor.RecordPacketProcessed(bytes, processingTime)
+// AvgProcessingTime is mean processing time of packet
+func (s Stats) AvgProcessingTime() time.Duration {
+	if s.PacketsProcessed == 0 {
+		return 0
+	}
+	return s.TotalProcessingTime / time.Duration(s.PacketsProcessed)
+}
+
+// PacketsPerSecond is mean rate of processed packets since start
+func (s Stats) PacketsPerSecond() float64 {
+	if s.Uptime.Seconds() <= 0 {
+		return 0
+	}
+	return float64(s.PacketsProcessed) / s.Uptime.Seconds()
 }
 
-func RecordError() {
-	globalPerfMonitor.RecordError()
+func (s Stats) String() string {
+	return fmt.Sprintf("packets=%d bytes=%d packets_per_second=%.2f avg_processing_time=%s errors=%d",
+		s.PacketsProcessed, s.BytesProcessed, s.PacketsPerSecond(), s.AvgProcessingTime(), s.Errors)
 }
 
+// PrintGlobalStats prints statistics of every service, their total and memory usage
 func PrintGlobalStats() {
-	globalPerfMonitor.PrintStats()
+	var total Stats
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\n=== Performance Statistics ===\n")
+	fmt.Fprintf(w, "service\tpackets\tbytes\tpackets/s\tavg processing\terrors\n")
+	for _, pm := range Monitors() {
+		s := pm.Stats()
+		printStatsRow(w, s.Label, s)
+		total.Uptime = max(total.Uptime, s.Uptime)
+		total.PacketsProcessed += s.PacketsProcessed
+		total.BytesProcessed += s.BytesProcessed
+		total.TotalProcessingTime += s.TotalProcessingTime
+		total.Errors += s.Errors
+	}
+	printStatsRow(w, "TOTAL", total)
+	w.Flush()
+
+	mem := GetCurrentMemoryStats()
+	fmt.Printf("\nUptime: %.2f seconds\n", total.Uptime.Seconds())
+	fmt.Printf("Current Memory: %.2f MB\n", float64(mem.Alloc)/1024/1024)
+	fmt.Printf("Total Allocated: %.2f MB\n", float64(mem.TotalAlloc)/1024/1024)
+	fmt.Printf("GC Cycles: %d\n", mem.NumGC)
+	fmt.Printf("Heap Objects: %d\n", mem.HeapObjects)
+	fmt.Printf("Total Mallocs: %d\n", mem.Mallocs)
+	fmt.Printf("Total Frees: %d\n", mem.Frees)
+	fmt.Printf("==============================\n\n")
 }
 
+func printStatsRow(w *tabwriter.Writer, label string, s Stats) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%s\t%d\n", label, s.PacketsProcessed, s.BytesProcessed, s.PacketsPerSecond(), s.AvgProcessingTime(), s.Errors)
+}
+
+// StartGlobalReporting periodically logs statistics of every service and takes memory snapshot
 func StartGlobalReporting(cfg *config.LoggingConfig) {
 	var interval = time.Second * time.Duration(cfg.PerformanceReportInterval)
-	globalPerfMonitor.StartPeriodicReporting(interval)
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			for _, pm := range Monitors() {
+				logging.LogPerformanceMetric(pm.label, "periodic_stats", pm.Stats())
+			}
+			mem := RecordMemorySnapshot()
+			logging.LogPerformanceMetric("MONITOR", "memory", fmt.Sprintf("alloc_mb=%.2f num_gc=%d heap_objects=%d",
+				float64(mem.Alloc)/1024/1024, mem.NumGC, mem.HeapObjects))
+		}
+	}()
 }
@@ -0,0 +1,50 @@
+package profiling
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitorsArePerLabel(t *testing.T) {
+	status := Monitor("TEST_STATUS")
+	world := Monitor("TEST_WORLD")
+	if Monitor("TEST_STATUS") != status {
+		t.Errorf("Expected same monitor for same label")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				status.RecordPacketProcessed(31, time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+	world.RecordPacketProcessed(100, time.Millisecond)
+	world.RecordError()
+
+	s := status.Stats()
+	if s.PacketsProcessed != 800 || s.BytesProcessed != 800*31 || s.Errors != 0 {
+		t.Errorf("Expected 800 packets of 31 bytes without errors, got %+v", s)
+	}
+	if s.AvgProcessingTime() != time.Microsecond {
+		t.Errorf("Expected average of 1µs, got %s", s.AvgProcessingTime())
+	}
+	w := world.Stats()
+	if w.PacketsProcessed != 1 || w.Errors != 1 {
+		t.Errorf("Expected 1 packet and 1 error for world, got %+v", w)
+	}
+}
+
+func TestMemorySnapshotsAreBounded(t *testing.T) {
+	for i := 0; i < maxMemorySnapshots+5; i++ {
+		RecordMemorySnapshot()
+	}
+	if n := len(GetMemoryTrend()); n != maxMemorySnapshots {
+		t.Errorf("Expected %d snapshots, got %d", maxMemorySnapshots, n)
+	}
+}
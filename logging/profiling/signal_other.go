@@ -0,0 +1,11 @@
+//go:build !unix
+
+package profiling
+
+import (
+	"context"
+	"time"
+)
+
+// CaptureOnSignal does nothing, there's no SIGUSR1 on this platform
+func CaptureOnSignal(ctx context.Context, dir string, duration time.Duration) {}
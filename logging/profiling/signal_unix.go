@@ -0,0 +1,37 @@
+//go:build unix
+
+package profiling
+
+import (
+	"ChromehoundsStatusServer/logging"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// CaptureOnSignal captures heap profile, cpu profile and execution trace into dir
+// every time SIGUSR1 is received, until ctx is done
+func CaptureOnSignal(ctx context.Context, dir string, duration time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			logging.Info.Printf("[PROFILE] SIGUSR1 received, capturing profiles into %s", dir)
+			for _, kind := range []string{Heap, CPU, Trace} {
+				path, err := Capture(kind, duration, dir)
+				if err != nil {
+					logging.Error.Printf("[PROFILE] %s capture failed: %v", kind, err)
+					continue
+				}
+				logging.Info.Printf("[PROFILE] %s written to %s", kind, path)
+			}
+		}
+	}
+}
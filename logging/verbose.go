@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// switches of verbose logging by server label, toggled at runtime through control socket or admin api
+var verbose sync.Map
+
+// VerboseSwitch returns verbose logging switch of label, created with initial value on first use.
+// Servers load it once per loop iteration, so toggling takes effect with the next packet.
+func VerboseSwitch(label string, initial bool) *atomic.Bool {
+	enabled := &atomic.Bool{}
+	enabled.Store(initial)
+	actual, _ := verbose.LoadOrStore(label, enabled)
+	return actual.(*atomic.Bool)
+}
+
+// SetVerbose toggles verbose logging of label. Returns false if no server uses the label.
+func SetVerbose(label string, enabled bool) bool {
+	value, ok := verbose.Load(label)
+	if ok {
+		value.(*atomic.Bool).Store(enabled)
+	}
+	return ok
+}
+
+// SetVerboseAll toggles verbose logging of all labels
+func SetVerboseAll(enabled bool) {
+	verbose.Range(func(_, value any) bool {
+		value.(*atomic.Bool).Store(enabled)
+		return true
+	})
+}
+
+// VerboseLabels returns whether verbose logging is enabled, by label
+func VerboseLabels() map[string]bool {
+	labels := make(map[string]bool)
+	verbose.Range(func(label, value any) bool {
+		labels[label.(string)] = value.(*atomic.Bool).Load()
+		return true
+	})
+	return labels
+}
@@ -1,17 +1,43 @@
 package main
 
 import (
+	"ChromehoundsStatusServer/accounts"
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/audit"
+	"ChromehoundsStatusServer/cli"
 	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/control"
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/feed"
+	"ChromehoundsStatusServer/health"
+	"ChromehoundsStatusServer/leaderboard"
 	"ChromehoundsStatusServer/logging"
 	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/maintenance"
 	"ChromehoundsStatusServer/pooling"
+	"ChromehoundsStatusServer/presence"
+	"ChromehoundsStatusServer/probe"
+	"ChromehoundsStatusServer/publicapi"
+	"ChromehoundsStatusServer/relay"
+	"ChromehoundsStatusServer/results"
 	"ChromehoundsStatusServer/server"
+	"ChromehoundsStatusServer/session"
+	"ChromehoundsStatusServer/squads"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/telemetry"
+	"ChromehoundsStatusServer/war"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -21,9 +47,26 @@ import (
 var wg sync.WaitGroup
 
 func main() {
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		args = append([]string{"serve"}, args...)
+	}
+	if args[0] != "serve" {
+		os.Exit(cli.Run(args, os.Stdout, os.Stderr))
+	}
+
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := flags.String("config", config.DefaultPath, "path of config file")
+	flags.Parse(args[1:])
+	serve(*configPath)
+}
+
+// runs all configured servers until interrupted
+func serve(configPath string) {
+	started := time.Now()
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
-	var cfg = config.LoadConfig()
+	var cfg = config.LoadConfig(configPath)
 	logging.Info.Println("Config Loaded")
 
 	// Initialize buffer pools for performance
@@ -38,36 +81,318 @@ func main() {
 		)
 	}
 
-	if cfg.Prometheus.Enabled {
-		http.Handle(cfg.Prometheus.PrometheusHttpPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
-		go http.ListenAndServe(cfg.Prometheus.PrometheusListenAddress, nil)
-	}
-
 	// Start performance monitoring if enabled
 	if cfg.Logging.EnablePerformanceMonitoring {
 		profiling.StartGlobalReporting(&cfg.Logging)
+		if cfg.Prometheus.Enabled {
+			reg.MustRegister(profiling.Collector())
+		}
 		logging.Info.Println("Performance monitoring enabled")
 		defer profiling.PrintGlobalStats()
 	}
 
+	go profiling.CaptureOnSignal(ctx, cfg.Profiling.CaptureDir, time.Duration(cfg.Profiling.SignalCaptureSeconds)*time.Second)
+	if cfg.Profiling.AutoCapture {
+		watchdog := profiling.NewWatchdog(cfg.Profiling)
+		if cfg.Prometheus.Enabled {
+			reg.MustRegister(watchdog)
+		}
+		go watchdog.Run(ctx)
+	}
+
+	// Metrics are exported from prometheus registry, so they stay the same on both
+	if cfg.OpenTelemetry.Enabled {
+		shutdownTelemetry, err := telemetry.Setup(ctx, cfg.OpenTelemetry, reg)
+		if err != nil {
+			logging.Error.Printf("[OTEL] export failed to start: %v", err)
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTelemetry(shutdownCtx); err != nil {
+				logging.Warn.Printf("[OTEL] failed flushing export: %v", err)
+			}
+		}()
+	}
+
+	// Shared subsystems used by servers
+	bus := events.NewBus()
+	sessions := session.NewRegistry(time.Duration(cfg.Sessions.IdleTimeoutSeconds)*time.Second, cfg.Sessions.MaxSessions, cfg.Sessions.MaxAnonymousPerNetwork)
+	sessions.SetEvents(bus)
+	if cfg.Prometheus.Enabled {
+		reg.MustRegister(bus)
+	}
+	go sessions.Run(ctx)
+	if cfg.Prometheus.Enabled {
+		reg.MustRegister(sessions)
+	}
+	healthRegistry := health.NewRegistry()
+	for _, serverConfig := range cfg.Servers {
+		if serverConfig.Enabled {
+			healthRegistry.Register(serverConfig.Label)
+		}
+	}
+	if cfg.Prometheus.Enabled {
+		reg.MustRegister(healthRegistry)
+	}
+	services := &server.Services{
+		Sessions: sessions,
+		Events:   bus,
+		Health:   healthRegistry,
+	}
+
+	store, err := storage.Open(cfg.Storage)
+	if err != nil {
+		logging.Error.Printf("[STORAGE] %v - fallback to in-memory storage, state will not persist", err)
+		store = storage.NewMemoryStore()
+	}
+	defer store.Close()
+
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditLog, err = audit.Open(cfg.Audit.Path)
+		if err != nil {
+			logging.Error.Printf("[AUDIT] %v - audit log disabled", err)
+		} else {
+			defer auditLog.Close()
+		}
+	}
+
+	if cfg.Accounts.Enabled {
+		accountRegistry, err := accounts.Open(store, cfg.Accounts)
+		if err != nil {
+			logging.Error.Printf("[ACCOUNTS] %v - accounts disabled", err)
+		} else {
+			services.Accounts = accountRegistry
+			go accountRegistry.Run(ctx)
+			// runs before store is closed, so logins since last flush are not lost
+			defer func() {
+				if _, err := accountRegistry.Flush(time.Now()); err != nil {
+					logging.Warn.Printf("[ACCOUNTS] %v", err)
+				}
+			}()
+		}
+	}
+
+	if cfg.Presence.Enabled {
+		presenceTracker, err := presence.Open(store, cfg.Presence.MaxPlayers)
+		if err != nil {
+			logging.Error.Printf("[PRESENCE] %v - presence disabled", err)
+		} else {
+			services.Presence = presenceTracker
+			go presenceTracker.Run(ctx)
+			if cfg.Prometheus.Enabled {
+				reg.MustRegister(presenceTracker)
+			}
+		}
+	}
+
+	maintenanceSchedule, err := maintenance.Open(store)
+	if err != nil {
+		logging.Error.Printf("[MAINTENANCE] %v - maintenance scheduling disabled", err)
+	} else {
+		maintenanceSchedule.SetEvents(bus)
+		services.Maintenance = maintenanceSchedule
+	}
+
+	var seasonScheduler *war.Scheduler
+	if cfg.War.Enabled {
+		campaign, err := war.Open(store, cfg.War, time.Now())
+		if err != nil {
+			logging.Error.Printf("[WAR] %v - war disabled, default season is advertised", err)
+		} else {
+			campaign.SetEvents(bus)
+			campaign.SetMaintenance(services.Maintenance)
+			services.War = campaign
+			seasonScheduler = war.NewScheduler(campaign, services.Maintenance, cfg.War)
+			if auditLog != nil {
+				seasonScheduler.SetAuditor(auditLog)
+			}
+			go seasonScheduler.Run(ctx)
+			if cfg.Prometheus.Enabled {
+				reg.MustRegister(campaign)
+			}
+		}
+	}
+
+	if cfg.Squads.Enabled {
+		squadRegistry, err := squads.Open(store, cfg.Squads, services.Accounts)
+		if err != nil {
+			logging.Error.Printf("[SQUADS] %v - squads disabled", err)
+		} else {
+			services.Squads = squadRegistry
+			if cfg.Prometheus.Enabled {
+				reg.MustRegister(squadRegistry)
+			}
+		}
+	}
+
+	if cfg.Results.Enabled {
+		recorder, err := results.Open(store, cfg.Results, services.War, services.Squads)
+		if err != nil {
+			logging.Error.Printf("[RESULTS] %v - result recording disabled", err)
+		} else {
+			services.Results = recorder
+			if services.Accounts != nil {
+				recorder.SetBanned(services.Accounts.IsBanned)
+			}
+			if cfg.Prometheus.Enabled {
+				reg.MustRegister(recorder)
+			}
+		}
+	}
+
+	var board *leaderboard.Board
+	if cfg.Leaderboards.Enabled && services.Results != nil {
+		board, err = leaderboard.New(services.Results, services.War, cfg.Leaderboards)
+		if err != nil {
+			logging.Error.Printf("[LEADERBOARD] %v - leaderboards disabled", err)
+		} else if cfg.Prometheus.Enabled && cfg.Leaderboards.ExportedEntries > 0 {
+			reg.MustRegister(leaderboard.NewCollector(board, cfg.Leaderboards.ExportedEntries))
+		}
+	}
+
+	for _, serverConfig := range cfg.Servers {
+		if serverConfig.Enabled && serverConfig.Type == config.Relay {
+			services.Relay = relay.NewRegistry(cfg.Relay.MaxMembersPerSession, time.Duration(cfg.Relay.SessionIdleTimeoutSeconds)*time.Second, cfg.Relay.MaxBytesPerSecond, cfg.Relay.MaxSessionsPerAddress)
+			if services.Accounts != nil {
+				services.Relay.SetBanned(services.Accounts.IsBanned)
+			}
+			go services.Relay.Run(ctx)
+			if cfg.Prometheus.Enabled {
+				reg.MustRegister(services.Relay)
+			}
+			break
+		}
+	}
+
+	// Public http endpoints share the prometheus listener
+	if cfg.Prometheus.Enabled {
+		http.Handle(cfg.Prometheus.PrometheusHttpPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	}
+	if services.Presence != nil {
+		http.Handle(cfg.Presence.HttpPath, services.Presence.Handler(cfg.Presence.ListPlayers))
+	}
+	if board != nil {
+		http.Handle(cfg.Leaderboards.HttpPath+"/", http.StripPrefix(cfg.Leaderboards.HttpPath, board.Handler(cfg.Leaderboards.PageSize, cfg.Leaderboards.MaxPageSize)))
+	}
+	if cfg.PublicApi.Enabled {
+		api := publicapi.New(cfg.PublicApi, publicapi.Sources{
+			Maintenance: services.Maintenance,
+			Presence:    services.Presence,
+			War:         services.War,
+			Seasons:     seasonScheduler,
+		})
+		http.Handle(cfg.PublicApi.HttpPath+"/", http.StripPrefix(cfg.PublicApi.HttpPath, api))
+	}
+	if cfg.Feed.Enabled {
+		http.Handle(cfg.Feed.HttpPath+"/", http.StripPrefix(cfg.Feed.HttpPath, feed.New(bus, cfg.Feed).Handler()))
+	}
+	if cfg.Health.Enabled {
+		checks := health.NewChecks(healthRegistry, services.Maintenance, cfg.Health.UnreadyDuringMaintenance)
+		http.HandleFunc("GET "+cfg.Health.LivePath, checks.Live)
+		http.HandleFunc("GET "+cfg.Health.ReadyPath, checks.Ready)
+	}
+	if cfg.Prometheus.Enabled || services.Presence != nil || board != nil || cfg.PublicApi.Enabled || cfg.Feed.Enabled || cfg.Health.Enabled {
+		serveHTTP(ctx, cfg.Prometheus.PrometheusListenAddress)
+	}
+
+	if cfg.Admin.Enabled || cfg.Admin.SocketPath != "" {
+		adminServer := admin.NewServer(cfg.Admin)
+		server.RegisterAdminRoutes(adminServer, cfg.Servers, services, started)
+		control.New(configPath, cfg).RegisterAdminRoutes(adminServer)
+		if auditLog != nil {
+			adminServer.SetAuditor(auditLog)
+			auditLog.RegisterAdminRoutes(adminServer)
+		}
+		sessions.RegisterAdminRoutes(adminServer)
+		if services.Presence != nil {
+			services.Presence.RegisterAdminRoutes(adminServer)
+		}
+		if services.Accounts != nil {
+			services.Accounts.RegisterAdminRoutes(adminServer)
+		}
+		if services.Maintenance != nil {
+			services.Maintenance.RegisterAdminRoutes(adminServer)
+		}
+		if services.Relay != nil {
+			services.Relay.RegisterAdminRoutes(adminServer)
+		}
+		if services.War != nil {
+			services.War.RegisterAdminRoutes(adminServer)
+			seasonScheduler.RegisterAdminRoutes(adminServer)
+		}
+		if services.Squads != nil {
+			services.Squads.RegisterAdminRoutes(adminServer)
+		}
+		if services.Results != nil {
+			services.Results.RegisterAdminRoutes(adminServer)
+		}
+		if cfg.Admin.Enabled {
+			go adminServer.Run(ctx)
+		}
+		if cfg.Admin.SocketPath != "" {
+			go adminServer.RunSocket(ctx)
+		}
+	}
+
 	logging.Info.Println("App started")
 	var address = net.ParseIP(cfg.ListeningAddress)
 	for _, serverConfig := range cfg.Servers {
 		if serverConfig.Enabled {
+			serverReg := prometheus.WrapRegistererWith(prometheus.Labels{"server_type": string(serverConfig.Type), "server_name": string(serverConfig.Label)}, reg)
 			switch serverConfig.Type {
 			case config.Status:
-				go server.RunStatusServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, prometheus.WrapRegistererWith(prometheus.Labels{"server_type": string(serverConfig.Type), "server_name": string(serverConfig.Label)}, reg))
+				go server.RunStatusServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, serverReg, services)
 			case config.Echoing:
-				go server.RunEchoingServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, prometheus.WrapRegistererWith(prometheus.Labels{"server_type": string(serverConfig.Type), "server_name": string(serverConfig.Label)}, reg))
+				go server.RunEchoingServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, serverReg, services)
+			case config.Lobby:
+				go server.RunLobbyServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, &cfg.Lobby, ctx, &wg, cfg.Prometheus, serverReg, services)
+			case config.Introducer:
+				go server.RunIntroducerServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, &cfg.Introducer, ctx, &wg, cfg.Prometheus, serverReg, services)
+			case config.Relay:
+				go server.RunRelayServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, serverReg, services)
+			case config.Results:
+				go server.RunResultsServer(address, &serverConfig, cfg.DefaultBufferSize, &cfg.Logging, ctx, &wg, cfg.Prometheus, serverReg, services)
 			default:
 				logging.Error.Printf("Unsupported server type: %s\n", serverConfig.Type)
+				healthRegistry.Set(serverConfig.Label, health.Failed, fmt.Errorf("unsupported server type %s", serverConfig.Type))
 			}
 		}
 	}
 
+	if cfg.Probe.Enabled {
+		var probeReg prometheus.Registerer
+		if cfg.Prometheus.Enabled {
+			probeReg = reg
+		}
+		go probe.New(probe.Targets(cfg), cfg.Probe, probeReg).Run(ctx)
+	}
+
 	// Sleep forever (or until manually stopped)
 	<-ctx.Done()
 	logging.Info.Println("Shuting down")
 	wg.Wait()
 	logging.Info.Println("Shut down")
 }
+
+// serveHTTP binds listener of public http endpoints and serves them until ctx is done
+func serveHTTP(ctx context.Context, address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logging.Error.Printf("[HTTP] failed to listen on %s: %v", address, err)
+		return
+	}
+	httpServer := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error.Printf("[HTTP] %v", err)
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package maintenance
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RegisterAdminRoutes exposes maintenance scheduling on admin api
+func (s *Schedule) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /maintenance", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		response := map[string]any{
+			"active":  s.Active(now),
+			"windows": s.List(),
+		}
+		if next, ok := s.Next(now); ok {
+			response["next"] = next
+		}
+		admin.WriteJSON(w, http.StatusOK, response)
+	})
+
+	a.HandleFunc("POST /maintenance", func(w http.ResponseWriter, r *http.Request) {
+		var request Window
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid window: "+err.Error())
+			return
+		}
+		window, err := s.Add(request.Start, request.End, request.Reason)
+		switch {
+		case errors.Is(err, ErrInvalidWindow):
+			admin.WriteError(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.Audit(r, "maintenance.schedule", window.ID, nil, window)
+			admin.WriteJSON(w, http.StatusCreated, window)
+		}
+	})
+
+	a.HandleFunc("DELETE /maintenance/{id}", func(w http.ResponseWriter, r *http.Request) {
+		window, err := s.Cancel(r.PathValue("id"))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			admin.WriteError(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.Audit(r, "maintenance.cancel", window.ID, window, nil)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package maintenance
+
+import (
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/storage"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const windowsBucket = "maintenance"
+
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial maintenance schedule schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+var (
+	ErrNotFound      = errors.New("maintenance window not found")
+	ErrInvalidWindow = errors.New("maintenance window has to end after it starts")
+)
+
+// Window is a period of time servers are announced to be under maintenance
+type Window struct {
+	ID     string    `json:"id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason"`
+}
+
+// payload of events about window
+func (w Window) event() events.Maintenance {
+	return events.Maintenance{ID: w.ID, Start: w.Start, End: w.End, Reason: w.Reason}
+}
+
+// Active reports whether window covers given moment
+func (w Window) Active(now time.Time) bool {
+	return !now.Before(w.Start) && now.Before(w.End)
+}
+
+// Schedule holds planned maintenance windows persisted in storage.
+// Safe for concurrent use.
+type Schedule struct {
+	store   storage.Store
+	events  *events.Bus
+	mu      sync.RWMutex
+	windows []Window // ordered by start
+}
+
+// Open migrates schema and loads scheduled windows from store
+func Open(store storage.Store) (*Schedule, error) {
+	if err := storage.Migrate(store, windowsBucket, migrations); err != nil {
+		return nil, err
+	}
+
+	s := &Schedule{store: store}
+	err := storage.LoadAllJSON(store, windowsBucket, func(_ string, w Window) {
+		s.windows = append(s.windows, w)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading maintenance schedule: %w", err)
+	}
+	s.sort()
+	return s, nil
+}
+
+// SetEvents makes schedule publish changes of windows to bus
+func (s *Schedule) SetEvents(bus *events.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = bus
+}
+
+// Add schedules new maintenance window and returns it with assigned ID
+func (s *Schedule) Add(start time.Time, end time.Time, reason string) (Window, error) {
+	if !end.After(start) {
+		return Window{}, ErrInvalidWindow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := Window{
+		ID:     s.newID(start),
+		Start:  start,
+		End:    end,
+		Reason: reason,
+	}
+	if err := storage.PutJSON(s.store, windowsBucket, w.ID, w); err != nil {
+		return Window{}, err
+	}
+	s.windows = append(s.windows, w)
+	s.sort()
+	s.events.Publish(events.MaintenanceScheduled, w.event())
+	return w, nil
+}
+
+// Cancel removes scheduled window
+func (s *Schedule) Cancel(id string) (Window, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, w := range s.windows {
+		if w.ID == id {
+			if err := s.store.Delete(windowsBucket, id); err != nil {
+				return Window{}, err
+			}
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			s.events.Publish(events.MaintenanceCancelled, w.event())
+			return w, nil
+		}
+	}
+	return Window{}, ErrNotFound
+}
+
+// List returns all windows, including those already over, ordered by start
+func (s *Schedule) List() []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]Window, len(s.windows))
+	copy(result, s.windows)
+	return result
+}
+
+// Next returns window that is active at now or the closest upcoming one
+func (s *Schedule) Next(now time.Time) (Window, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, w := range s.windows {
+		if now.Before(w.End) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Active reports whether maintenance is in progress
+func (s *Schedule) Active(now time.Time) bool {
+	w, ok := s.Next(now)
+	return ok && w.Active(now)
+}
+
+// IDs are derived from start time, which keeps them short and roughly sortable.
+// caller has to hold write lock.
+func (s *Schedule) newID(start time.Time) string {
+	for n := start.UnixNano(); ; n++ {
+		id := strconv.FormatInt(n, 36)
+		if !slices.ContainsFunc(s.windows, func(w Window) bool { return w.ID == id }) {
+			return id
+		}
+	}
+}
+
+func (s *Schedule) sort() {
+	sort.Slice(s.windows, func(i, j int) bool {
+		return s.windows[i].Start.Before(s.windows[j].Start)
+	})
+}
@@ -0,0 +1,51 @@
+package maintenance
+
+import (
+	"ChromehoundsStatusServer/storage"
+	"testing"
+	"time"
+)
+
+func TestScheduleNextAndCancel(t *testing.T) {
+	store := storage.NewMemoryStore()
+	schedule, err := Open(store)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	now := time.Now()
+
+	if _, err := schedule.Add(now, now.Add(-time.Hour), "backwards"); err != ErrInvalidWindow {
+		t.Errorf("Expected ErrInvalidWindow, got %v", err)
+	}
+
+	later, _ := schedule.Add(now.Add(48*time.Hour), now.Add(50*time.Hour), "later")
+	sooner, _ := schedule.Add(now.Add(24*time.Hour), now.Add(26*time.Hour), "sooner")
+	schedule.Add(now.Add(-2*time.Hour), now.Add(-time.Hour), "over")
+
+	next, ok := schedule.Next(now)
+	if !ok || next.ID != sooner.ID {
+		t.Errorf("Expected sooner window to be next, got %v", next)
+	}
+	if schedule.Active(now) {
+		t.Errorf("Expected no active maintenance")
+	}
+	if !schedule.Active(now.Add(25 * time.Hour)) {
+		t.Errorf("Expected maintenance to be active within window")
+	}
+
+	if _, err := schedule.Cancel(sooner.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if _, err := schedule.Cancel(sooner.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound on second cancel, got %v", err)
+	}
+
+	reloaded, err := Open(store)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	next, ok = reloaded.Next(now)
+	if !ok || next.ID != later.ID {
+		t.Errorf("Expected later window to be next after reload, got %v", next)
+	}
+}
@@ -0,0 +1,97 @@
+package presence
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var onlinePlayersDesc = prometheus.NewDesc(
+	"presence_online_players",
+	"Number of distinct players active within sliding window",
+	[]string{"window", "service"}, nil,
+)
+
+// Describe implements prometheus.Collector
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- onlinePlayersDesc
+}
+
+// Collect implements prometheus.Collector. Total over all services is reported with service="all".
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	for name, counts := range t.CountAll(time.Now()) {
+		ch <- prometheus.MustNewConstMetric(onlinePlayersDesc, prometheus.GaugeValue, float64(counts.Total), name, "all")
+		for service, n := range counts.PerService {
+			ch <- prometheus.MustNewConstMetric(onlinePlayersDesc, prometheus.GaugeValue, float64(n), name, service)
+		}
+	}
+}
+
+// how long public response is served from cache, counting walks all tracked players
+const handlerCacheTTL = 5 * time.Second
+
+// Handler serves public json with online counts. Player list is included only when listPlayers is set.
+func (t *Tracker) Handler(listPlayers bool) http.Handler {
+	var mu sync.Mutex
+	var response map[string]any
+	var built time.Time
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		mu.Lock()
+		if response == nil || now.Sub(built) >= handlerCacheTTL {
+			response = map[string]any{
+				"windows": t.CountAll(now),
+			}
+			if listPlayers {
+				response["players"] = t.OnlinePlayers(Windows[0].Duration, now)
+			}
+			built = now
+		}
+		cached := response
+		mu.Unlock()
+		admin.WriteJSON(w, http.StatusOK, cached)
+	})
+}
+
+// RegisterAdminRoutes exposes opt-in management of public player list on admin api
+func (t *Tracker) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("PUT /presence/optin/{xuid}", func(w http.ResponseWriter, r *http.Request) {
+		tag := r.URL.Query().Get("tag")
+		if tag == "" {
+			admin.WriteError(w, http.StatusBadRequest, "missing tag")
+			return
+		}
+		xuid := r.PathValue("xuid")
+		before, found := t.Tag(xuid)
+		if err := t.OptIn(xuid, tag); err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.Audit(r, "presence.optin", xuid, optional(before, found), tag)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	a.HandleFunc("DELETE /presence/optin/{xuid}", func(w http.ResponseWriter, r *http.Request) {
+		xuid := r.PathValue("xuid")
+		before, found := t.Tag(xuid)
+		if err := t.OptOut(xuid); err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if found {
+			admin.Audit(r, "presence.optout", xuid, before, nil)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func optional(tag string, found bool) any {
+	if !found {
+		return nil
+	}
+	return tag
+}
@@ -0,0 +1,228 @@
+package presence
+
+import (
+	"ChromehoundsStatusServer/storage"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// opt-ins to public player list, public tag keyed by xuid
+const optInBucket = "presence_optin"
+
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial presence opt-in schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+// Window is a sliding time window players are counted over
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows reported by tracker. Longest window also bounds how long activity is remembered.
+var Windows = []Window{
+	{Name: "5m", Duration: 5 * time.Minute},
+	{Name: "1h", Duration: time.Hour},
+	{Name: "24h", Duration: 24 * time.Hour},
+}
+
+var retention = Windows[len(Windows)-1].Duration
+
+// Counts holds number of distinct players active within a window
+type Counts struct {
+	Total      int            `json:"total"`
+	PerService map[string]int `json:"per_service"`
+}
+
+// OnlinePlayer is an entry of public player list
+type OnlinePlayer struct {
+	Tag      string    `json:"tag"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Tracker remembers when each XUID was last active on each service.
+// Activity is kept in memory only, opt-ins to public player list are persisted in storage.
+// Safe for concurrent use.
+type Tracker struct {
+	store      storage.Store
+	maxPlayers int
+
+	mu       sync.RWMutex
+	lastSeen map[string]map[string]time.Time // xuid -> service label -> last activity
+	optedIn  map[string]string               // xuid -> public player tag
+}
+
+// Open creates presence tracker with opt-ins loaded from store. At most maxPlayers players are tracked,
+// 0 meaning unlimited. Players first seen while tracker is full are left out until old activity is pruned.
+func Open(store storage.Store, maxPlayers int) (*Tracker, error) {
+	if err := storage.Migrate(store, optInBucket, migrations); err != nil {
+		return nil, err
+	}
+
+	t := &Tracker{
+		store:      store,
+		maxPlayers: maxPlayers,
+		lastSeen:   make(map[string]map[string]time.Time),
+		optedIn:    make(map[string]string),
+	}
+	err := storage.LoadAllJSON(store, optInBucket, func(xuid string, tag string) {
+		t.optedIn[xuid] = tag
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading presence opt-ins: %w", err)
+	}
+	return t, nil
+}
+
+// Seen records activity of player on service
+func (t *Tracker) Seen(xuid string, service string, now time.Time) {
+	if xuid == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	services, ok := t.lastSeen[xuid]
+	if !ok {
+		if t.maxPlayers > 0 && len(t.lastSeen) >= t.maxPlayers {
+			return
+		}
+		services = make(map[string]time.Time)
+		t.lastSeen[xuid] = services
+	}
+	services[service] = now
+}
+
+// Count returns number of distinct players active within duration before now, in total and per service
+func (t *Tracker) Count(within time.Duration, now time.Time) Counts {
+	since := now.Add(-within)
+	counts := Counts{PerService: make(map[string]int)}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, services := range t.lastSeen {
+		active := false
+		for service, seen := range services {
+			if seen.After(since) {
+				counts.PerService[service]++
+				active = true
+			}
+		}
+		if active {
+			counts.Total++
+		}
+	}
+	return counts
+}
+
+// CountAll returns counts for every window, keyed by window name
+func (t *Tracker) CountAll(now time.Time) map[string]Counts {
+	result := make(map[string]Counts, len(Windows))
+	for _, w := range Windows {
+		result[w.Name] = t.Count(w.Duration, now)
+	}
+	return result
+}
+
+// OptIn makes player visible under given tag in public list of online players
+func (t *Tracker) OptIn(xuid string, tag string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := storage.PutJSON(t.store, optInBucket, xuid, tag); err != nil {
+		return fmt.Errorf("saving opt-in of %s: %w", xuid, err)
+	}
+	t.optedIn[xuid] = tag
+	return nil
+}
+
+// Tag returns public tag of player opted in to public list
+func (t *Tracker) Tag(xuid string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tag, ok := t.optedIn[xuid]
+	return tag, ok
+}
+
+// OptOut removes player from public list of online players
+func (t *Tracker) OptOut(xuid string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.optedIn[xuid]; !ok {
+		return nil
+	}
+	if err := t.store.Delete(optInBucket, xuid); err != nil {
+		return fmt.Errorf("deleting opt-in of %s: %w", xuid, err)
+	}
+	delete(t.optedIn, xuid)
+	return nil
+}
+
+// OnlinePlayers lists opted in players active within duration before now, most recent first
+func (t *Tracker) OnlinePlayers(within time.Duration, now time.Time) []OnlinePlayer {
+	since := now.Add(-within)
+
+	t.mu.RLock()
+	players := make([]OnlinePlayer, 0, len(t.optedIn))
+	for xuid, tag := range t.optedIn {
+		var latest time.Time
+		for _, seen := range t.lastSeen[xuid] {
+			if seen.After(latest) {
+				latest = seen
+			}
+		}
+		if latest.After(since) {
+			players = append(players, OnlinePlayer{Tag: tag, LastSeen: latest})
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].LastSeen.After(players[j].LastSeen)
+	})
+	return players
+}
+
+// Prune forgets activity older than the longest window
+func (t *Tracker) Prune(now time.Time) {
+	since := now.Add(-retention)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for xuid, services := range t.lastSeen {
+		for service, seen := range services {
+			if !seen.After(since) {
+				delete(services, service)
+			}
+		}
+		if len(services) == 0 {
+			delete(t.lastSeen, xuid)
+		}
+	}
+}
+
+// Run periodically prunes old activity until context is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.Prune(now)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package presence
+
+import (
+	"ChromehoundsStatusServer/storage"
+	"testing"
+	"time"
+)
+
+func openTestTracker(t *testing.T, store storage.Store) *Tracker {
+	tracker, err := Open(store, 0)
+	if err != nil {
+		t.Fatalf("Failed opening tracker: %v", err)
+	}
+	return tracker
+}
+
+func TestCountWindows(t *testing.T) {
+	tracker := openTestTracker(t, storage.NewMemoryStore())
+	now := time.Now()
+
+	tracker.Seen("PLAYER1", "STATUS", now.Add(-time.Minute))
+	tracker.Seen("PLAYER1", "WORLD", now.Add(-time.Minute))
+	tracker.Seen("PLAYER2", "STATUS", now.Add(-30*time.Minute))
+	tracker.Seen("PLAYER3", "STATUS", now.Add(-12*time.Hour))
+
+	tests := []struct {
+		window        time.Duration
+		expectedTotal int
+		expectedPer   map[string]int
+	}{
+		{5 * time.Minute, 1, map[string]int{"STATUS": 1, "WORLD": 1}},
+		{time.Hour, 2, map[string]int{"STATUS": 2, "WORLD": 1}},
+		{24 * time.Hour, 3, map[string]int{"STATUS": 3, "WORLD": 1}},
+	}
+
+	for _, tt := range tests {
+		counts := tracker.Count(tt.window, now)
+		if counts.Total != tt.expectedTotal {
+			t.Errorf("Window %v: expected total %d, got %d", tt.window, tt.expectedTotal, counts.Total)
+		}
+		for service, expected := range tt.expectedPer {
+			if counts.PerService[service] != expected {
+				t.Errorf("Window %v: expected %d on %s, got %d", tt.window, expected, service, counts.PerService[service])
+			}
+		}
+	}
+}
+
+func TestOnlinePlayersOnlyOptedIn(t *testing.T) {
+	store := storage.NewMemoryStore()
+	tracker := openTestTracker(t, store)
+	now := time.Now()
+
+	tracker.Seen("PLAYER1", "STATUS", now)
+	tracker.Seen("PLAYER2", "STATUS", now)
+	tracker.OptIn("PLAYER2", "Hound Two")
+
+	players := tracker.OnlinePlayers(5*time.Minute, now)
+	if len(players) != 1 || players[0].Tag != "Hound Two" {
+		t.Errorf("Expected only opted in player to be listed, got %v", players)
+	}
+
+	reopened := openTestTracker(t, store)
+	reopened.Seen("PLAYER2", "STATUS", now)
+	if players := reopened.OnlinePlayers(5*time.Minute, now); len(players) != 1 || players[0].Tag != "Hound Two" {
+		t.Errorf("Expected opt in to persist, got %v", players)
+	}
+
+	tracker.OptOut("PLAYER2")
+	if players := tracker.OnlinePlayers(5*time.Minute, now); len(players) != 0 {
+		t.Errorf("Expected empty list after opt out, got %v", players)
+	}
+	if _, ok := openTestTracker(t, store).Tag("PLAYER2"); ok {
+		t.Errorf("Expected opt out to persist")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tracker := openTestTracker(t, storage.NewMemoryStore())
+	now := time.Now()
+
+	tracker.Seen("PLAYER1", "STATUS", now.Add(-25*time.Hour))
+	tracker.Seen("PLAYER2", "STATUS", now)
+	tracker.Prune(now)
+
+	if counts := tracker.Count(48*time.Hour, now); counts.Total != 1 {
+		t.Errorf("Expected stale activity to be pruned, got %d players", counts.Total)
+	}
+}
+
+func TestMaxPlayers(t *testing.T) {
+	tracker, err := Open(storage.NewMemoryStore(), 2)
+	if err != nil {
+		t.Fatalf("Failed opening tracker: %v", err)
+	}
+	now := time.Now()
+
+	tracker.Seen("PLAYER1", "STATUS", now.Add(-25*time.Hour))
+	tracker.Seen("PLAYER2", "STATUS", now)
+	tracker.Seen("PLAYER3", "STATUS", now)
+	tracker.Seen("PLAYER2", "WORLD", now)
+	if counts := tracker.Count(48*time.Hour, now); counts.Total != 2 || counts.PerService["WORLD"] != 1 {
+		t.Errorf("Expected 2 tracked players with known player still updated, got %v", counts)
+	}
+
+	tracker.Prune(now)
+	tracker.Seen("PLAYER3", "STATUS", now)
+	if counts := tracker.Count(time.Minute, now); counts.Total != 2 {
+		t.Errorf("Expected pruned player to free its slot, got %d players", counts.Total)
+	}
+}
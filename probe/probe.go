@@ -0,0 +1,201 @@
+package probe
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/constants"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/status"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Result of single probe, used as metric label
+const (
+	ResultOK      = "ok"
+	ResultTimeout = "timeout"
+	ResultInvalid = "invalid" // response arrived, but doesn't match what was sent
+	ResultError   = "error"
+)
+
+// ErrInvalidResponse wraps all problems found in response
+var ErrInvalidResponse = errors.New("invalid response")
+
+// Target is single server checked by prober
+type Target struct {
+	Label string
+	Type  config.ServerType
+	Addr  string
+}
+
+// Prober periodically sends hello message to status and echo servers over UDP,
+// as clients do, and records round trip time and validity of responses
+type Prober struct {
+	targets  []Target
+	interval time.Duration
+	timeout  time.Duration
+
+	duration *prometheus.HistogramVec
+	attempts *prometheus.CounterVec
+	success  *prometheus.GaugeVec
+}
+
+// Targets returns enabled status and echo servers of config, reached at cfg.Probe.Address
+func Targets(cfg config.Config) []Target {
+	host := cfg.Probe.Address
+	if host == "" {
+		host = "127.0.0.1"
+		if ip := net.ParseIP(cfg.ListeningAddress); ip != nil && !ip.IsUnspecified() {
+			host = ip.String()
+		}
+	}
+	var targets []Target
+	for _, s := range cfg.Servers {
+		if s.Enabled && (s.Type == config.Status || s.Type == config.Echoing) {
+			targets = append(targets, Target{Label: s.Label, Type: s.Type, Addr: net.JoinHostPort(host, strconv.Itoa(s.Port))})
+		}
+	}
+	return targets
+}
+
+// New creates prober of targets with metrics registered in reg, which may be nil
+func New(targets []Target, cfg config.ProbeConfig, reg prometheus.Registerer) *Prober {
+	factory := promauto.With(reg)
+	return &Prober{
+		targets:  targets,
+		interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		timeout:  time.Duration(cfg.TimeoutMillis) * time.Millisecond,
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "probe_duration_seconds",
+			Help:    "Round trip time of successful synthetic probes",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"server_name", "server_type"}),
+		attempts: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "probe_attempts_total",
+			Help: "Synthetic probes sent, by result",
+		}, []string{"server_name", "server_type", "result"}),
+		success: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "probe_success",
+			Help: "Whether last synthetic probe of server succeeded",
+		}, []string{"server_name", "server_type"}),
+	}
+}
+
+// Run probes all targets every interval until ctx is done.
+// First probe is sent after one interval, giving servers time to bind.
+func (p *Prober) Run(ctx context.Context) {
+	if len(p.targets) == 0 {
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.ProbeAll()
+		}
+	}
+}
+
+// ProbeAll probes every target once and records results
+func (p *Prober) ProbeAll() {
+	for _, target := range p.targets {
+		rtt, err := Probe(target, p.timeout)
+		result := classify(err)
+		p.attempts.WithLabelValues(target.Label, string(target.Type), result).Inc()
+		if err != nil {
+			p.success.WithLabelValues(target.Label, string(target.Type)).Set(0)
+			logging.Warn.Printf("[PROBE] %s at %s: %v", target.Label, target.Addr, err)
+			continue
+		}
+		p.success.WithLabelValues(target.Label, string(target.Type)).Set(1)
+		p.duration.WithLabelValues(target.Label, string(target.Type)).Observe(rtt.Seconds())
+	}
+}
+
+func classify(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ResultOK
+	case errors.Is(err, ErrInvalidResponse):
+		return ResultInvalid
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ResultTimeout
+	default:
+		return ResultError
+	}
+}
+
+// Probe sends hello message to target and validates response, returning round trip time
+func Probe(target Target, timeout time.Duration) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", target.Addr, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	hello := HelloPacket()
+	response := make([]byte, constants.MaxBufferSize)
+	start := time.Now()
+	conn.SetDeadline(start.Add(timeout))
+	if _, err := conn.Write(hello); err != nil {
+		return 0, err
+	}
+	n, err := conn.Read(response)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+
+	switch target.Type {
+	case config.Status:
+		err = ValidateStatusResponse(response[:n], status.ProbeXuid)
+	case config.Echoing:
+		if !bytes.Equal(response[:n], hello) {
+			err = fmt.Errorf("%w: echo of %d bytes differs from %d bytes sent", ErrInvalidResponse, n, len(hello))
+		}
+	}
+	return rtt, err
+}
+
+// HelloPacket encodes hello message carrying probe xuid
+func HelloPacket() []byte {
+	packet := make([]byte, constants.MinHelloMessageSize)
+	binary.Encode(packet, binary.LittleEndian, status.CreateHello(status.ProbeXuid))
+	return packet
+}
+
+// ValidateStatusResponse checks response is server state sent back to xuid, with plausible times in it
+func ValidateStatusResponse(response []byte, xuid [15]byte) error {
+	if len(response) != constants.StatusResponseSize {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrInvalidResponse, len(response), constants.StatusResponseSize)
+	}
+	var state status.ServerState
+	if _, err := binary.Decode(response, binary.LittleEndian, &state); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if state.Header != status.CreateHeader(xuid) {
+		return fmt.Errorf("%w: unexpected header %q", ErrInvalidResponse, response[:31])
+	}
+	for name, t := range map[string]status.ServerTime{
+		"server time":       state.ServerLocalTime,
+		"maintenance start": state.ServerMaintenanceStartTime,
+		"maintenance end":   state.ServerMaintenanceEndTime,
+	} {
+		if t.Month < 1 || t.Month > 12 || t.Day < 1 || t.Day > 31 || t.Hour > 23 || t.Minute > 59 || t.Second > 59 {
+			return fmt.Errorf("%w: malformed %s %+v", ErrInvalidResponse, name, t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package probe
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/status"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serves udp on loopback, answering every packet with reply(packet); nil reply means no answer
+func serveUDP(t *testing.T, reply func([]byte) []byte) string {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if response := reply(buffer[:n]); response != nil {
+				conn.WriteToUDP(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func statusResponse(xuid [15]byte) []byte {
+	now := time.Now()
+	response := make([]byte, 64)
+	binary.Encode(response, binary.LittleEndian, status.CreateStatus(xuid, 3, now, now.Add(-time.Hour), now.Add(time.Hour)))
+	return response
+}
+
+func TestProbeStatus(t *testing.T) {
+	addr := serveUDP(t, func(packet []byte) []byte {
+		var hello status.UserHelloMessage
+		binary.Decode(packet, binary.LittleEndian, &hello)
+		return statusResponse(hello.Xuid)
+	})
+	if _, err := Probe(Target{Label: "STATUS", Type: config.Status, Addr: addr}, time.Second); err != nil {
+		t.Errorf("Expected valid status response, got %v", err)
+	}
+}
+
+func TestProbeEcho(t *testing.T) {
+	addr := serveUDP(t, func(packet []byte) []byte { return packet })
+	if _, err := Probe(Target{Label: "WORLD", Type: config.Echoing, Addr: addr}, time.Second); err != nil {
+		t.Errorf("Expected valid echo, got %v", err)
+	}
+
+	addr = serveUDP(t, func(packet []byte) []byte { return packet[:8] })
+	_, err := Probe(Target{Label: "WORLD", Type: config.Echoing, Addr: addr}, time.Second)
+	if classify(err) != ResultInvalid {
+		t.Errorf("Expected truncated echo to be invalid, got %v", err)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	addr := serveUDP(t, func([]byte) []byte { return nil })
+	_, err := Probe(Target{Label: "STATUS", Type: config.Status, Addr: addr}, 50*time.Millisecond)
+	if classify(err) != ResultTimeout {
+		t.Errorf("Expected timeout, got %v", err)
+	}
+}
+
+func TestValidateStatusResponse(t *testing.T) {
+	if err := ValidateStatusResponse(statusResponse(status.ProbeXuid), status.ProbeXuid); err != nil {
+		t.Errorf("Expected valid response, got %v", err)
+	}
+	if err := ValidateStatusResponse(statusResponse(status.ProbeXuid)[:32], status.ProbeXuid); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected short response to be invalid, got %v", err)
+	}
+	if err := ValidateStatusResponse(statusResponse(status.XuidValueHardCoded), status.ProbeXuid); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected response to other xuid to be invalid, got %v", err)
+	}
+	malformed := statusResponse(status.ProbeXuid)
+	malformed[42] = 13 // month of server time
+	if err := ValidateStatusResponse(malformed, status.ProbeXuid); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected malformed time to be invalid, got %v", err)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	cfg := config.Config{
+		ListeningAddress: "0.0.0.0",
+		Servers: []config.ServerConfig{
+			{Label: "STATUS", Port: 1207, Enabled: true, Type: config.Status},
+			{Label: "WORLD", Port: 1215, Enabled: false, Type: config.Echoing},
+			{Label: "LOBBY", Port: 1300, Enabled: true, Type: config.Lobby},
+		},
+	}
+	targets := Targets(cfg)
+	if len(targets) != 1 || targets[0].Addr != "127.0.0.1:1207" {
+		t.Errorf("Expected only status server on loopback, got %+v", targets)
+	}
+}
@@ -0,0 +1,28 @@
+package protocol
+
+import (
+	"net"
+	"net/netip"
+)
+
+// Endpoint is UDP address in wire format. IPv4 addresses are stored IPv4-mapped.
+//
+// 18 bytes
+type Endpoint struct {
+	IP   [16]byte
+	Port uint16
+}
+
+// EndpointFromUDPAddr converts address to wire format
+func EndpointFromUDPAddr(addr *net.UDPAddr) Endpoint {
+	var e Endpoint
+	copy(e.IP[:], addr.IP.To16())
+	e.Port = uint16(addr.Port)
+	return e
+}
+
+// UDPAddr converts wire format back to address
+func (e Endpoint) UDPAddr() *net.UDPAddr {
+	ip := netip.AddrFrom16(e.IP).Unmap()
+	return &net.UDPAddr{IP: ip.AsSlice(), Port: int(e.Port)}
+}
@@ -0,0 +1,107 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Services we run on top of the game protocol use CHxx framing like the status service,
+// with xx identifying the service. Codes are our own, status service uses "00".
+//
+// Frame layout (little endian):
+//
+//	[4]byte  magic   'C', 'H', x, x
+//	uint8    opcode
+//	uint16   payload length
+//	[]byte   payload
+type ServiceCode [2]byte
+
+var (
+	ServiceLobby      = ServiceCode{'1', '0'}
+	ServiceIntroducer = ServiceCode{'1', '1'}
+	ServiceRelay      = ServiceCode{'1', '2'}
+	ServiceResults    = ServiceCode{'1', '3'}
+)
+
+const FrameHeaderSize = 7
+
+var (
+	ErrFrameTooShort    = errors.New("frame shorter than header")
+	ErrInvalidMagic     = errors.New("invalid CH magic")
+	ErrPayloadTruncated = errors.New("payload shorter than declared length")
+)
+
+// Frame is a single decoded message
+type Frame struct {
+	Service ServiceCode
+	Opcode  uint8
+	Payload []byte
+}
+
+// DecodeFrame parses frame from packet. Payload references packet memory.
+func DecodeFrame(packet []byte) (Frame, error) {
+	if len(packet) < FrameHeaderSize {
+		return Frame{}, ErrFrameTooShort
+	}
+	if packet[0] != 'C' || packet[1] != 'H' {
+		return Frame{}, ErrInvalidMagic
+	}
+
+	length := int(binary.LittleEndian.Uint16(packet[5:7]))
+	if len(packet)-FrameHeaderSize < length {
+		return Frame{}, ErrPayloadTruncated
+	}
+
+	return Frame{
+		Service: ServiceCode{packet[2], packet[3]},
+		Opcode:  packet[4],
+		Payload: packet[FrameHeaderSize : FrameHeaderSize+length],
+	}, nil
+}
+
+// Encode serializes frame into newly allocated buffer
+func (f Frame) Encode() ([]byte, error) {
+	if len(f.Payload) > 0xFFFF {
+		return nil, fmt.Errorf("payload too large: %d bytes", len(f.Payload))
+	}
+
+	buffer := make([]byte, FrameHeaderSize+len(f.Payload))
+	buffer[0] = 'C'
+	buffer[1] = 'H'
+	buffer[2] = f.Service[0]
+	buffer[3] = f.Service[1]
+	buffer[4] = f.Opcode
+	binary.LittleEndian.PutUint16(buffer[5:7], uint16(len(f.Payload)))
+	copy(buffer[FrameHeaderSize:], f.Payload)
+	return buffer, nil
+}
+
+// NewFrame encodes fixed size message struct as payload of frame
+func NewFrame(service ServiceCode, opcode uint8, message any) (Frame, error) {
+	payload := make([]byte, 0, binary.Size(message))
+	payload, err := binary.Append(payload, binary.LittleEndian, message)
+	if err != nil {
+		return Frame{}, fmt.Errorf("encoding opcode %d: %w", opcode, err)
+	}
+	return Frame{Service: service, Opcode: opcode, Payload: payload}, nil
+}
+
+// DecodePayload decodes fixed size message struct from frame payload
+func (f Frame) DecodePayload(message any) error {
+	if _, err := binary.Decode(f.Payload, binary.LittleEndian, message); err != nil {
+		return fmt.Errorf("decoding opcode %d: %w", f.Opcode, err)
+	}
+	return nil
+}
+
+// AppendPayload encodes fixed size message struct at the end of payload,
+// used for responses made of header followed by variable number of entries
+func (f *Frame) AppendPayload(message any) error {
+	payload, err := binary.Append(f.Payload, binary.LittleEndian, message)
+	if err != nil {
+		return fmt.Errorf("encoding opcode %d: %w", f.Opcode, err)
+	}
+	f.Payload = payload
+	return nil
+}
@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	frame := Frame{Service: ServiceLobby, Opcode: 3, Payload: []byte{1, 2, 3}}
+	encoded, err := frame.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := []byte{'C', 'H', '1', '0', 3, 3, 0, 1, 2, 3}
+	if !bytes.Equal(encoded, expected) {
+		t.Errorf("Unexpected encoding: %v", encoded)
+	}
+
+	decoded, err := DecodeFrame(encoded)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded.Service != frame.Service || decoded.Opcode != frame.Opcode || !bytes.Equal(decoded.Payload, frame.Payload) {
+		t.Errorf("Round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestDecodeFrameErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		err    error
+	}{
+		{"Too short", []byte{'C', 'H', '1'}, ErrFrameTooShort},
+		{"Invalid magic", []byte{'X', 'Y', '1', '0', 0, 0, 0}, ErrInvalidMagic},
+		{"Truncated payload", []byte{'C', 'H', '1', '0', 0, 5, 0, 1}, ErrPayloadTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeFrame(tt.packet); err != tt.err {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEndpointRoundTrip(t *testing.T) {
+	for _, addr := range []*net.UDPAddr{
+		{IP: net.ParseIP("192.168.1.10"), Port: 1215},
+		{IP: net.ParseIP("2001:db8::1"), Port: 1255},
+	} {
+		converted := EndpointFromUDPAddr(addr).UDPAddr()
+		if !converted.IP.Equal(addr.IP) || converted.Port != addr.Port {
+			t.Errorf("Expected %v, got %v", addr, converted)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package publicapi
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/presence"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/war"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how long clients may reuse response without revalidating it
+const maxAge = 10 * time.Second
+
+// Sources of data served by api, any of them may be nil when the feature is disabled
+type Sources struct {
+	Maintenance *maintenance.Schedule
+	Presence    *presence.Tracker
+	War         *war.Campaign
+	Seasons     *war.Scheduler
+}
+
+// API serves read-only json about server state. Versioned routes live under /v1,
+// breaking changes go to new version while old one keeps being served.
+type API struct {
+	sources Sources
+	limiter *limiter
+	mux     *http.ServeMux
+}
+
+type statusResponse struct {
+	Status          string              `json:"status"` // "up" or "maintenance"
+	Maintenance     *maintenance.Window `json:"maintenance,omitempty"`
+	NextMaintenance *maintenance.Window `json:"next_maintenance,omitempty"`
+}
+
+type seasonResponse struct {
+	Current war.Season  `json:"current"`
+	Next    *war.Season `json:"next,omitempty"`
+}
+
+type territoryResponse struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Owner war.Nation `json:"owner"`
+}
+
+type warResponse struct {
+	Season      uint32              `json:"season"`
+	Territories []territoryResponse `json:"territories"`
+	Owned       map[war.Nation]int  `json:"owned"`
+	Points      map[war.Nation]int  `json:"contribution"`
+	LastChange  time.Time           `json:"last_change"`
+}
+
+// New creates public api. Handler has to be mounted with its path prefix stripped.
+func New(cfg config.PublicApiConfig, sources Sources) *API {
+	a := &API{
+		sources: sources,
+		limiter: newLimiter(cfg.RequestsPerMinute, cfg.Burst),
+		mux:     http.NewServeMux(),
+	}
+	a.mux.HandleFunc("GET /v1/status", a.handleStatus)
+	a.mux.HandleFunc("GET /v1/season", a.handleSeason)
+	a.mux.HandleFunc("GET /v1/online", a.handleOnline)
+	a.mux.HandleFunc("GET /v1/war", a.handleWar)
+	return a
+}
+
+// ServeHTTP applies rate limit of client before routing request
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	if ok, wait := a.limiter.allow(client, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+		admin.WriteError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+	a.mux.ServeHTTP(w, r)
+}
+
+func (a *API) handleStatus(w http.ResponseWriter, r *http.Request) {
+	response := statusResponse{Status: "up"}
+	if a.sources.Maintenance != nil {
+		now := time.Now()
+		if window, ok := a.sources.Maintenance.Next(now); ok {
+			if window.Active(now) {
+				response.Status = "maintenance"
+				response.Maintenance = &window
+			} else {
+				response.NextMaintenance = &window
+			}
+		}
+	}
+	writeCached(w, r, response)
+}
+
+func (a *API) handleSeason(w http.ResponseWriter, r *http.Request) {
+	if a.sources.War == nil {
+		writeCached(w, r, seasonResponse{Current: war.Season{Number: status.DefaultGameSeason}})
+		return
+	}
+	response := seasonResponse{Current: a.sources.War.CurrentSeason()}
+	if a.sources.Seasons != nil {
+		next := a.sources.Seasons.Next(response.Current)
+		response.Next = &next
+	}
+	writeCached(w, r, response)
+}
+
+func (a *API) handleOnline(w http.ResponseWriter, r *http.Request) {
+	if a.sources.Presence == nil {
+		admin.WriteError(w, http.StatusNotFound, "presence tracking is disabled")
+		return
+	}
+	writeCached(w, r, map[string]any{"windows": a.sources.Presence.CountAll(time.Now())})
+}
+
+func (a *API) handleWar(w http.ResponseWriter, r *http.Request) {
+	if a.sources.War == nil {
+		admin.WriteError(w, http.StatusNotFound, "war is disabled")
+		return
+	}
+	state := a.sources.War.State()
+	response := warResponse{
+		Season:      state.Season.Number,
+		Territories: make([]territoryResponse, len(state.Territories)),
+		Owned:       make(map[war.Nation]int),
+		Points:      state.Contribution,
+		LastChange:  state.LastChange,
+	}
+	for i, t := range state.Territories {
+		response.Territories[i] = territoryResponse{ID: t.ID, Name: t.Name, Owner: t.Owner}
+		response.Owned[t.Owner]++
+	}
+	writeCached(w, r, response)
+}
+
+// writes json with ETag derived from its content, answering with 304 when client already has it
+func writeCached(w http.ResponseWriter, r *http.Request, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// If-None-Match may list several tags, or * for any
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package publicapi
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func get(api *API, path string, remoteAddr string, etag string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.RemoteAddr = remoteAddr
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestStatusAndETag(t *testing.T) {
+	schedule, _ := maintenance.Open(storage.NewMemoryStore())
+	api := New(config.PublicApiConfig{RequestsPerMinute: 60, Burst: 10}, Sources{Maintenance: schedule})
+
+	response := get(api, "/v1/status", "10.0.0.1:1000", "")
+	var status statusResponse
+	json.NewDecoder(response.Body).Decode(&status)
+	if response.Code != http.StatusOK || status.Status != "up" || status.NextMaintenance != nil {
+		t.Fatalf("Expected up without maintenance, got %d %v", response.Code, status)
+	}
+	etag := response.Header().Get("ETag")
+
+	if response := get(api, "/v1/status", "10.0.0.1:1000", etag); response.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for matching ETag, got %d", response.Code)
+	}
+
+	now := time.Now()
+	schedule.Add(now.Add(-time.Minute), now.Add(time.Hour), "upgrade")
+	response = get(api, "/v1/status", "10.0.0.1:1000", etag)
+	json.NewDecoder(response.Body).Decode(&status)
+	if response.Code != http.StatusOK || status.Status != "maintenance" || status.Maintenance == nil {
+		t.Errorf("Expected maintenance status after change, got %d %v", response.Code, status)
+	}
+
+	if response := get(api, "/v1/war", "10.0.0.1:1000", ""); response.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for disabled war, got %d", response.Code)
+	}
+}
+
+func TestRateLimitPerClient(t *testing.T) {
+	api := New(config.PublicApiConfig{RequestsPerMinute: 1, Burst: 2}, Sources{})
+
+	for i := 0; i < 2; i++ {
+		if response := get(api, "/v1/season", "10.0.0.1:1000", ""); response.Code != http.StatusOK {
+			t.Fatalf("Expected request %d within burst to pass, got %d", i, response.Code)
+		}
+	}
+	response := get(api, "/v1/season", "10.0.0.1:2000", "")
+	if response.Code != http.StatusTooManyRequests || response.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After for same client, got %d", response.Code)
+	}
+	if response := get(api, "/v1/season", "10.0.0.2:1000", ""); response.Code != http.StatusOK {
+		t.Errorf("Expected other client to pass, got %d", response.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package publicapi
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// clients idle for this long are forgotten, their bucket would be full again anyway
+const limiterIdleTimeout = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter is token bucket rate limiter per client address. Safe for concurrent use.
+type limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func newLimiter(requestsPerMinute int, burst int) *limiter {
+	return &limiter{
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes token of client. When none is left, returns how long until next one is available.
+func (l *limiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > limiterIdleTimeout {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// caller has to hold lock
+func (l *limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > limiterIdleTimeout {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,13 @@
+package relay
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"net/http"
+)
+
+// RegisterAdminRoutes exposes relay sessions on admin api
+func (r *Registry) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /relay/sessions", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.Sessions())
+	})
+}
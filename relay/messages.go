@@ -0,0 +1,157 @@
+package relay
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/status"
+	"errors"
+	"net"
+	"time"
+)
+
+// Opcodes of relay service. Data frames are forwarded to other members unchanged.
+const (
+	OpAllocate uint8 = 0x01
+	OpJoin     uint8 = 0x02
+	OpLeave    uint8 = 0x03
+	OpData     uint8 = 0x04
+
+	OpAllocated uint8 = 0x81
+	OpJoined    uint8 = 0x82
+	OpLeft      uint8 = 0x83
+	OpError     uint8 = 0xFF
+)
+
+// Error codes sent in OpError response
+const (
+	ErrorCodeMalformed uint8 = iota + 1
+	ErrorCodeSessionNotFound
+	ErrorCodeSessionFull
+	ErrorCodeNotAllowed
+	ErrorCodeNotMember
+	ErrorCodeUnknownOpcode
+	ErrorCodeInternal
+	ErrorCodeTooManySessions
+	ErrorCodeAddressInUse
+	ErrorCodeBanned
+)
+
+// 15 bytes
+type AllocateRequest struct {
+	Xuid [15]byte
+}
+
+// 19 bytes
+type JoinRequest struct {
+	Xuid  [15]byte
+	Token uint32
+}
+
+// 4 bytes, payload of allocated and left responses, and prefix of every data frame
+type TokenMessage struct {
+	Token uint32
+}
+
+// 5 bytes
+type JoinedResponse struct {
+	Token       uint32
+	MemberCount uint8
+}
+
+// 2 bytes
+type ErrorResponse struct {
+	RequestOpcode uint8
+	Code          uint8
+}
+
+const tokenSize = 4
+
+// Result tells server what to do with handled packet
+type Result struct {
+	Response  *protocol.Frame // reply to sender, nil if none
+	ForwardTo []*net.UDPAddr  // members the original packet has to be forwarded to
+}
+
+// HandleFrame executes relay request. Data frames produce no response, only recipients.
+func (r *Registry) HandleFrame(frame protocol.Frame, addr *net.UDPAddr, now time.Time) Result {
+	switch frame.Opcode {
+	case OpData:
+		if len(frame.Payload) < tokenSize {
+			return Result{}
+		}
+		var header TokenMessage
+		frame.DecodePayload(&header)
+		// failures are not answered, so spoofed data frames can't be used for reflection
+		recipients, _ := r.Forward(header.Token, addr, len(frame.Payload)-tokenSize, now)
+		return Result{ForwardTo: recipients}
+
+	case OpAllocate:
+		var request AllocateRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorResult(frame.Opcode, ErrorCodeMalformed)
+		}
+		xuid := status.XuidString(request.Xuid)
+		token, err := r.AllocateFrom(addr, now)
+		if err != nil {
+			return errorResult(frame.Opcode, errorCode(err))
+		}
+		if _, err := r.Join(token, xuid, addr, now); err != nil {
+			return errorResult(frame.Opcode, errorCode(err))
+		}
+		return response(OpAllocated, TokenMessage{Token: token})
+
+	case OpJoin:
+		var request JoinRequest
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorResult(frame.Opcode, ErrorCodeMalformed)
+		}
+		members, err := r.Join(request.Token, status.XuidString(request.Xuid), addr, now)
+		if err != nil {
+			return errorResult(frame.Opcode, errorCode(err))
+		}
+		return response(OpJoined, JoinedResponse{Token: request.Token, MemberCount: uint8(members)})
+
+	case OpLeave:
+		var request TokenMessage
+		if err := frame.DecodePayload(&request); err != nil {
+			return errorResult(frame.Opcode, ErrorCodeMalformed)
+		}
+		if err := r.Leave(request.Token, addr); err != nil {
+			return errorResult(frame.Opcode, errorCode(err))
+		}
+		return response(OpLeft, request)
+
+	default:
+		return errorResult(frame.Opcode, ErrorCodeUnknownOpcode)
+	}
+}
+
+func errorCode(err error) uint8 {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return ErrorCodeSessionNotFound
+	case errors.Is(err, ErrSessionFull):
+		return ErrorCodeSessionFull
+	case errors.Is(err, ErrNotAllowed):
+		return ErrorCodeNotAllowed
+	case errors.Is(err, ErrNotMember):
+		return ErrorCodeNotMember
+	case errors.Is(err, ErrTooManySessions):
+		return ErrorCodeTooManySessions
+	case errors.Is(err, ErrAddressInUse):
+		return ErrorCodeAddressInUse
+	case errors.Is(err, ErrBanned):
+		return ErrorCodeBanned
+	default:
+		return ErrorCodeInternal
+	}
+}
+
+func errorResult(requestOpcode uint8, code uint8) Result {
+	return response(OpError, ErrorResponse{RequestOpcode: requestOpcode, Code: code})
+}
+
+// all messages are fixed size structs, encoding them can't fail
+func response(opcode uint8, payload any) Result {
+	frame, _ := protocol.NewFrame(protocol.ServiceRelay, opcode, payload)
+	return Result{Response: &frame}
+}
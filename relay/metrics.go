@@ -0,0 +1,103 @@
+package relay
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// at most this many open sessions are exported one by one, oldest first
+const maxExportedSessions = 100
+
+// Sessions are labelled by their id rather than token, as token is all that's needed
+// to join session open to anyone.
+var (
+	activeSessionsDesc = prometheus.NewDesc(
+		"relay_sessions_active",
+		"Number of open relay sessions",
+		nil, nil,
+	)
+	membersDesc = prometheus.NewDesc(
+		"relay_members",
+		"Number of members joined to open relay sessions",
+		nil, nil,
+	)
+	relayedBytesDesc = prometheus.NewDesc(
+		"relay_bytes_total",
+		"Total bytes relayed over all sessions, including closed ones",
+		[]string{"direction"}, nil,
+	)
+	droppedDesc = prometheus.NewDesc(
+		"relay_dropped_packets_total",
+		"Total packets dropped due to bandwidth limit, including closed sessions",
+		nil, nil,
+	)
+	rejectedAllocationsDesc = prometheus.NewDesc(
+		"relay_rejected_allocations_total",
+		"Total allocation requests rejected as address holds too many sessions",
+		nil, nil,
+	)
+	sessionBytesDesc = prometheus.NewDesc(
+		"relay_session_bytes_total",
+		"Bytes relayed by open session",
+		[]string{"session", "direction"}, nil,
+	)
+	sessionMembersDesc = prometheus.NewDesc(
+		"relay_session_members",
+		"Number of members joined to open session",
+		[]string{"session"}, nil,
+	)
+	sessionDroppedDesc = prometheus.NewDesc(
+		"relay_session_dropped_packets_total",
+		"Packets of open session dropped due to bandwidth limit",
+		[]string{"session"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+	ch <- membersDesc
+	ch <- relayedBytesDesc
+	ch <- droppedDesc
+	ch <- rejectedAllocationsDesc
+	ch <- sessionBytesDesc
+	ch <- sessionMembersDesc
+	ch <- sessionDroppedDesc
+}
+
+// Collect implements prometheus.Collector
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	totalIn, totalOut, dropped := r.expiredBytesIn, r.expiredBytesOut, r.expiredDropped
+	members := 0
+	sessions := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		totalIn += s.bytesIn
+		totalOut += s.bytesOut
+		dropped += s.packetsDropped
+		members += len(s.members)
+		sessions = append(sessions, s.info())
+	}
+	rejected := r.rejectedAllocations
+	r.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(len(sessions)))
+	ch <- prometheus.MustNewConstMetric(membersDesc, prometheus.GaugeValue, float64(members))
+	ch <- prometheus.MustNewConstMetric(relayedBytesDesc, prometheus.CounterValue, float64(totalIn), "in")
+	ch <- prometheus.MustNewConstMetric(relayedBytesDesc, prometheus.CounterValue, float64(totalOut), "out")
+	ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(dropped))
+	ch <- prometheus.MustNewConstMetric(rejectedAllocationsDesc, prometheus.CounterValue, float64(rejected))
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+	for _, s := range sessions[:min(len(sessions), maxExportedSessions)] {
+		id := strconv.FormatUint(s.ID, 10)
+		ch <- prometheus.MustNewConstMetric(sessionBytesDesc, prometheus.CounterValue, float64(s.BytesIn), id, "in")
+		ch <- prometheus.MustNewConstMetric(sessionBytesDesc, prometheus.CounterValue, float64(s.BytesOut), id, "out")
+		ch <- prometheus.MustNewConstMetric(sessionMembersDesc, prometheus.GaugeValue, float64(len(s.Members)), id)
+		ch <- prometheus.MustNewConstMetric(sessionDroppedDesc, prometheus.CounterValue, float64(s.PacketsDropped), id)
+	}
+}
@@ -0,0 +1,357 @@
+package relay
+
+import (
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/protocol"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = errors.New("relay session not found")
+	ErrSessionFull     = errors.New("relay session is full")
+	ErrNotAllowed      = errors.New("player not allowed in relay session")
+	ErrNotMember       = errors.New("sender is not member of relay session")
+	ErrRateLimited     = errors.New("relay session bandwidth exceeded")
+	ErrTooManySessions = errors.New("too many relay sessions allocated from address")
+	ErrAddressInUse    = errors.New("player or address already joined relay session from elsewhere")
+	ErrBanned          = errors.New("player is banned")
+)
+
+// member is a client joined to relay session
+type member struct {
+	xuid string
+	addr *net.UDPAddr
+}
+
+// session forwards datagrams between its members
+type session struct {
+	id           uint64 // sequential, unlike token it's safe to publish
+	token        uint32
+	created      time.Time
+	lastActivity time.Time
+	allowed      []string // xuids allowed to join, empty means anyone with the token
+	allocatedBy  string   // ip of client that allocated session, empty for sessions of introducer
+	members      []member
+
+	bytesIn        uint64
+	bytesOut       uint64
+	packetsIn      uint64
+	packetsDropped uint64
+
+	windowStart time.Time
+	windowBytes uint64
+}
+
+// SessionInfo is snapshot of session state
+type SessionInfo struct {
+	ID             uint64    `json:"id"`
+	Token          uint32    `json:"token"`
+	Created        time.Time `json:"created"`
+	LastActivity   time.Time `json:"last_activity"`
+	Members        []string  `json:"members"`
+	BytesIn        uint64    `json:"bytes_in"`
+	BytesOut       uint64    `json:"bytes_out"`
+	PacketsIn      uint64    `json:"packets_in"`
+	PacketsDropped uint64    `json:"packets_dropped"`
+}
+
+// Registry holds relay sessions. Safe for concurrent use.
+type Registry struct {
+	mu                    sync.Mutex
+	maxMembers            int
+	idleTimeout           time.Duration
+	maxBytesPerSecond     uint64
+	maxSessionsPerAddress int
+	sessions              map[uint32]*session
+	allocations           map[string]int // open sessions by ip that allocated them
+	lastID                uint64
+	expiredBytesIn        uint64
+	expiredBytesOut       uint64
+	expiredDropped        uint64
+	rejectedAllocations   uint64
+	banned                func(xuid string) bool
+}
+
+// NewRegistry creates relay session registry. maxBytesPerSecond of 0 disables bandwidth limit,
+// maxSessionsPerAddress of 0 disables limit of sessions clients can allocate.
+func NewRegistry(maxMembers int, idleTimeout time.Duration, maxBytesPerSecond uint64, maxSessionsPerAddress int) *Registry {
+	return &Registry{
+		maxMembers:            maxMembers,
+		idleTimeout:           idleTimeout,
+		maxBytesPerSecond:     maxBytesPerSecond,
+		maxSessionsPerAddress: maxSessionsPerAddress,
+		sessions:              make(map[uint32]*session),
+		allocations:           make(map[string]int),
+	}
+}
+
+// SetBanned sets check of banned players, who can't join sessions
+func (r *Registry) SetBanned(banned func(xuid string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.banned = banned
+}
+
+// Allocate creates session. When xuids are given, only those players may join it.
+func (r *Registry) Allocate(xuids []string, now time.Time) (uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, err := r.newToken()
+	if err != nil {
+		return 0, err
+	}
+	r.lastID++
+	r.sessions[token] = &session{
+		id:           r.lastID,
+		token:        token,
+		created:      now,
+		lastActivity: now,
+		allowed:      slices.Clone(xuids),
+	}
+	return token, nil
+}
+
+// AllocateFrom creates session open to anyone with the token on request of client at addr.
+// Number of open sessions allocated from single ip is limited.
+func (r *Registry) AllocateFrom(addr *net.UDPAddr, now time.Time) (uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ip := addr.IP.String()
+	if r.maxSessionsPerAddress > 0 && r.allocations[ip] >= r.maxSessionsPerAddress {
+		r.rejectedAllocations++
+		return 0, ErrTooManySessions
+	}
+	token, err := r.newToken()
+	if err != nil {
+		return 0, err
+	}
+	r.lastID++
+	r.sessions[token] = &session{
+		id:           r.lastID,
+		token:        token,
+		created:      now,
+		lastActivity: now,
+		allocatedBy:  ip,
+	}
+	r.allocations[ip]++
+	return token, nil
+}
+
+// Join adds client to session. Member stays bound to address it joined from,
+// joining again under its xuid, or as another player from its address, is refused until it leaves.
+func (r *Registry) Join(token uint32, xuid string, addr *net.UDPAddr, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[token]
+	if !ok {
+		return 0, ErrSessionNotFound
+	}
+	if r.banned != nil && r.banned(xuid) {
+		return 0, ErrBanned
+	}
+	if len(s.allowed) > 0 && !slices.Contains(s.allowed, xuid) {
+		return 0, ErrNotAllowed
+	}
+
+	if i := s.memberIndex(addr); i >= 0 {
+		if s.members[i].xuid != xuid {
+			return 0, ErrAddressInUse
+		}
+		s.lastActivity = now
+		return len(s.members), nil
+	}
+	if slices.ContainsFunc(s.members, func(m member) bool { return m.xuid == xuid }) {
+		return 0, ErrAddressInUse
+	}
+	s.lastActivity = now
+	if len(s.members) >= r.maxMembers {
+		return 0, ErrSessionFull
+	}
+	s.members = append(s.members, member{xuid: xuid, addr: addr})
+	return len(s.members), nil
+}
+
+// Leave removes client from session. Session is closed once empty.
+func (r *Registry) Leave(token uint32, addr *net.UDPAddr) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[token]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	i := s.memberIndex(addr)
+	if i < 0 {
+		return ErrNotMember
+	}
+	s.members = slices.Delete(s.members, i, i+1)
+	if len(s.members) == 0 {
+		r.close(s)
+	}
+	return nil
+}
+
+// Forward accounts datagram of given size sent by member and returns addresses it has to be forwarded to.
+// Bandwidth limit applies to bytes sent out to all recipients.
+func (r *Registry) Forward(token uint32, from *net.UDPAddr, size int, now time.Time) ([]*net.UDPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[token]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	sender := s.memberIndex(from)
+	if sender < 0 {
+		return nil, ErrNotMember
+	}
+
+	s.lastActivity = now
+	s.packetsIn++
+	s.bytesIn += uint64(size)
+
+	out := uint64(size * (len(s.members) - 1))
+	if r.maxBytesPerSecond > 0 {
+		if now.Sub(s.windowStart) >= time.Second {
+			s.windowStart = now
+			s.windowBytes = 0
+		}
+		if s.windowBytes+out > r.maxBytesPerSecond {
+			s.packetsDropped++
+			return nil, ErrRateLimited
+		}
+		s.windowBytes += out
+	}
+
+	recipients := make([]*net.UDPAddr, 0, len(s.members)-1)
+	for i, m := range s.members {
+		if i != sender {
+			recipients = append(recipients, m.addr)
+		}
+	}
+	s.bytesOut += out
+	return recipients, nil
+}
+
+// Expire closes sessions without traffic for longer than idle timeout. Returns number of closed sessions.
+func (r *Registry) Expire(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	closed := 0
+	for _, s := range r.sessions {
+		if now.Sub(s.lastActivity) > r.idleTimeout {
+			r.close(s)
+			closed++
+		}
+	}
+	return closed
+}
+
+// Sessions returns snapshot of all sessions ordered by creation
+func (r *Registry) Sessions() []SessionInfo {
+	r.mu.Lock()
+	result := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		result = append(result, s.info())
+	}
+	r.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Before(result[j].Created)
+	})
+	return result
+}
+
+// Run periodically closes idle sessions until context is cancelled
+func (r *Registry) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if closed := r.Expire(now); closed > 0 {
+				logging.Info.Printf("[RELAY] closed %d idle sessions", closed)
+			}
+		}
+	}
+}
+
+// caller has to hold lock
+func (r *Registry) close(s *session) {
+	r.expiredBytesIn += s.bytesIn
+	r.expiredBytesOut += s.bytesOut
+	r.expiredDropped += s.packetsDropped
+	if s.allocatedBy != "" {
+		if r.allocations[s.allocatedBy]--; r.allocations[s.allocatedBy] <= 0 {
+			delete(r.allocations, s.allocatedBy)
+		}
+	}
+	delete(r.sessions, s.token)
+}
+
+// caller has to hold lock
+func (r *Registry) newToken() (uint32, error) {
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		token := binary.LittleEndian.Uint32(buf[:])
+		if _, taken := r.sessions[token]; token != 0 && !taken {
+			return token, nil
+		}
+	}
+}
+
+func (s *session) memberIndex(addr *net.UDPAddr) int {
+	for i, m := range s.members {
+		if m.addr.IP.Equal(addr.IP) && m.addr.Port == addr.Port {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *session) info() SessionInfo {
+	members := make([]string, len(s.members))
+	for i, m := range s.members {
+		members[i] = m.xuid
+	}
+	return SessionInfo{
+		ID:             s.id,
+		Token:          s.token,
+		Created:        s.created,
+		LastActivity:   s.lastActivity,
+		Members:        members,
+		BytesIn:        s.bytesIn,
+		BytesOut:       s.bytesOut,
+		PacketsIn:      s.packetsIn,
+		PacketsDropped: s.packetsDropped,
+	}
+}
+
+// IntroducerAllocator lets introducer hand out sessions of this relay to peers that failed to punch
+type IntroducerAllocator struct {
+	Registry *Registry
+	Endpoint protocol.Endpoint // public endpoint of relay server
+}
+
+func (a IntroducerAllocator) Allocate(xuids []string, now time.Time) (protocol.Endpoint, uint32, error) {
+	token, err := a.Registry.Allocate(xuids, now)
+	return a.Endpoint, token, err
+}
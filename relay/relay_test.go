@@ -0,0 +1,210 @@
+package relay
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func testAddr(port int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+}
+
+func TestForwardToOtherMembers(t *testing.T) {
+	registry := NewRegistry(3, time.Minute, 0, 0)
+	now := time.Now()
+
+	token, err := registry.Allocate(nil, now)
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	for i, xuid := range []string{"A", "B", "C"} {
+		if _, err := registry.Join(token, xuid, testAddr(i+1), now); err != nil {
+			t.Fatalf("Join of %s failed: %v", xuid, err)
+		}
+	}
+	if _, err := registry.Join(token, "D", testAddr(4), now); err != ErrSessionFull {
+		t.Errorf("Expected ErrSessionFull, got %v", err)
+	}
+
+	recipients, err := registry.Forward(token, testAddr(2), 100, now)
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	if len(recipients) != 2 || recipients[0].Port != 1 || recipients[1].Port != 3 {
+		t.Errorf("Expected forwarding to members 1 and 3, got %v", recipients)
+	}
+	if _, err := registry.Forward(token, testAddr(9), 100, now); err != ErrNotMember {
+		t.Errorf("Expected ErrNotMember for outsider, got %v", err)
+	}
+
+	info := registry.Sessions()[0]
+	if info.BytesIn != 100 || info.BytesOut != 200 || info.PacketsIn != 1 {
+		t.Errorf("Unexpected accounting: %+v", info)
+	}
+}
+
+func TestAllowedMembersAndLeave(t *testing.T) {
+	registry := NewRegistry(8, time.Minute, 0, 0)
+	now := time.Now()
+
+	token, _ := registry.Allocate([]string{"HOST", "JOINER"}, now)
+	if _, err := registry.Join(token, "STRANGER", testAddr(3), now); err != ErrNotAllowed {
+		t.Errorf("Expected ErrNotAllowed, got %v", err)
+	}
+	registry.Join(token, "HOST", testAddr(1), now)
+	registry.Join(token, "JOINER", testAddr(2), now)
+
+	registry.Leave(token, testAddr(1))
+	registry.Leave(token, testAddr(2))
+	if len(registry.Sessions()) != 0 {
+		t.Errorf("Expected empty session to be closed")
+	}
+}
+
+func TestBandwidthLimit(t *testing.T) {
+	registry := NewRegistry(2, time.Minute, 150, 0)
+	now := time.Now()
+
+	token, _ := registry.Allocate(nil, now)
+	registry.Join(token, "A", testAddr(1), now)
+	registry.Join(token, "B", testAddr(2), now)
+
+	if _, err := registry.Forward(token, testAddr(1), 100, now); err != nil {
+		t.Errorf("Expected first packet within limit, got %v", err)
+	}
+	if _, err := registry.Forward(token, testAddr(1), 100, now); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if _, err := registry.Forward(token, testAddr(1), 100, now.Add(time.Second)); err != nil {
+		t.Errorf("Expected limit to reset after a second, got %v", err)
+	}
+	if dropped := registry.Sessions()[0].PacketsDropped; dropped != 1 {
+		t.Errorf("Expected 1 dropped packet, got %d", dropped)
+	}
+
+	// limit applies to bytes sent to all recipients
+	registry.maxMembers = 3
+	registry.Join(token, "C", testAddr(3), now)
+	if _, err := registry.Forward(token, testAddr(1), 100, now.Add(2*time.Second)); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited for packet relayed to two members, got %v", err)
+	}
+}
+
+func TestJoinBindsMemberToAddress(t *testing.T) {
+	registry := NewRegistry(4, time.Minute, 0, 0)
+	now := time.Now()
+	token, _ := registry.Allocate(nil, now)
+	registry.Join(token, "A", testAddr(1), now)
+
+	if _, err := registry.Join(token, "A", testAddr(1), now); err != nil {
+		t.Errorf("Expected rejoin from same address to succeed, got %v", err)
+	}
+	if _, err := registry.Join(token, "A", testAddr(2), now); err != ErrAddressInUse {
+		t.Errorf("Expected ErrAddressInUse for rejoin from other address, got %v", err)
+	}
+	if _, err := registry.Join(token, "B", testAddr(1), now); err != ErrAddressInUse {
+		t.Errorf("Expected ErrAddressInUse for second player from same address, got %v", err)
+	}
+	if members := registry.Sessions()[0].Members; len(members) != 1 {
+		t.Errorf("Expected single member, got %v", members)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	registry := NewRegistry(2, time.Minute, 0, 0)
+	now := time.Now()
+	registry.Allocate(nil, now)
+
+	if closed := registry.Expire(now.Add(2 * time.Minute)); closed != 1 {
+		t.Errorf("Expected idle session to be closed, got %d", closed)
+	}
+}
+
+func TestHandleFrameDataForwarding(t *testing.T) {
+	registry := NewRegistry(2, time.Minute, 0, 0)
+	now := time.Now()
+
+	allocate := AllocateRequest{}
+	copy(allocate.Xuid[:], "HOST")
+	request, _ := protocol.NewFrame(protocol.ServiceRelay, OpAllocate, allocate)
+	result := registry.HandleFrame(request, testAddr(1), now)
+	if result.Response == nil || result.Response.Opcode != OpAllocated {
+		t.Fatalf("Expected allocated response, got %+v", result.Response)
+	}
+	var allocated TokenMessage
+	result.Response.DecodePayload(&allocated)
+
+	join := JoinRequest{Token: allocated.Token}
+	copy(join.Xuid[:], "JOINER")
+	request, _ = protocol.NewFrame(protocol.ServiceRelay, OpJoin, join)
+	result = registry.HandleFrame(request, testAddr(2), now)
+	var joined JoinedResponse
+	result.Response.DecodePayload(&joined)
+	if joined.MemberCount != 2 {
+		t.Errorf("Expected 2 members after join, got %d", joined.MemberCount)
+	}
+
+	data, _ := protocol.NewFrame(protocol.ServiceRelay, OpData, TokenMessage{Token: allocated.Token})
+	data.Payload = append(data.Payload, []byte("game data")...)
+	result = registry.HandleFrame(data, testAddr(2), now)
+	if result.Response != nil || len(result.ForwardTo) != 1 || result.ForwardTo[0].Port != 1 {
+		t.Errorf("Expected data forwarded to host only, got %+v", result)
+	}
+
+	// data for unknown session is dropped silently
+	data, _ = protocol.NewFrame(protocol.ServiceRelay, OpData, TokenMessage{Token: allocated.Token + 1})
+	if result = registry.HandleFrame(data, testAddr(2), now); result.Response != nil || len(result.ForwardTo) != 0 {
+		t.Errorf("Expected unknown session data to be dropped, got %+v", result)
+	}
+}
+
+func TestAllocateFromLimitedPerAddress(t *testing.T) {
+	registry := NewRegistry(2, time.Minute, 0, 2)
+	now := time.Now()
+
+	first, err := registry.AllocateFrom(testAddr(1), now)
+	if err != nil {
+		t.Fatalf("AllocateFrom failed: %v", err)
+	}
+	if _, err := registry.AllocateFrom(testAddr(2), now); err != nil {
+		t.Fatalf("Expected second session from same ip, got %v", err)
+	}
+	if _, err := registry.AllocateFrom(testAddr(3), now); err != ErrTooManySessions {
+		t.Errorf("Expected ErrTooManySessions, got %v", err)
+	}
+	other := &net.UDPAddr{IP: net.ParseIP("127.0.0.2"), Port: 1}
+	if _, err := registry.AllocateFrom(other, now); err != nil {
+		t.Errorf("Expected other ip not to be limited, got %v", err)
+	}
+
+	registry.Join(first, "A", testAddr(1), now)
+	registry.Leave(first, testAddr(1))
+	if _, err := registry.AllocateFrom(testAddr(1), now); err != nil {
+		t.Errorf("Expected closed session to free allocation, got %v", err)
+	}
+}
+
+func TestCollectBoundsSessionSeries(t *testing.T) {
+	registry := NewRegistry(2, time.Minute, 0, 0)
+	now := time.Now()
+	for range maxExportedSessions + 5 {
+		registry.Allocate(nil, now)
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	registry.Collect(ch)
+	close(ch)
+	perSession := 0
+	for metric := range ch {
+		if metric.Desc() == sessionMembersDesc {
+			perSession++
+		}
+	}
+	if perSession != maxExportedSessions {
+		t.Errorf("Expected %d exported sessions, got %d", maxExportedSessions, perSession)
+	}
+}
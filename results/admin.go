@@ -0,0 +1,58 @@
+package results
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RegisterAdminRoutes exposes result ingestion and player statistics on admin api
+func (r *Recorder) RegisterAdminRoutes(a *admin.Server) {
+	// imports results recorded elsewhere. Unlike UDP reports, they are trusted and need no confirmation.
+	a.HandleFunc("POST /results", func(w http.ResponseWriter, req *http.Request) {
+		var result Result
+		if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid result: "+err.Error())
+			return
+		}
+		stored, err := r.Record(result, time.Now())
+		switch {
+		case errors.Is(err, ErrDuplicate):
+			admin.WriteError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, ErrInvalidResult):
+			admin.WriteError(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.Audit(req, "results.record", stored.MatchID, nil, stored)
+			admin.WriteJSON(w, http.StatusCreated, stored)
+		}
+	})
+
+	a.HandleFunc("GET /results/{id}", func(w http.ResponseWriter, req *http.Request) {
+		result, err := r.Get(req.PathValue("id"))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			admin.WriteError(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.WriteJSON(w, http.StatusOK, result)
+		}
+	})
+
+	a.HandleFunc("GET /stats/players", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.AllPlayerStats())
+	})
+
+	a.HandleFunc("GET /players/{xuid}/stats", func(w http.ResponseWriter, req *http.Request) {
+		stats, ok := r.PlayerStats(req.PathValue("xuid"))
+		if !ok {
+			admin.WriteError(w, http.StatusNotFound, "no matches recorded for player")
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, stats)
+	})
+}
@@ -0,0 +1,164 @@
+package results
+
+import (
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/war"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Opcodes of results service
+const (
+	OpReport uint8 = 0x01
+
+	OpAccepted  uint8 = 0x81
+	OpDuplicate uint8 = 0x82
+	OpPending   uint8 = 0x83
+	OpError     uint8 = 0xFF
+)
+
+// Error codes sent in OpError response
+const (
+	ErrorCodeMalformed uint8 = iota + 1
+	ErrorCodeInvalid
+	ErrorCodeInternal
+	ErrorCodeUnknownOpcode
+	ErrorCodeUnverified
+	ErrorCodeBanned
+)
+
+// 37 bytes, followed by ParticipantCount ParticipantMessage entries.
+// Nations are encoded as 1 Morskoj, 2 Rutania, 3 Tarakia.
+type ReportHeader struct {
+	MatchID          uint64
+	Reporter         [15]byte
+	Territory        [8]byte // zero padded territory id
+	Winner           uint8
+	DurationSeconds  uint32
+	ParticipantCount uint8
+}
+
+// 25 bytes. Roles are encoded as position in Roles plus one.
+type ParticipantMessage struct {
+	Xuid         [15]byte
+	Nation       uint8
+	Role         uint8
+	Kills        uint16
+	Deaths       uint16
+	Contribution uint32
+}
+
+// 8 bytes, payload of accepted, duplicate and pending responses
+type MatchMessage struct {
+	MatchID uint64
+}
+
+// 2 bytes
+type ErrorResponse struct {
+	RequestOpcode uint8
+	Code          uint8
+}
+
+var (
+	reportHeaderSize = binary.Size(ReportHeader{})
+	participantSize  = binary.Size(ParticipantMessage{})
+)
+
+var errMalformed = errors.New("malformed result report")
+
+// DecodeReport converts report payload into result
+func DecodeReport(payload []byte) (Result, uint64, error) {
+	var header ReportHeader
+	if _, err := binary.Decode(payload, binary.LittleEndian, &header); err != nil {
+		return Result{}, 0, errMalformed
+	}
+	entries := payload[reportHeaderSize:]
+	if len(entries) < int(header.ParticipantCount)*participantSize {
+		return Result{}, header.MatchID, errMalformed
+	}
+
+	result := Result{
+		MatchID:         strconv.FormatUint(header.MatchID, 16),
+		Reporter:        status.XuidString(header.Reporter),
+		Territory:       strings.TrimRight(string(header.Territory[:]), "\x00"),
+		Winner:          nationFromCode(header.Winner),
+		DurationSeconds: int(header.DurationSeconds),
+		Participants:    make([]Participant, header.ParticipantCount),
+	}
+	for i := range result.Participants {
+		var entry ParticipantMessage
+		binary.Decode(entries[i*participantSize:], binary.LittleEndian, &entry)
+		result.Participants[i] = Participant{
+			Xuid:         status.XuidString(entry.Xuid),
+			Nation:       nationFromCode(entry.Nation),
+			Role:         roleFromCode(entry.Role),
+			Kills:        int(entry.Kills),
+			Deaths:       int(entry.Deaths),
+			Contribution: int(entry.Contribution),
+		}
+	}
+	return result, header.MatchID, nil
+}
+
+// HandleFrame records reported result and builds response for reporter.
+// sender is xuid known to use the address report came from, reports on behalf of anyone else are rejected.
+func (r *Recorder) HandleFrame(frame protocol.Frame, sender string, now time.Time) protocol.Frame {
+	if frame.Opcode != OpReport {
+		return errorFrame(frame.Opcode, ErrorCodeUnknownOpcode)
+	}
+	result, matchID, err := DecodeReport(frame.Payload)
+	if err != nil {
+		r.rejected.Add(1)
+		return errorFrame(frame.Opcode, ErrorCodeMalformed)
+	}
+
+	if sender == "" || result.Reporter != sender {
+		r.rejected.Add(1)
+		return errorFrame(frame.Opcode, ErrorCodeUnverified)
+	}
+
+	_, err = r.Report(result, now)
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		return response(OpDuplicate, MatchMessage{MatchID: matchID})
+	case errors.Is(err, ErrPending):
+		return response(OpPending, MatchMessage{MatchID: matchID})
+	case errors.Is(err, ErrInvalidResult):
+		return errorFrame(frame.Opcode, ErrorCodeInvalid)
+	case errors.Is(err, ErrBanned):
+		return errorFrame(frame.Opcode, ErrorCodeBanned)
+	case err != nil:
+		return errorFrame(frame.Opcode, ErrorCodeInternal)
+	default:
+		return response(OpAccepted, MatchMessage{MatchID: matchID})
+	}
+}
+
+// unknown codes decode to empty values and fail validation
+func nationFromCode(code uint8) war.Nation {
+	if code == 0 || int(code) > len(war.Nations) {
+		return ""
+	}
+	return war.Nations[code-1]
+}
+
+func roleFromCode(code uint8) Role {
+	if code == 0 || int(code) > len(Roles) {
+		return ""
+	}
+	return Roles[code-1]
+}
+
+func errorFrame(requestOpcode uint8, code uint8) protocol.Frame {
+	return response(OpError, ErrorResponse{RequestOpcode: requestOpcode, Code: code})
+}
+
+// all messages are fixed size structs, encoding them can't fail
+func response(opcode uint8, payload any) protocol.Frame {
+	frame, _ := protocol.NewFrame(protocol.ServiceResults, opcode, payload)
+	return frame
+}
@@ -0,0 +1,45 @@
+package results
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	reportsDesc = prometheus.NewDesc(
+		"results_reports_total",
+		"Total number of match result reports by outcome",
+		[]string{"outcome"}, nil,
+	)
+	pendingDesc = prometheus.NewDesc(
+		"results_pending_confirmation",
+		"Number of match results awaiting confirmation by another participant",
+		nil, nil,
+	)
+	playersDesc = prometheus.NewDesc(
+		"results_players_with_stats",
+		"Number of players with at least one recorded match",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	ch <- reportsDesc
+	ch <- pendingDesc
+	ch <- playersDesc
+}
+
+// Collect implements prometheus.Collector
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(reportsDesc, prometheus.CounterValue, float64(r.accepted.Load()), "accepted")
+	ch <- prometheus.MustNewConstMetric(reportsDesc, prometheus.CounterValue, float64(r.duplicates.Load()), "duplicate")
+	ch <- prometheus.MustNewConstMetric(reportsDesc, prometheus.CounterValue, float64(r.rejected.Load()), "rejected")
+	ch <- prometheus.MustNewConstMetric(reportsDesc, prometheus.CounterValue, float64(r.awaiting.Load()), "pending")
+
+	r.mu.Lock()
+	players := len(r.stats)
+	pending := len(r.pending)
+	r.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(pendingDesc, prometheus.GaugeValue, float64(pending))
+	ch <- prometheus.MustNewConstMetric(playersDesc, prometheus.GaugeValue, float64(players))
+}
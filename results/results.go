@@ -0,0 +1,487 @@
+package results
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/squads"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/war"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	resultsBucket     = "results"
+	playerStatsBucket = "player_stats"
+)
+
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial match result schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+var (
+	ErrNotFound       = errors.New("match result not found")
+	ErrDuplicate      = errors.New("match result already reported")
+	ErrInvalidResult  = errors.New("invalid match result")
+	ErrPending        = errors.New("match result awaits confirmation by another participant")
+	ErrTooManyPending = errors.New("too many match results await confirmation")
+	ErrBanned         = errors.New("reporter is banned")
+)
+
+// reports awaiting confirmation are kept in memory, this bounds how many
+const maxPendingResults = 10000
+
+// Role of hound in match
+type Role string
+
+const (
+	RoleSoldier     Role = "soldier"
+	RoleSniper      Role = "sniper"
+	RoleHeavyGunner Role = "heavy_gunner"
+	RoleDefender    Role = "defender"
+	RoleScout       Role = "scout"
+	RoleCommander   Role = "commander"
+)
+
+var Roles = []Role{RoleSoldier, RoleSniper, RoleHeavyGunner, RoleDefender, RoleScout, RoleCommander}
+
+// Participant of match and their performance in it
+type Participant struct {
+	Xuid         string     `json:"xuid"`
+	Nation       war.Nation `json:"nation"`
+	Role         Role       `json:"role"`
+	Kills        int        `json:"kills"`
+	Deaths       int        `json:"deaths"`
+	Contribution int        `json:"contribution"`
+	Squad        string     `json:"squad,omitempty"` // filled in when result is recorded
+}
+
+// Result of finished match. MatchID is assigned by the hosting client and identifies the match
+// across reports of all participants.
+type Result struct {
+	MatchID         string        `json:"match_id"`
+	Reporter        string        `json:"reporter"`
+	Season          uint32        `json:"season"`
+	Territory       string        `json:"territory"`
+	Winner          war.Nation    `json:"winner"`
+	DurationSeconds int           `json:"duration_seconds"`
+	Reported        time.Time     `json:"reported"`
+	Participants    []Participant `json:"participants"`
+}
+
+// PlayerStats are totals of all matches reported for player
+type PlayerStats struct {
+	Xuid            string       `json:"xuid"`
+	Matches         int          `json:"matches"`
+	Wins            int          `json:"wins"`
+	Losses          int          `json:"losses"`
+	Kills           int          `json:"kills"`
+	Deaths          int          `json:"deaths"`
+	Contribution    int          `json:"contribution"`
+	PlayTimeSeconds int          `json:"play_time_seconds"`
+	Roles           map[Role]int `json:"roles"` // matches played in each role
+	LastMatch       time.Time    `json:"last_match"`
+}
+
+// Recorder validates and stores match results and aggregates them into player, squad and war state.
+// Safe for concurrent use.
+type Recorder struct {
+	store           storage.Store
+	war             *war.Campaign    // nil if war is disabled
+	squads          *squads.Registry // nil if squads are disabled
+	maxParticipants int
+	maxDuration     time.Duration
+	confirmTimeout  time.Duration
+	maxKills        int // per minute of match, also bounds deaths
+	maxContribution int // per minute of match
+
+	mu        sync.Mutex
+	stats     map[string]PlayerStats
+	pending   map[string]*pendingMatch // reports awaiting confirmation by match id
+	listeners []func(Result)
+	banned    func(xuid string) bool
+
+	accepted   atomic.Uint64
+	duplicates atomic.Uint64
+	rejected   atomic.Uint64
+	awaiting   atomic.Uint64
+}
+
+// pendingMatch holds differing reports of match, until one of them is confirmed by another participant.
+// Every participant backs at most one report, so single reporter can't block the others.
+type pendingMatch struct {
+	reports  []pendingResult
+	reported time.Time // of first report
+}
+
+type pendingResult struct {
+	result    Result
+	reporters []string
+}
+
+// Open loads player statistics from store. Campaign and squads may be nil.
+func Open(store storage.Store, cfg config.ResultsConfig, campaign *war.Campaign, squadRegistry *squads.Registry) (*Recorder, error) {
+	if err := storage.Migrate(store, resultsBucket, migrations); err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		store:           store,
+		war:             campaign,
+		squads:          squadRegistry,
+		maxParticipants: cfg.MaxParticipants,
+		maxDuration:     time.Duration(cfg.MaxMatchMinutes) * time.Minute,
+		confirmTimeout:  time.Duration(cfg.ConfirmationTimeoutMinutes) * time.Minute,
+		maxKills:        cfg.MaxKillsPerMinute,
+		maxContribution: cfg.MaxContributionPerMinute,
+		stats:           make(map[string]PlayerStats),
+		pending:         make(map[string]*pendingMatch),
+	}
+	err := storage.LoadAllJSON(store, playerStatsBucket, func(_ string, stats PlayerStats) {
+		r.stats[stats.Xuid] = stats
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading player stats: %w", err)
+	}
+	return r, nil
+}
+
+// SetBanned sets check of banned players, whose reports are rejected
+func (r *Recorder) SetBanned(banned func(xuid string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.banned = banned
+}
+
+// Report handles result reported by participant. Single participant can't be trusted to report honestly,
+// so result is held until another participant reports the same result, scores included, and only then recorded.
+// Until then ErrPending is returned. Reporting again replaces earlier report of the same participant.
+// Reports not confirmed within confirmation timeout are dropped.
+func (r *Recorder) Report(result Result, now time.Time) (Result, error) {
+	if err := r.validate(result); err != nil {
+		r.rejected.Add(1)
+		return Result{}, err
+	}
+
+	r.mu.Lock()
+	if r.banned != nil && r.banned(result.Reporter) {
+		r.mu.Unlock()
+		r.rejected.Add(1)
+		return Result{}, ErrBanned
+	}
+	r.expirePending(now)
+	if _, err := r.store.Get(resultsBucket, result.MatchID); err == nil {
+		r.mu.Unlock()
+		r.duplicates.Add(1)
+		return Result{}, fmt.Errorf("%w: %s", ErrDuplicate, result.MatchID)
+	}
+	match, ok := r.pending[result.MatchID]
+	if !ok {
+		if len(r.pending) >= maxPendingResults {
+			r.mu.Unlock()
+			r.rejected.Add(1)
+			return Result{}, ErrTooManyPending
+		}
+		match = &pendingMatch{reported: now}
+		r.pending[result.MatchID] = match
+	}
+	i := slices.IndexFunc(match.reports, func(p pendingResult) bool { return sameResult(p.result, result) })
+	if i >= 0 && slices.Contains(match.reports[i].reporters, result.Reporter) {
+		r.mu.Unlock()
+		return Result{}, ErrPending
+	}
+	match.withdraw(result.Reporter)
+	if i < 0 {
+		match.reports = append(match.reports, pendingResult{result: result, reporters: []string{result.Reporter}})
+		r.mu.Unlock()
+		r.awaiting.Add(1)
+		return Result{}, ErrPending
+	}
+	confirmed := match.reports[i].result
+	delete(r.pending, result.MatchID)
+	r.mu.Unlock()
+
+	return r.Record(confirmed, now)
+}
+
+// drops earlier report of reporter, if any
+func (m *pendingMatch) withdraw(reporter string) {
+	for i, p := range m.reports {
+		if j := slices.Index(p.reporters, reporter); j >= 0 {
+			m.reports[i].reporters = slices.Delete(p.reporters, j, j+1)
+			if len(m.reports[i].reporters) == 0 {
+				m.reports = slices.Delete(m.reports, i, i+1)
+			}
+			return
+		}
+	}
+}
+
+// Record validates and stores result, then updates statistics, squads and war with it.
+// Result is trusted as is, reports of players go through Report. Returns result as stored.
+func (r *Recorder) Record(result Result, now time.Time) (Result, error) {
+	if err := r.validate(result); err != nil {
+		r.rejected.Add(1)
+		return Result{}, err
+	}
+	result.Reported = now
+	result.Season = status.DefaultGameSeason
+	if r.war != nil {
+		result.Season = r.war.Season()
+	}
+	result.Participants = slices.Clone(result.Participants)
+	if r.squads != nil {
+		for i, p := range result.Participants {
+			if squad, ok := r.squads.SquadOf(p.Xuid); ok {
+				result.Participants[i].Squad = squad.Tag
+			}
+		}
+	}
+
+	r.mu.Lock()
+	_, err := r.store.Get(resultsBucket, result.MatchID)
+	if err == nil {
+		r.mu.Unlock()
+		r.duplicates.Add(1)
+		return Result{}, fmt.Errorf("%w: %s", ErrDuplicate, result.MatchID)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		r.mu.Unlock()
+		return Result{}, err
+	}
+	if err := storage.PutJSON(r.store, resultsBucket, result.MatchID, result); err != nil {
+		r.mu.Unlock()
+		return Result{}, fmt.Errorf("storing result %s: %w", result.MatchID, err)
+	}
+	r.aggregate(result)
+	listeners := r.listeners
+	r.mu.Unlock()
+	r.accepted.Add(1)
+
+	for _, listener := range listeners {
+		listener(result)
+	}
+
+	r.updateSquads(result)
+	r.updateWar(result)
+	return result, nil
+}
+
+// AddListener registers function called with every newly recorded result.
+// Listeners are called synchronously and must not block.
+func (r *Recorder) AddListener(listener func(Result)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// ForEach calls fn with every stored result, in match id order
+func (r *Recorder) ForEach(fn func(Result)) error {
+	return storage.LoadAllJSON(r.store, resultsBucket, func(_ string, result Result) {
+		fn(result)
+	})
+}
+
+// Get returns stored result of match
+func (r *Recorder) Get(matchID string) (Result, error) {
+	var result Result
+	err := storage.GetJSON(r.store, resultsBucket, matchID, &result)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Result{}, ErrNotFound
+	}
+	return result, err
+}
+
+// PlayerStats returns totals of player
+func (r *Recorder) PlayerStats(xuid string) (PlayerStats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[xuid]
+	stats.Roles = maps.Clone(stats.Roles)
+	return stats, ok
+}
+
+// AllPlayerStats returns totals of all players ordered by XUID
+func (r *Recorder) AllPlayerStats() []PlayerStats {
+	r.mu.Lock()
+	result := make([]PlayerStats, 0, len(r.stats))
+	for _, stats := range r.stats {
+		stats.Roles = maps.Clone(stats.Roles)
+		result = append(result, stats)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Xuid < result[j].Xuid
+	})
+	return result
+}
+
+func (r *Recorder) validate(result Result) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidResult, fmt.Sprintf(format, args...))
+	}
+
+	if result.MatchID == "" {
+		return invalid("missing match id")
+	}
+	if len(result.Participants) < 2 || len(result.Participants) > r.maxParticipants {
+		return invalid("%d participants, expected 2 to %d", len(result.Participants), r.maxParticipants)
+	}
+	if result.DurationSeconds <= 0 || time.Duration(result.DurationSeconds)*time.Second > r.maxDuration {
+		return invalid("match duration of %ds", result.DurationSeconds)
+	}
+	if _, err := war.ParseNation(string(result.Winner)); err != nil {
+		return invalid("winner: %v", err)
+	}
+	if r.war != nil && !r.war.HasTerritory(result.Territory) {
+		return invalid("unknown territory %q", result.Territory)
+	}
+
+	minutes := (result.DurationSeconds + 59) / 60
+	seen := make(map[string]bool, len(result.Participants))
+	winnerPresent := false
+	for _, p := range result.Participants {
+		if p.Xuid == "" || seen[p.Xuid] {
+			return invalid("missing or repeated participant xuid %q", p.Xuid)
+		}
+		seen[p.Xuid] = true
+		if _, err := war.ParseNation(string(p.Nation)); err != nil {
+			return invalid("participant %s: %v", p.Xuid, err)
+		}
+		if !slices.Contains(Roles, p.Role) {
+			return invalid("participant %s: unknown role %q", p.Xuid, p.Role)
+		}
+		if p.Kills < 0 || p.Deaths < 0 || p.Contribution < 0 {
+			return invalid("participant %s: negative score", p.Xuid)
+		}
+		if p.Kills > minutes*r.maxKills || p.Deaths > minutes*r.maxKills || p.Contribution > minutes*r.maxContribution {
+			return invalid("participant %s: score too high for match of %ds", p.Xuid, result.DurationSeconds)
+		}
+		winnerPresent = winnerPresent || p.Nation == result.Winner
+	}
+	if !winnerPresent {
+		return invalid("winner %s did not take part", result.Winner)
+	}
+	if !seen[result.Reporter] {
+		return invalid("reporter %q did not take part", result.Reporter)
+	}
+	return nil
+}
+
+// drops reports not confirmed in time. caller has to hold lock.
+func (r *Recorder) expirePending(now time.Time) {
+	for matchID, match := range r.pending {
+		if now.Sub(match.reported) > r.confirmTimeout {
+			delete(r.pending, matchID)
+		}
+	}
+}
+
+// reports agree when everything but reporter is the same, including scores of every participant
+func sameResult(a Result, b Result) bool {
+	if a.Winner != b.Winner || a.Territory != b.Territory || a.DurationSeconds != b.DurationSeconds || len(a.Participants) != len(b.Participants) {
+		return false
+	}
+	participants := make(map[string]Participant, len(a.Participants))
+	for _, p := range a.Participants {
+		participants[p.Xuid] = p
+	}
+	for _, p := range b.Participants {
+		if other, ok := participants[p.Xuid]; !ok || other != p {
+			return false
+		}
+	}
+	return true
+}
+
+// adds result to player totals. caller has to hold lock.
+func (r *Recorder) aggregate(result Result) {
+	for _, p := range result.Participants {
+		stats := r.stats[p.Xuid]
+		stats.Xuid = p.Xuid
+		stats.Matches++
+		if p.Nation == result.Winner {
+			stats.Wins++
+		} else {
+			stats.Losses++
+		}
+		stats.Kills += p.Kills
+		stats.Deaths += p.Deaths
+		stats.Contribution += p.Contribution
+		stats.PlayTimeSeconds += result.DurationSeconds
+		if stats.Roles == nil {
+			stats.Roles = make(map[Role]int)
+		}
+		stats.Roles[p.Role]++
+		stats.LastMatch = result.Reported
+
+		// result itself is stored already, totals can be rebuilt from it if this fails
+		if err := storage.PutJSON(r.store, playerStatsBucket, p.Xuid, stats); err != nil {
+			logging.Error.Printf("[RESULTS] failed storing stats of %s: %v", p.Xuid, err)
+		}
+		r.stats[p.Xuid] = stats
+	}
+}
+
+// records match once for every squad that took part
+func (r *Recorder) updateSquads(result Result) {
+	if r.squads == nil {
+		return
+	}
+	type squadScore struct {
+		won           bool
+		kills, deaths int
+	}
+	scores := make(map[string]*squadScore)
+	for _, p := range result.Participants {
+		if p.Squad == "" {
+			continue
+		}
+		score, ok := scores[p.Squad]
+		if !ok {
+			score = &squadScore{won: p.Nation == result.Winner}
+			scores[p.Squad] = score
+		}
+		score.kills += p.Kills
+		score.deaths += p.Deaths
+	}
+	for tag, score := range scores {
+		if _, err := r.squads.RecordMatch(tag, score.won, score.kills, score.deaths); err != nil {
+			logging.Warn.Printf("[RESULTS] failed recording match %s for squad %s: %v", result.MatchID, tag, err)
+		}
+	}
+}
+
+func (r *Recorder) updateWar(result Result) {
+	if r.war == nil {
+		return
+	}
+	outcome := war.Outcome{
+		Territory:    result.Territory,
+		Winner:       result.Winner,
+		Contribution: make(map[war.Nation]int),
+	}
+	for _, p := range result.Participants {
+		outcome.Contribution[p.Nation] += p.Contribution
+	}
+	change, err := r.war.Apply(outcome, result.Reported)
+	if err != nil {
+		logging.Warn.Printf("[RESULTS] failed applying match %s to war: %v", result.MatchID, err)
+		return
+	}
+	if change != nil {
+		logging.Info.Printf("[WAR] %s captured %s from %s", change.NewOwner, change.Territory, change.PreviousOwner)
+	}
+}
@@ -0,0 +1,230 @@
+package results
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/squads"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/war"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	hostXuid  = "00900004EA25063"
+	guestXuid = "00900004EA25064"
+)
+
+var testConfig = config.ResultsConfig{MaxParticipants: 4, MaxMatchMinutes: 60, ConfirmationTimeoutMinutes: 10, MaxKillsPerMinute: 2, MaxContributionPerMinute: 20}
+
+func testResult(matchID string) Result {
+	return Result{
+		MatchID:         matchID,
+		Reporter:        hostXuid,
+		Territory:       "A",
+		Winner:          war.Morskoj,
+		DurationSeconds: 600,
+		Participants: []Participant{
+			{Xuid: hostXuid, Nation: war.Morskoj, Role: RoleSniper, Kills: 3, Deaths: 1, Contribution: 40},
+			{Xuid: guestXuid, Nation: war.Rutania, Role: RoleDefender, Kills: 1, Deaths: 3, Contribution: 15},
+		},
+	}
+}
+
+func openTestRecorder(t *testing.T, store storage.Store) (*Recorder, *war.Campaign, *squads.Registry) {
+	campaign, err := war.Open(store, config.WarConfig{
+		SeasonLengthDays: 28,
+		CaptureMargin:    1,
+		Territories:      []config.TerritoryConfig{{ID: "A", Name: "A", InitialOwner: "Rutania"}},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("Failed opening campaign: %v", err)
+	}
+	squadRegistry, err := squads.Open(store, config.SquadsConfig{MaxMembers: 4, InviteExpiryHours: 1}, nil)
+	if err != nil {
+		t.Fatalf("Failed opening squads: %v", err)
+	}
+	recorder, err := Open(store, testConfig, campaign, squadRegistry)
+	if err != nil {
+		t.Fatalf("Failed opening recorder: %v", err)
+	}
+	return recorder, campaign, squadRegistry
+}
+
+func TestRecordAggregatesAndDetectsDuplicates(t *testing.T) {
+	store := storage.NewMemoryStore()
+	recorder, campaign, squadRegistry := openTestRecorder(t, store)
+	squadRegistry.Create("HND", "Hounds", war.Morskoj, hostXuid, time.Now())
+
+	stored, err := recorder.Record(testResult("1"), time.Now())
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if stored.Participants[0].Squad != "HND" || stored.Season != campaign.Season() {
+		t.Errorf("Expected squad and season to be filled in, got %v", stored)
+	}
+	if _, err := recorder.Record(testResult("1"), time.Now()); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+
+	squad, _ := squadRegistry.Get("HND")
+	if squad.Stats.Wins != 1 || squad.Stats.Kills != 3 {
+		t.Errorf("Expected squad stats to count the win, got %v", squad.Stats)
+	}
+	if owner := campaign.State().Territories[0].Owner; owner != war.Morskoj {
+		t.Errorf("Expected territory captured by Morskoj, got %s", owner)
+	}
+
+	reloaded, _, _ := openTestRecorder(t, store)
+	stats, ok := reloaded.PlayerStats(guestXuid)
+	if !ok || stats.Matches != 1 || stats.Losses != 1 || stats.Roles[RoleDefender] != 1 {
+		t.Errorf("Unexpected player stats after reload: %v", stats)
+	}
+	if _, err := reloaded.Record(testResult("1"), time.Now()); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate after reload, got %v", err)
+	}
+}
+
+func TestRecordValidation(t *testing.T) {
+	recorder, _, _ := openTestRecorder(t, storage.NewMemoryStore())
+
+	tests := []struct {
+		name   string
+		modify func(*Result)
+	}{
+		{"missing match id", func(r *Result) { r.MatchID = "" }},
+		{"single participant", func(r *Result) { r.Participants = r.Participants[:1] }},
+		{"repeated participant", func(r *Result) { r.Participants[1].Xuid = hostXuid }},
+		{"too long", func(r *Result) { r.DurationSeconds = 2 * 3600 }},
+		{"unknown territory", func(r *Result) { r.Territory = "Z" }},
+		{"unknown role", func(r *Result) { r.Participants[0].Role = "pilot" }},
+		{"absent winner", func(r *Result) { r.Winner = war.Tarakia }},
+		{"outside reporter", func(r *Result) { r.Reporter = "00900004EA25065" }},
+		{"too many kills", func(r *Result) { r.Participants[0].Kills = 21 }},
+		{"too much contribution", func(r *Result) { r.Participants[1].Contribution = 201 }},
+	}
+	for _, test := range tests {
+		result := testResult("2")
+		test.modify(&result)
+		if _, err := recorder.Record(result, time.Now()); !errors.Is(err, ErrInvalidResult) {
+			t.Errorf("%s: Expected ErrInvalidResult, got %v", test.name, err)
+		}
+	}
+}
+
+func TestReportNeedsConfirmation(t *testing.T) {
+	recorder, _, _ := openTestRecorder(t, storage.NewMemoryStore())
+	now := time.Now()
+
+	if _, err := recorder.Report(testResult("1"), now); !errors.Is(err, ErrPending) {
+		t.Fatalf("Expected first report to await confirmation, got %v", err)
+	}
+	if _, err := recorder.Report(testResult("1"), now); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected repeated report of same player to keep waiting, got %v", err)
+	}
+	if _, err := recorder.Get("1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected unconfirmed result not to be stored, got %v", err)
+	}
+
+	conflicting := testResult("1")
+	conflicting.Reporter = guestXuid
+	conflicting.Winner = war.Rutania
+	if _, err := recorder.Report(conflicting, now); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected differing report to await its own confirmation, got %v", err)
+	}
+	inflated := testResult("1")
+	inflated.Reporter = guestXuid
+	inflated.Participants[1].Kills = 9
+	if _, err := recorder.Report(inflated, now); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected report with different scores not to confirm, got %v", err)
+	}
+
+	confirmation := testResult("1")
+	confirmation.Reporter = guestXuid
+	stored, err := recorder.Report(confirmation, now)
+	if err != nil {
+		t.Fatalf("Expected confirmation to record result, got %v", err)
+	}
+	if stored.Reporter != hostXuid {
+		t.Errorf("Expected first report to be recorded, got reporter %s", stored.Reporter)
+	}
+	if _, err := recorder.Report(confirmation, now); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate after recording, got %v", err)
+	}
+
+	// unconfirmed report expires, so late confirmation starts over
+	recorder.Report(testResult("2"), now)
+	late := testResult("2")
+	late.Reporter = guestXuid
+	if _, err := recorder.Report(late, now.Add(time.Hour)); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected expired report to be dropped, got %v", err)
+	}
+}
+
+func reportFrame(reporter string) protocol.Frame {
+	header := ReportHeader{MatchID: 0xABC, Winner: 1, DurationSeconds: 600, ParticipantCount: 2}
+	copy(header.Reporter[:], reporter)
+	copy(header.Territory[:], "A")
+	frame, _ := protocol.NewFrame(protocol.ServiceResults, OpReport, header)
+	host := ParticipantMessage{Nation: 1, Role: 2, Kills: 3}
+	copy(host.Xuid[:], hostXuid)
+	guest := ParticipantMessage{Nation: 2, Role: 4, Deaths: 3}
+	copy(guest.Xuid[:], guestXuid)
+	frame.AppendPayload(host)
+	frame.AppendPayload(guest)
+	return frame
+}
+
+func TestHandleReportFrame(t *testing.T) {
+	recorder, _, _ := openTestRecorder(t, storage.NewMemoryStore())
+
+	if response := recorder.HandleFrame(reportFrame(hostXuid), guestXuid, time.Now()); response.Opcode != OpError {
+		t.Errorf("Expected report on behalf of other player to be rejected, got opcode %x", response.Opcode)
+	}
+	if response := recorder.HandleFrame(reportFrame(hostXuid), hostXuid, time.Now()); response.Opcode != OpPending {
+		t.Fatalf("Expected pending response, got opcode %x", response.Opcode)
+	}
+	if response := recorder.HandleFrame(reportFrame(guestXuid), guestXuid, time.Now()); response.Opcode != OpAccepted {
+		t.Fatalf("Expected accepted response, got opcode %x", response.Opcode)
+	}
+	result, err := recorder.Get("abc")
+	if err != nil {
+		t.Fatalf("Expected result stored under hex match id: %v", err)
+	}
+	if result.Participants[0].Role != RoleSniper || result.Participants[1].Nation != war.Rutania {
+		t.Errorf("Unexpected decoded participants: %v", result.Participants)
+	}
+	if response := recorder.HandleFrame(reportFrame(hostXuid), hostXuid, time.Now()); response.Opcode != OpDuplicate {
+		t.Errorf("Expected duplicate response, got opcode %x", response.Opcode)
+	}
+
+	frame := reportFrame(hostXuid)
+	frame.Payload = frame.Payload[:len(frame.Payload)-1]
+	if response := recorder.HandleFrame(frame, hostXuid, time.Now()); response.Opcode != OpError {
+		t.Errorf("Expected error response for truncated report, got opcode %x", response.Opcode)
+	}
+}
+
+func TestSquattedMatchIDDoesNotBlockReports(t *testing.T) {
+	recorder, _, _ := openTestRecorder(t, storage.NewMemoryStore())
+	now := time.Now()
+
+	// third participant reports first, claiming the other nation won
+	squatted := testResult("1")
+	squatted.Participants = append(squatted.Participants, Participant{Xuid: "00900004EA25065", Nation: war.Rutania, Role: RoleScout})
+	honest := squatted
+	squatted.Reporter = "00900004EA25065"
+	squatted.Winner = war.Rutania
+	recorder.Report(squatted, now)
+
+	recorder.Report(honest, now)
+	honest.Reporter = guestXuid
+	stored, err := recorder.Report(honest, now)
+	if err != nil {
+		t.Fatalf("Expected matching reports to be recorded, got %v", err)
+	}
+	if stored.Winner != war.Morskoj {
+		t.Errorf("Expected confirmed winner Morskoj, got %s", stored.Winner)
+	}
+}
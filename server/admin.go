@@ -0,0 +1,61 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/health"
+	"ChromehoundsStatusServer/maintenance"
+	"net/http"
+	"time"
+)
+
+// Status is overview of running instance
+type Status struct {
+	Started         time.Time           `json:"started"`
+	UptimeSeconds   int64               `json:"uptime_seconds"`
+	Season          uint32              `json:"season"`
+	OnlinePlayers   int                 `json:"online_players"`
+	Maintenance     bool                `json:"maintenance"`
+	NextMaintenance *maintenance.Window `json:"next_maintenance,omitempty"`
+	Servers         []ServerStatus      `json:"servers"`
+}
+
+// ServerStatus describes single configured UDP server
+type ServerStatus struct {
+	Label   string            `json:"label"`
+	Type    config.ServerType `json:"type"`
+	Port    int               `json:"port"`
+	Enabled bool              `json:"enabled"`
+	State   health.State      `json:"state,omitempty"` // empty for disabled servers
+}
+
+// RegisterAdminRoutes exposes overview of running instance on admin api
+func RegisterAdminRoutes(a *admin.Server, servers []config.ServerConfig, services *Services, started time.Time) {
+	a.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		status := Status{
+			Started:       started,
+			UptimeSeconds: int64(now.Sub(started) / time.Second),
+			Season:        gameSeason(services.War),
+			Servers:       make([]ServerStatus, len(servers)),
+		}
+		if services.Sessions != nil {
+			status.OnlinePlayers, _ = services.Sessions.Counts()
+		}
+		if services.Maintenance != nil {
+			if window, ok := services.Maintenance.Next(now); ok {
+				status.Maintenance = window.Active(now)
+				status.NextMaintenance = &window
+			}
+		}
+		for i, s := range servers {
+			status.Servers[i] = ServerStatus{Label: s.Label, Type: s.Type, Port: s.Port, Enabled: s.Enabled}
+			if services.Health != nil {
+				if service, ok := services.Health.Get(s.Label); ok {
+					status.Servers[i].State = service.State
+				}
+			}
+		}
+		admin.WriteJSON(w, http.StatusOK, status)
+	})
+}
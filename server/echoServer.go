@@ -16,32 +16,36 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-func RunEchoingServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer) {
+func RunEchoingServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
 	echoResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "echo_responses_handled_total",
 		Help: "Total number of echo responses handled",
 	})
+	metrics := newServerMetrics(reg, promConfig)
 	wg.Add(1)
 	defer wg.Done()
 	// Pre-compute config flags to avoid pointer dereferencing in hot path
 	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
-	verboseLogging := loggingConfig.Verbose
 	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
 
-	conn, err := buildUDPListener(listenAddress, serverConfig.Port, label, bufferSize)
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
 	if err != nil {
 		return
 	}
 	defer conn.Close()
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
 
 	buffer := pooling.ReadBufferPool.Get()
 	defer pooling.ReadBufferPool.Put(buffer)
 
 	// Pre-allocate to avoid repeated allocations
-	var startTime time.Time
 	var processingTime time.Duration
 
 	for {
+		verboseLogging := verbose.Load()
 		select {
 		case <-ctx.Done():
 			if verboseLogging {
@@ -50,42 +54,57 @@ func RunEchoingServer(listenAddress net.IP, serverConfig *config.ServerConfig, b
 			return
 
 		default:
-			if enablePerfMonitoring {
-				startTime = time.Now()
-			}
-
-			n, clientAddr, err := readUDP(conn, &buffer, label)
+			n, clientAddr, err := readUDP(conn, &buffer, label, metrics)
 			if err != nil {
 				if !isTimeoutError(err) && enablePerfMonitoring {
-					profiling.RecordError()
+					perf.RecordError()
 				}
 				continue
 			}
 
+			received := time.Now()
 			packet := buffer[:n]
+			span := startPacketSpan("echo packet", label, clientAddr, n, received)
 
 			// Validate echo packet
 			if err := ValidateEchoPacket(packet, clientAddr, label); err != nil {
+				metrics.validationFailed(err)
+				publishValidationFailed(services.Events, label, clientAddr, n, err)
 				if verboseLogging {
 					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
 				}
 				if enablePerfMonitoring {
-					profiling.RecordError()
+					perf.RecordError()
 				}
+				endPacketSpan(span, outcomeInvalid, err)
 				continue // Skip invalid packets
 			}
 
 			if enablePerfMonitoring {
-				processingTime := time.Since(startTime)
-				profiling.RecordPacketProcessed(n, processingTime)
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
 
 			}
 			if verboseLogging {
 				logging.LogPacketReceived(label, clientAddr, n, processingTime)
 
 			}
+			publishPacketReceived(services.Events, label, clientAddr, n, processingTime)
 
-			sendUDP(conn, clientAddr, &packet, label, false)
+			if services.Sessions != nil && !isProbe(packet, clientAddr) {
+				now := time.Now()
+				services.Sessions.TouchAddr(clientAddr, label, now)
+				if xuid, ok := services.Sessions.XuidForAddr(clientAddr); ok && services.Presence != nil {
+					services.Presence.Seen(xuid, label, now)
+				}
+			}
+
+			if err := sendUDP(conn, clientAddr, &packet, label, false, metrics); err != nil {
+				endPacketSpan(span, outcomeError, err)
+			} else {
+				endPacketSpan(span, outcomeServed, nil)
+			}
+			metrics.processed(received)
 			if promConfig.Enabled {
 				echoResponsesHandled.Inc()
 			}
@@ -100,6 +119,7 @@ func ValidateEchoPacket(packet []byte, clientAddr *net.UDPAddr, label string) er
 	// Basic size validation for echo packets
 	if packetSize == 0 {
 		err := ValidationError{
+			Code:   EmptyPacket,
 			Reason: "empty packet",
 			Size:   packetSize,
 		}
@@ -109,6 +129,7 @@ func ValidateEchoPacket(packet []byte, clientAddr *net.UDPAddr, label string) er
 
 	if packetSize > constants.MaxBufferSize {
 		err := ValidationError{
+			Code:   PacketTooLarge,
 			Reason: fmt.Sprintf("packet too large (maximum: %d bytes)", constants.MaxBufferSize),
 			Size:   packetSize,
 		}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/introducer"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/pooling"
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/relay"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+func RunIntroducerServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, introducerConfig *config.IntroducerConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
+	introducerResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "introducer_responses_handled_total",
+		Help: "Total number of introducer responses handled",
+	})
+	metrics := newServerMetrics(reg, promConfig)
+	wg.Add(1)
+	defer wg.Done()
+	// Pre-compute config flags to avoid pointer dereferencing in hot path
+	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
+	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
+
+	punchTimeout := time.Duration(introducerConfig.PunchTimeoutSeconds) * time.Second
+	in := introducer.New(punchTimeout, time.Duration(introducerConfig.RegistrationTimeoutSeconds)*time.Second, introducerRelay(introducerConfig, services.Relay, label))
+	if services.Accounts != nil {
+		in.SetBanned(services.Accounts.IsBanned)
+	}
+	registerIntroducerMetrics(reg, in)
+
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
+
+	go expirePunchAttempts(ctx, in, conn, label, punchTimeout, metrics)
+
+	buffer := pooling.ReadBufferPool.Get()
+	defer pooling.ReadBufferPool.Put(buffer)
+
+	// Pre-allocate to avoid repeated allocations
+	var processingTime time.Duration
+
+	for {
+		verboseLogging := verbose.Load()
+		select {
+		case <-ctx.Done():
+			if verboseLogging {
+				logging.LogShutdown(label)
+			}
+			return
+
+		default:
+			n, clientAddr, err := readUDP(conn, &buffer, label, metrics)
+			if err != nil {
+				if !isTimeoutError(err) && enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue
+			}
+
+			received := time.Now()
+			frame, err := ValidateFramedPacket(buffer[:n], protocol.ServiceIntroducer, clientAddr, label)
+			if err != nil {
+				metrics.validationFailed(err)
+				if verboseLogging {
+					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
+				}
+				if enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue // Skip invalid packets
+			}
+
+			now := time.Now()
+			outgoing := in.HandleFrame(frame, clientAddr, now)
+			if services.Sessions != nil {
+				services.Sessions.TouchAddr(clientAddr, label, now)
+			}
+
+			if enablePerfMonitoring {
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
+			}
+			if verboseLogging {
+				logging.LogPacketReceived(label, clientAddr, n, processingTime)
+			}
+
+			sendOutgoing(conn, outgoing, label, verboseLogging, metrics)
+			metrics.processed(received)
+			if promConfig.Enabled {
+				introducerResponsesHandled.Inc()
+			}
+		}
+	}
+}
+
+// relay peers are sent to after failed punch, nil if none is configured.
+// sessions are allocated up front when relay runs in this instance, otherwise peers are just pointed to external relay.
+func introducerRelay(introducerConfig *config.IntroducerConfig, registry *relay.Registry, label string) introducer.RelayAllocator {
+	if introducerConfig.RelayAddress == "" {
+		logging.Warn.Printf("[%s] no relay configured, failed punch attempts will not fall back to relay", label)
+		return nil
+	}
+	relayAddr, err := net.ResolveUDPAddr("udp", introducerConfig.RelayAddress)
+	if err != nil {
+		logging.Error.Printf("[%s] invalid relay address %s: %v", label, introducerConfig.RelayAddress, err)
+		return nil
+	}
+	endpoint := protocol.EndpointFromUDPAddr(relayAddr)
+	if registry != nil {
+		return relay.IntroducerAllocator{Registry: registry, Endpoint: endpoint}
+	}
+	return introducer.StaticRelay{Endpoint: endpoint}
+}
+
+func registerIntroducerMetrics(reg prometheus.Registerer, in *introducer.Introducer) {
+	outcomes := map[string]func(introducer.Stats) uint64{
+		"punched": func(s introducer.Stats) uint64 { return s.Punched },
+		"relayed": func(s introducer.Stats) uint64 { return s.Relayed },
+		"failed":  func(s introducer.Stats) uint64 { return s.Failed },
+	}
+	for outcome, value := range outcomes {
+		promauto.With(reg).NewCounterFunc(prometheus.CounterOpts{
+			Name:        "introducer_punch_attempts_total",
+			Help:        "Total number of finished punch attempts by outcome",
+			ConstLabels: prometheus.Labels{"outcome": outcome},
+		}, func() float64 {
+			return float64(value(in.Stats()))
+		})
+	}
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "introducer_pending_punch_attempts",
+		Help: "Number of punch attempts waiting for result",
+	}, func() float64 {
+		return float64(in.Stats().Pending)
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "introducer_registered_peers",
+		Help: "Number of peers with known public endpoint",
+	}, func() float64 {
+		return float64(in.Stats().Known)
+	})
+}
+
+// periodically falls back to relay for timed out punch attempts until context is cancelled
+func expirePunchAttempts(ctx context.Context, in *introducer.Introducer, conn *net.UDPConn, label string, punchTimeout time.Duration, metrics *serverMetrics) {
+	defer metrics.worker()()
+	interval := punchTimeout / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sendOutgoing(conn, in.Expire(now), label, false, metrics)
+		}
+	}
+}
+
+func sendOutgoing(conn *net.UDPConn, outgoing []introducer.Outgoing, label string, logSend bool, metrics *serverMetrics) {
+	for _, msg := range outgoing {
+		sendBuffer, err := msg.Frame.Encode()
+		if err != nil {
+			logging.Warn.Printf("[%s] failed encoding message: %v\n", label, err)
+			continue
+		}
+		sendUDP(conn, msg.Addr, &sendBuffer, label, logSend, metrics)
+	}
+}
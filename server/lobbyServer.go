@@ -0,0 +1,157 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/lobby"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/pooling"
+	"ChromehoundsStatusServer/protocol"
+	"ChromehoundsStatusServer/session"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+func RunLobbyServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, lobbyConfig *config.LobbyConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
+	lobbyResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "lobby_responses_handled_total",
+		Help: "Total number of lobby responses handled",
+	})
+	metrics := newServerMetrics(reg, promConfig)
+	wg.Add(1)
+	defer wg.Done()
+	// Pre-compute config flags to avoid pointer dereferencing in hot path
+	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
+	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
+
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	manager := lobby.NewManager(lobbyConfig.MaxRoomCapacity, lobbyConfig.MaxRoomsPerAddress, time.Duration(lobbyConfig.MemberIdleTimeoutSeconds)*time.Second)
+	if services.Accounts != nil {
+		manager.SetBanned(services.Accounts.IsBanned)
+	}
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "lobby_open_rooms",
+		Help: "Number of open lobby rooms",
+	}, func() float64 {
+		return float64(len(manager.List()))
+	})
+	go expireLobbyRooms(ctx, manager, label, time.Duration(lobbyConfig.MemberIdleTimeoutSeconds)*time.Second, metrics)
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
+
+	buffer := pooling.ReadBufferPool.Get()
+	defer pooling.ReadBufferPool.Put(buffer)
+
+	// Pre-allocate to avoid repeated allocations
+	var processingTime time.Duration
+
+	for {
+		verboseLogging := verbose.Load()
+		select {
+		case <-ctx.Done():
+			if verboseLogging {
+				logging.LogShutdown(label)
+			}
+			return
+
+		default:
+			n, clientAddr, err := readUDP(conn, &buffer, label, metrics)
+			if err != nil {
+				if !isTimeoutError(err) && enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue
+			}
+
+			received := time.Now()
+			frame, err := ValidateFramedPacket(buffer[:n], protocol.ServiceLobby, clientAddr, label)
+			if err != nil {
+				metrics.validationFailed(err)
+				if verboseLogging {
+					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
+				}
+				if enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue // Skip invalid packets
+			}
+
+			// room state and room list are much bigger than request, and spoofed address
+			// must not become room member, so only clients known to status server are served
+			if !identified(services.Sessions, clientAddr) {
+				if verboseLogging {
+					logging.Warn.Printf("[%s] request of %s without session dropped\n", label, clientAddr)
+				}
+				continue
+			}
+
+			now := time.Now()
+			response, err := manager.HandleFrame(frame, clientAddr, now)
+			if err != nil {
+				logging.Warn.Printf("[%s] failed building response: %v\n", label, err)
+				continue
+			}
+			if services.Sessions != nil {
+				services.Sessions.TouchAddr(clientAddr, label, now)
+			}
+
+			if enablePerfMonitoring {
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
+			}
+			if verboseLogging {
+				logging.LogPacketReceived(label, clientAddr, n, processingTime)
+			}
+
+			sendBuffer, err := response.Encode()
+			if err != nil {
+				logging.Warn.Printf("[%s] failed encoding response: %v\n", label, err)
+				continue
+			}
+			sendUDP(conn, clientAddr, &sendBuffer, label, verboseLogging, metrics)
+			metrics.processed(received)
+			if promConfig.Enabled {
+				lobbyResponsesHandled.Inc()
+			}
+		}
+	}
+}
+
+// periodically drops idle room members until context is cancelled
+func expireLobbyRooms(ctx context.Context, manager *lobby.Manager, label string, idleTimeout time.Duration, metrics *serverMetrics) {
+	defer metrics.worker()()
+	ticker := time.NewTicker(idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if closed := manager.Expire(now); closed > 0 {
+				logging.Info.Printf("[%s] closed %d rooms with idle host", label, closed)
+			}
+		}
+	}
+}
+
+// reports whether client at addr has said hello to status server from it
+func identified(sessions *session.Registry, addr *net.UDPAddr) bool {
+	if sessions == nil {
+		return false
+	}
+	_, ok := sessions.XuidForAddr(addr)
+	return ok
+}
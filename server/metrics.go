@@ -0,0 +1,131 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// serverMetrics are traffic metrics common to all UDP servers, registered through
+// per-server registerer so they carry server_type and server_name labels.
+// Nil when prometheus is disabled, all methods do nothing then.
+type serverMetrics struct {
+	processingTime     prometheus.Histogram
+	packetsReceived    prometheus.Counter
+	packetsSent        prometheus.Counter
+	bytesReceived      prometheus.Counter
+	bytesSent          prometheus.Counter
+	validationFailures *prometheus.CounterVec
+	readErrors         prometheus.Counter
+	sendErrors         prometheus.Counter
+	activeWorkers      prometheus.Gauge
+}
+
+func newServerMetrics(reg prometheus.Registerer, promConfig config.PrometheusConfig) *serverMetrics {
+	if !promConfig.Enabled {
+		return nil
+	}
+	factory := promauto.With(reg)
+	return &serverMetrics{
+		processingTime: factory.NewHistogram(prometheus.HistogramOpts{
+			Name:    "packet_processing_seconds",
+			Help:    "Time from receiving packet to sending all responses to it",
+			Buckets: []float64{.00001, .000025, .00005, .0001, .00025, .0005, .001, .0025, .005, .01, .05},
+		}),
+		packetsReceived: factory.NewCounter(prometheus.CounterOpts{
+			Name: "packets_received_total",
+			Help: "Total number of datagrams received",
+		}),
+		packetsSent: factory.NewCounter(prometheus.CounterOpts{
+			Name: "packets_sent_total",
+			Help: "Total number of datagrams sent",
+		}),
+		bytesReceived: factory.NewCounter(prometheus.CounterOpts{
+			Name: "received_bytes_total",
+			Help: "Total size of datagrams received",
+		}),
+		bytesSent: factory.NewCounter(prometheus.CounterOpts{
+			Name: "sent_bytes_total",
+			Help: "Total size of datagrams sent",
+		}),
+		validationFailures: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "packet_validation_failures_total",
+			Help: "Total number of rejected packets by reason",
+		}, []string{"reason"}),
+		readErrors: factory.NewCounter(prometheus.CounterOpts{
+			Name: "read_errors_total",
+			Help: "Total number of failed socket reads, timeouts excluded",
+		}),
+		sendErrors: factory.NewCounter(prometheus.CounterOpts{
+			Name: "send_errors_total",
+			Help: "Total number of failed socket writes",
+		}),
+		activeWorkers: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "active_workers",
+			Help: "Number of running goroutines of server, packet loop and background expiry",
+		}),
+	}
+}
+
+func (m *serverMetrics) received(size int) {
+	if m == nil {
+		return
+	}
+	m.packetsReceived.Inc()
+	m.bytesReceived.Add(float64(size))
+}
+
+func (m *serverMetrics) sent(size int) {
+	if m == nil {
+		return
+	}
+	m.packetsSent.Inc()
+	m.bytesSent.Add(float64(size))
+}
+
+func (m *serverMetrics) readFailed() {
+	if m == nil {
+		return
+	}
+	m.readErrors.Inc()
+}
+
+func (m *serverMetrics) sendFailed() {
+	if m == nil {
+		return
+	}
+	m.sendErrors.Inc()
+}
+
+// counts rejected packet by code of validation error
+func (m *serverMetrics) validationFailed(err error) {
+	if m == nil {
+		return
+	}
+	code := ValidationCode("other")
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) && validationErr.Code != "" {
+		code = validationErr.Code
+	}
+	m.validationFailures.WithLabelValues(string(code)).Inc()
+}
+
+// records handling of packet received at given time
+func (m *serverMetrics) processed(received time.Time) {
+	if m == nil {
+		return
+	}
+	m.processingTime.Observe(time.Since(received).Seconds())
+}
+
+// counts running goroutine of server, returned func is to be deferred by it
+func (m *serverMetrics) worker() func() {
+	if m == nil {
+		return func() {}
+	}
+	m.activeWorkers.Inc()
+	return m.activeWorkers.Dec
+}
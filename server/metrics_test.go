@@ -0,0 +1,82 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// value of metric with given name and reason label, if any, as gathered from reg
+func gathered(t *testing.T, reg *prometheus.Registry, name string, reason string) float64 {
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := reason == ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reason" && label.GetValue() == reason {
+					matches = true
+				}
+			}
+			if !matches {
+				continue
+			}
+			switch {
+			case metric.Counter != nil:
+				return metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				return metric.Gauge.GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestServerMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := newServerMetrics(reg, config.PrometheusConfig{Enabled: true})
+
+	metrics.received(31)
+	metrics.received(40)
+	metrics.sent(64)
+	metrics.validationFailed(ValidationError{Code: PacketTooSmall, Reason: "packet too small (minimum: 31 bytes)", Size: 3})
+	metrics.validationFailed(errors.New("something else"))
+	done := metrics.worker()
+
+	if value := gathered(t, reg, "received_bytes_total", ""); value != 71 {
+		t.Errorf("Expected 71 bytes received, got %v", value)
+	}
+	if value := gathered(t, reg, "packets_sent_total", ""); value != 1 {
+		t.Errorf("Expected 1 packet sent, got %v", value)
+	}
+	if value := gathered(t, reg, "packet_validation_failures_total", string(PacketTooSmall)); value != 1 {
+		t.Errorf("Expected 1 failure for too_small, got %v", value)
+	}
+	if value := gathered(t, reg, "packet_validation_failures_total", "other"); value != 1 {
+		t.Errorf("Expected 1 failure for other, got %v", value)
+	}
+	if value := gathered(t, reg, "active_workers", ""); value != 1 {
+		t.Errorf("Expected 1 active worker, got %v", value)
+	}
+	done()
+	if value := gathered(t, reg, "active_workers", ""); value != 0 {
+		t.Errorf("Expected no active workers, got %v", value)
+	}
+}
+
+func TestDisabledServerMetrics(t *testing.T) {
+	metrics := newServerMetrics(prometheus.NewRegistry(), config.PrometheusConfig{Enabled: false})
+	if metrics != nil {
+		t.Fatalf("Expected no metrics when prometheus is disabled")
+	}
+	metrics.received(10)
+	metrics.validationFailed(ValidationError{Code: EmptyPacket})
+	metrics.worker()()
+}
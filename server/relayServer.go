@@ -0,0 +1,127 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/health"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/pooling"
+	"ChromehoundsStatusServer/protocol"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+func RunRelayServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
+	relayPacketsForwarded := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "relay_packets_forwarded_total",
+		Help: "Total number of datagrams forwarded to relay session members",
+	})
+	relayResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "relay_responses_handled_total",
+		Help: "Total number of relay control responses handled",
+	})
+	metrics := newServerMetrics(reg, promConfig)
+	wg.Add(1)
+	defer wg.Done()
+	// Pre-compute config flags to avoid pointer dereferencing in hot path
+	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
+	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
+
+	if services.Relay == nil {
+		logging.Error.Printf("[%s] relay registry not initialized", label)
+		services.Health.Set(label, health.Failed, errors.New("relay registry not initialized"))
+		return
+	}
+
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
+
+	buffer := pooling.ReadBufferPool.Get()
+	defer pooling.ReadBufferPool.Put(buffer)
+
+	// Pre-allocate to avoid repeated allocations
+	var processingTime time.Duration
+
+	for {
+		verboseLogging := verbose.Load()
+		select {
+		case <-ctx.Done():
+			if verboseLogging {
+				logging.LogShutdown(label)
+			}
+			return
+
+		default:
+			n, clientAddr, err := readUDP(conn, &buffer, label, metrics)
+			if err != nil {
+				if !isTimeoutError(err) && enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue
+			}
+
+			received := time.Now()
+			packet := buffer[:n]
+
+			frame, err := ValidateFramedPacket(packet, protocol.ServiceRelay, clientAddr, label)
+			if err != nil {
+				metrics.validationFailed(err)
+				if verboseLogging {
+					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
+				}
+				if enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue // Skip invalid packets
+			}
+
+			now := time.Now()
+			result := services.Relay.HandleFrame(frame, clientAddr, now)
+			if services.Sessions != nil {
+				services.Sessions.TouchAddr(clientAddr, label, now)
+			}
+
+			if enablePerfMonitoring {
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
+			}
+			if verboseLogging {
+				logging.LogPacketReceived(label, clientAddr, n, processingTime)
+			}
+
+			// data frames go to other members exactly as received
+			for _, recipient := range result.ForwardTo {
+				sendUDP(conn, recipient, &packet, label, false, metrics)
+			}
+			if promConfig.Enabled {
+				relayPacketsForwarded.Add(float64(len(result.ForwardTo)))
+			}
+
+			if result.Response != nil {
+				sendBuffer, err := result.Response.Encode()
+				if err != nil {
+					logging.Warn.Printf("[%s] failed encoding response: %v\n", label, err)
+					continue
+				}
+				sendUDP(conn, clientAddr, &sendBuffer, label, verboseLogging, metrics)
+				if promConfig.Enabled {
+					relayResponsesHandled.Inc()
+				}
+			}
+			metrics.processed(received)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/health"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/pooling"
+	"ChromehoundsStatusServer/protocol"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+func RunResultsServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
+	resultsResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "results_responses_handled_total",
+		Help: "Total number of results responses handled",
+	})
+	metrics := newServerMetrics(reg, promConfig)
+	wg.Add(1)
+	defer wg.Done()
+	// Pre-compute config flags to avoid pointer dereferencing in hot path
+	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
+	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
+
+	if services.Results == nil {
+		logging.Error.Printf("[%s] result recording is disabled, not starting server", label)
+		services.Health.Set(label, health.Failed, errors.New("result recording is disabled"))
+		return
+	}
+
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
+
+	buffer := pooling.ReadBufferPool.Get()
+	defer pooling.ReadBufferPool.Put(buffer)
+
+	// Pre-allocate to avoid repeated allocations
+	var processingTime time.Duration
+
+	for {
+		verboseLogging := verbose.Load()
+		select {
+		case <-ctx.Done():
+			if verboseLogging {
+				logging.LogShutdown(label)
+			}
+			return
+
+		default:
+			n, clientAddr, err := readUDP(conn, &buffer, label, metrics)
+			if err != nil {
+				if !isTimeoutError(err) && enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue
+			}
+
+			received := time.Now()
+			frame, err := ValidateFramedPacket(buffer[:n], protocol.ServiceResults, clientAddr, label)
+			if err != nil {
+				metrics.validationFailed(err)
+				if verboseLogging {
+					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
+				}
+				if enablePerfMonitoring {
+					perf.RecordError()
+				}
+				continue // Skip invalid packets
+			}
+
+			now := time.Now()
+			var sender string
+			if services.Sessions != nil {
+				sender, _ = services.Sessions.XuidForAddr(clientAddr)
+			}
+			response := services.Results.HandleFrame(frame, sender, now)
+			if services.Sessions != nil {
+				services.Sessions.TouchAddr(clientAddr, label, now)
+			}
+
+			if enablePerfMonitoring {
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
+			}
+			if verboseLogging {
+				logging.LogPacketReceived(label, clientAddr, n, processingTime)
+			}
+
+			sendBuffer, err := response.Encode()
+			if err != nil {
+				logging.Warn.Printf("[%s] failed encoding response: %v\n", label, err)
+				continue
+			}
+			sendUDP(conn, clientAddr, &sendBuffer, label, verboseLogging, metrics)
+			metrics.processed(received)
+			if promConfig.Enabled {
+				resultsResponsesHandled.Inc()
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/accounts"
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/health"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/presence"
+	"ChromehoundsStatusServer/relay"
+	"ChromehoundsStatusServer/results"
+	"ChromehoundsStatusServer/session"
+	"ChromehoundsStatusServer/squads"
+	"ChromehoundsStatusServer/war"
+)
+
+// Services bundles shared subsystems the UDP servers report into.
+// Any of them can be nil when the feature is not in use.
+type Services struct {
+	Sessions    *session.Registry
+	Presence    *presence.Tracker
+	Accounts    *accounts.Registry
+	Maintenance *maintenance.Schedule
+	Relay       *relay.Registry
+	War         *war.Campaign
+	Squads      *squads.Registry
+	Results     *results.Recorder
+	Events      *events.Bus
+	Health      *health.Registry
+}
@@ -1,12 +1,15 @@
 package server
 
 import (
+	"ChromehoundsStatusServer/accounts"
 	"ChromehoundsStatusServer/config"
 	"ChromehoundsStatusServer/constants"
 	"ChromehoundsStatusServer/logging"
 	"ChromehoundsStatusServer/logging/profiling"
+	"ChromehoundsStatusServer/maintenance"
 	"ChromehoundsStatusServer/pooling"
 	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/war"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -18,32 +21,36 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-func RunStatusServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer) {
+func RunStatusServer(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, loggingConfig *config.LoggingConfig, ctx context.Context, wg *sync.WaitGroup, promConfig config.PrometheusConfig, reg prometheus.Registerer, services *Services) {
 	statusResponsesHandled := promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "status_responses_handled_total",
 		Help: "Total number of status responses handled",
 	})
+	metrics := newServerMetrics(reg, promConfig)
 	wg.Add(1)
 	defer wg.Done()
 	// Pre-compute config flags to avoid pointer dereferencing in hot path
 	enablePerfMonitoring := loggingConfig.EnablePerformanceMonitoring
-	verboseLogging := loggingConfig.Verbose
 	label := serverConfig.Label
+	verbose := logging.VerboseSwitch(label, loggingConfig.Verbose)
+	perf := profiling.Monitor(label)
 
-	conn, err := buildUDPListener(listenAddress, serverConfig.Port, serverConfig.Label, bufferSize)
+	conn, err := listen(listenAddress, serverConfig, bufferSize, services)
 	if err != nil {
 		return
 	}
 	defer conn.Close()
+	defer announceServer(services, serverConfig)()
+	defer metrics.worker()()
 
 	readBuffer := pooling.ReadBufferPool.Get()
 	defer pooling.ReadBufferPool.Put(readBuffer)
 
 	// Pre-allocate to avoid repeated allocations
-	var startTime time.Time
 	var processingTime time.Duration
 
 	for {
+		verboseLogging := verbose.Load()
 		select {
 		case <-ctx.Done():
 			if verboseLogging {
@@ -52,51 +59,76 @@ func RunStatusServer(listenAddress net.IP, serverConfig *config.ServerConfig, bu
 			return
 
 		default:
-			if enablePerfMonitoring {
-				startTime = time.Now()
-			}
-
-			n, clientAddr, err := readUDP(conn, &readBuffer, label)
+			n, clientAddr, err := readUDP(conn, &readBuffer, label, metrics)
 			if err != nil {
 				if !isTimeoutError(err) && enablePerfMonitoring {
-					profiling.RecordError()
+					perf.RecordError()
 				}
 				continue
 			}
 
+			received := time.Now()
 			packet := readBuffer[:n]
+			span := startPacketSpan("status packet", label, clientAddr, n, received)
 
 			// Validate status packet
 			if err := ValidateStatusPacket(packet, clientAddr, label); err != nil {
+				metrics.validationFailed(err)
+				publishValidationFailed(services.Events, label, clientAddr, n, err)
 				if verboseLogging {
 					logging.LogPacketValidationError(label, clientAddr, err.Error(), n)
 				}
 				if enablePerfMonitoring {
-					profiling.RecordError()
+					perf.RecordError()
 				}
+				endPacketSpan(span, outcomeInvalid, err)
 				continue // Skip invalid packets
 			}
 
 			if enablePerfMonitoring {
-				processingTime := time.Since(startTime)
-				profiling.RecordPacketProcessed(n, processingTime)
+				processingTime = time.Since(received)
+				perf.RecordPacketProcessed(n, processingTime)
 			}
 			if verboseLogging {
 				logging.LogPacketReceived(label, clientAddr, n, processingTime)
 			}
+			publishPacketReceived(services.Events, label, clientAddr, n, processingTime)
+
+			hello := decodeHelloMessage(packet, label)
+			xuid := status.XuidString(hello.Xuid)
+			now := time.Now()
+			if !isProbe(packet, clientAddr) {
+				if services.Accounts != nil && !accountAllowed(services.Accounts, xuid, clientAddr, label, now, verboseLogging) {
+					endPacketSpan(span, outcomeDenied, nil)
+					continue
+				}
+				if services.Sessions != nil {
+					services.Sessions.Touch(xuid, clientAddr, hello.ClientBuild(), label, now)
+				}
+				if services.Presence != nil {
+					services.Presence.Seen(xuid, label, now)
+				}
+			}
 
-			sendBuffer, err := createStatusResponse(&packet, label, enablePerfMonitoring)
+			maintenanceStart, maintenanceEnd := maintenanceWindow(services.Maintenance, now)
+			sendBuffer, err := createStatusResponse(&hello, gameSeason(services.War), maintenanceStart, maintenanceEnd, label, enablePerfMonitoring)
 			if err != nil {
 				if verboseLogging {
 					logging.Warn.Println(err)
 				}
 				if enablePerfMonitoring {
-					profiling.RecordError()
+					perf.RecordError()
 				}
+				endPacketSpan(span, outcomeError, err)
 				continue
 			}
 
-			sendUDP(conn, clientAddr, sendBuffer, label, true)
+			if err := sendUDP(conn, clientAddr, sendBuffer, label, true, metrics); err != nil {
+				endPacketSpan(span, outcomeError, err)
+			} else {
+				endPacketSpan(span, outcomeServed, nil)
+			}
+			metrics.processed(received)
 			if promConfig.Enabled {
 				statusResponsesHandled.Inc()
 			}
@@ -104,20 +136,61 @@ func RunStatusServer(listenAddress net.IP, serverConfig *config.ServerConfig, bu
 	}
 }
 
-func createStatusResponse(readBuffer *[]byte, label string, enablePerformanceMonitoring bool) (*[]byte, error) {
-	var startTime = time.Now()
-
-	offset := time.Hour * 12
+// records login of player and reports whether status should be served to them.
+// banned accounts are denied, unknown ones only if auto registration is disabled.
+func accountAllowed(registry *accounts.Registry, xuid string, clientAddr *net.UDPAddr, label string, now time.Time, verboseLogging bool) bool {
+	account, ok := registry.Login(xuid, now)
+	if !ok {
+		if verboseLogging {
+			logging.Warn.Printf("[%s] denied unregistered xuid %s from %s:%d\n", label, xuid, clientAddr.IP, clientAddr.Port)
+		}
+		return false
+	}
+	if account.Flags.Banned {
+		if verboseLogging {
+			logging.Warn.Printf("[%s] denied banned xuid %s from %s:%d\n", label, xuid, clientAddr.IP, clientAddr.Port)
+		}
+		return false
+	}
+	return true
+}
 
-	var helloBuffer []byte = (*readBuffer)[0:31]
+// decodes hello message from validated packet. falls back to hardcoded xuid if it can't be parsed
+func decodeHelloMessage(packet []byte, label string) status.UserHelloMessage {
+	var helloBuffer []byte = packet[0:constants.MinHelloMessageSize]
 	var helloStruct status.UserHelloMessage
 
 	if _, err := binary.Decode(helloBuffer, binary.LittleEndian, &helloStruct); err != nil {
 		logging.Warn.Printf("[%s] fallback to default xuid due to parsing error of hello header: %v\n", label, err)
 		helloStruct.Xuid = status.XuidValueHardCoded
 	}
+	return helloStruct
+}
+
+// maintenance window announced to clients. without anything scheduled,
+// window spanning 12 hours around current time is sent, as that's what clients accept as "no maintenance".
+func maintenanceWindow(schedule *maintenance.Schedule, now time.Time) (time.Time, time.Time) {
+	if schedule != nil {
+		if window, ok := schedule.Next(now); ok {
+			return window.Start, window.End
+		}
+	}
+	offset := time.Hour * 12
+	return now.Add(-offset), now.Add(offset)
+}
+
+// season advertised to clients, taken from war campaign when it runs
+func gameSeason(campaign *war.Campaign) uint32 {
+	if campaign != nil {
+		return campaign.Season()
+	}
+	return status.DefaultGameSeason
+}
+
+func createStatusResponse(hello *status.UserHelloMessage, season uint32, maintenanceStart time.Time, maintenanceEnd time.Time, label string, enablePerformanceMonitoring bool) (*[]byte, error) {
+	var startTime = time.Now()
 
-	responseStruct := status.CreateStatus(helloStruct.Xuid, startTime, startTime.Add(-offset), startTime.Add(offset))
+	responseStruct := status.CreateStatus(hello.Xuid, season, startTime, maintenanceStart, maintenanceEnd)
 
 	// Use buffer pool for response
 	sendBuffer := pooling.StatusResponsePool.Get()
@@ -146,6 +219,7 @@ func ValidateStatusPacket(packet []byte, clientAddr *net.UDPAddr, label string)
 	// Check minimum size for UserHelloMessage
 	if packetSize < constants.MinHelloMessageSize {
 		err := ValidationError{
+			Code:   PacketTooSmall,
 			Reason: fmt.Sprintf("packet too small (minimum: %d bytes)", constants.MinHelloMessageSize),
 			Size:   packetSize,
 		}
@@ -156,6 +230,7 @@ func ValidateStatusPacket(packet []byte, clientAddr *net.UDPAddr, label string)
 	// Check for reasonable maximum size to prevent abuse
 	if packetSize > constants.MaxBufferSize {
 		err := ValidationError{
+			Code:   PacketTooLarge,
 			Reason: fmt.Sprintf("packet too large (maximum: %d bytes)", constants.MaxBufferSize),
 			Size:   packetSize,
 		}
@@ -167,6 +242,7 @@ func ValidateStatusPacket(packet []byte, clientAddr *net.UDPAddr, label string)
 	expectedHeader := ChromeHoundsHeader
 	if packet[0] != expectedHeader[0] || packet[1] != expectedHeader[1] {
 		err := ValidationError{
+			Code:   InvalidHeader,
 			Reason: "invalid Chromehounds header",
 			Size:   packetSize,
 		}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"ChromehoundsStatusServer/telemetry"
+	"context"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Outcomes of handled packet, recorded on its span
+const (
+	outcomeServed  = "served"
+	outcomeInvalid = "invalid"
+	outcomeDenied  = "denied"
+	outcomeError   = "error"
+)
+
+// span that records nothing, used while tracing is disabled
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// starts span of packet received at given time. Attributes are only set on sampled spans,
+// so unsampled packets cost no more than sampling decision.
+func startPacketSpan(name string, label string, clientAddr *net.UDPAddr, size int, received time.Time) trace.Span {
+	if !telemetry.Tracing() {
+		return noopSpan
+	}
+	_, span := telemetry.Tracer().Start(context.Background(), name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(received),
+	)
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("server.name", label),
+			attribute.String("client.address", clientAddr.IP.String()),
+			attribute.Int("client.port", clientAddr.Port),
+			attribute.Int("packet.size", size),
+		)
+	}
+	return span
+}
+
+// ends packet span with outcome of handling, err marks span as failed
+func endPacketSpan(span trace.Span, outcome string, err error) {
+	if span.IsRecording() {
+		span.SetAttributes(attribute.String("packet.outcome", outcome))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, outcome)
+		}
+	}
+	span.End()
+}
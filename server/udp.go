@@ -1,9 +1,18 @@
 package server
 
 import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/constants"
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/health"
 	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/status"
+	"bytes"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -16,23 +25,67 @@ func buildUDPListener(listenAddress net.IP, listenPort int, label string, buffer
 	conn, err := net.ListenUDP("udp", &addr)
 	if err != nil {
 		logging.Error.Printf("[%s] Failed to bind: %v\n", label, err)
-		return nil, nil
+		return nil, err
 	}
 
 	logging.LogServerStart(label, listenPort, bufferSize)
 	return conn, nil
 }
 
-func readUDP(conn *net.UDPConn, buffer *[]byte, label string) (int, *net.UDPAddr, error) {
+// listen binds server socket and records outcome in health registry
+func listen(listenAddress net.IP, serverConfig *config.ServerConfig, bufferSize int, services *Services) (*net.UDPConn, error) {
+	conn, err := buildUDPListener(listenAddress, serverConfig.Port, serverConfig.Label, bufferSize)
+	if err != nil {
+		services.Health.Set(serverConfig.Label, health.Failed, err)
+		return nil, err
+	}
+	services.Health.Set(serverConfig.Label, health.Bound, nil)
+	return conn, nil
+}
+
+// publishes start of server and returns function publishing its stop
+func announceServer(services *Services, serverConfig *config.ServerConfig) func() {
+	server := events.Server{Label: serverConfig.Label, Type: string(serverConfig.Type), Port: serverConfig.Port}
+	services.Health.Set(serverConfig.Label, health.Serving, nil)
+	services.Events.Publish(events.ServerStarted, server)
+	return func() {
+		services.Health.Set(serverConfig.Label, health.Stopped, nil)
+		services.Events.Publish(events.ServerStopped, server)
+	}
+}
+
+// publishes packet that passed validation, without building payload when nobody listens
+func publishPacketReceived(bus *events.Bus, label string, clientAddr *net.UDPAddr, size int, processingTime time.Duration) {
+	if bus.Subscribed(events.PacketReceived) {
+		bus.Publish(events.PacketReceived, events.Packet{Label: label, Addr: clientAddr.String(), Size: size, ProcessingTime: processingTime})
+	}
+}
+
+// publishes rejected packet, without building payload when nobody listens
+func publishValidationFailed(bus *events.Bus, label string, clientAddr *net.UDPAddr, size int, err error) {
+	if bus.Subscribed(events.ValidationFailed) {
+		reason := err.Error()
+		var validationErr ValidationError
+		if errors.As(err, &validationErr) {
+			reason = validationErr.Reason
+		}
+		bus.Publish(events.ValidationFailed, events.ValidationFailure{Label: label, Addr: clientAddr.String(), Reason: reason, Size: size})
+	}
+}
+
+func readUDP(conn *net.UDPConn, buffer *[]byte, label string, metrics *serverMetrics) (int, *net.UDPAddr, error) {
 	conn.SetReadDeadline(time.Now().Add(1 * time.Second))
 	n, clientAddr, err := conn.ReadFromUDP(*buffer)
 	if err != nil {
 		if ne, ok := err.(net.Error); ok && ne.Timeout() {
 		} else {
 			logging.Warn.Printf("[%s] Read error: %v\n", label, err)
+			metrics.readFailed()
 		}
 		return 0, nil, err
-	} else if n == 0 {
+	}
+	metrics.received(n)
+	if n == 0 {
 
 		return 0, nil, fmt.Errorf("0 bytes recieved, but still recieved")
 	}
@@ -41,15 +94,60 @@ func readUDP(conn *net.UDPConn, buffer *[]byte, label string) (int, *net.UDPAddr
 	return n, clientAddr, nil
 }
 
-func sendUDP(conn *net.UDPConn, clientAddr *net.UDPAddr, buffer *[]byte, label string, logSend bool) error {
+func sendUDP(conn *net.UDPConn, clientAddr *net.UDPAddr, buffer *[]byte, label string, logSend bool, metrics *serverMetrics) error {
 	bytesSent, err := conn.WriteToUDP(*buffer, clientAddr)
 	if err != nil {
 		logging.Warn.Printf("[%s] send failed: %v\n", label, err)
+		metrics.sendFailed()
 		return err
 	}
+	metrics.sent(bytesSent)
 
 	if logSend {
 		logging.LogPacketSent(label, clientAddr, bytesSent)
 	}
 	return nil
 }
+
+// reports whether packet is hello of built-in prober sent from this host.
+// those are answered as usual, but are not recorded as player activity.
+func isProbe(packet []byte, clientAddr *net.UDPAddr) bool {
+	return len(packet) >= constants.MinHelloMessageSize &&
+		bytes.Equal(packet[4:4+len(status.ProbeXuid)], status.ProbeXuid[:]) &&
+		hostAddresses.contains(clientAddr.IP, time.Now())
+}
+
+// how long addresses of host interfaces are cached
+const hostAddressesRefresh = time.Minute
+
+// addresses of this host, prober sends from one of them whichever address it targets
+var hostAddresses = &addressCache{}
+
+type addressCache struct {
+	mu        sync.Mutex
+	ips       []net.IP
+	refreshed time.Time
+}
+
+func (c *addressCache) contains(ip net.IP, now time.Time) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.refreshed) > hostAddressesRefresh {
+		c.refreshed = now
+		c.ips = c.ips[:0]
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			logging.Warn.Printf("[PROBE] failed listing host addresses: %v", err)
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				c.ips = append(c.ips, ipNet.IP)
+			}
+		}
+	}
+	return slices.ContainsFunc(c.ips, ip.Equal)
+}
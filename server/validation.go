@@ -1,13 +1,57 @@
 package server
 
-import "fmt"
+import (
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/protocol"
+	"fmt"
+	"net"
+)
 
 // ValidationError represents a packet validation error
 type ValidationError struct {
+	Code   ValidationCode
 	Reason string
 	Size   int
 }
 
+// ValidationCode classifies validation errors, unlike Reason it doesn't carry packet contents
+type ValidationCode string
+
+const (
+	EmptyPacket       ValidationCode = "empty"
+	PacketTooSmall    ValidationCode = "too_small"
+	PacketTooLarge    ValidationCode = "too_large"
+	InvalidHeader     ValidationCode = "invalid_header"
+	MalformedFrame    ValidationCode = "malformed_frame"
+	UnexpectedService ValidationCode = "unexpected_service"
+)
+
 func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation failed: %s (packet size: %d)", e.Reason, e.Size)
 }
+
+// ValidateFramedPacket decodes CHxx frame and checks it is addressed to expected service
+func ValidateFramedPacket(packet []byte, service protocol.ServiceCode, clientAddr *net.UDPAddr, label string) (protocol.Frame, error) {
+	frame, err := protocol.DecodeFrame(packet)
+	if err != nil {
+		validationErr := ValidationError{
+			Code:   MalformedFrame,
+			Reason: err.Error(),
+			Size:   len(packet),
+		}
+		logging.LogPacketValidationError(label, clientAddr, validationErr.Reason, len(packet))
+		return protocol.Frame{}, validationErr
+	}
+
+	if frame.Service != service {
+		validationErr := ValidationError{
+			Code:   UnexpectedService,
+			Reason: fmt.Sprintf("unexpected service code CH%s", frame.Service[:]),
+			Size:   len(packet),
+		}
+		logging.LogPacketValidationError(label, clientAddr, validationErr.Reason, len(packet))
+		return protocol.Frame{}, validationErr
+	}
+
+	return frame, nil
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"ChromehoundsStatusServer/constants"
+	"ChromehoundsStatusServer/status"
 	"net"
 	"testing"
 )
@@ -15,6 +16,7 @@ func TestValidateStatusPacket(t *testing.T) {
 		packet      []byte
 		expectError bool
 		errorReason string
+		errorCode   ValidationCode
 	}{
 		{
 			name: "Valid packet",
@@ -30,12 +32,14 @@ func TestValidateStatusPacket(t *testing.T) {
 			packet:      make([]byte, constants.MinHelloMessageSize-1),
 			expectError: true,
 			errorReason: "packet too small",
+			errorCode:   PacketTooSmall,
 		},
 		{
 			name:        "Packet too large",
 			packet:      make([]byte, constants.MaxBufferSize+1),
 			expectError: true,
 			errorReason: "packet too large",
+			errorCode:   PacketTooLarge,
 		},
 		{
 			name: "Valid Chromehounds header",
@@ -56,6 +60,7 @@ func TestValidateStatusPacket(t *testing.T) {
 			}(),
 			expectError: true,
 			errorReason: "invalid Chromehounds header",
+			errorCode:   InvalidHeader,
 		},
 	}
 
@@ -76,6 +81,9 @@ func TestValidateStatusPacket(t *testing.T) {
 					if validationErr.Size != len(tt.packet) {
 						t.Errorf("Expected size %d but got %d", len(tt.packet), validationErr.Size)
 					}
+					if validationErr.Code != tt.errorCode {
+						t.Errorf("Expected code %s but got %s", tt.errorCode, validationErr.Code)
+					}
 				}
 			}
 		})
@@ -127,3 +135,24 @@ func TestValidateEchoPacket(t *testing.T) {
 		})
 	}
 }
+
+func TestIsProbeOnlyFromHostAddresses(t *testing.T) {
+	packet := make([]byte, constants.MinHelloMessageSize)
+	copy(packet[0:4], ChromeHoundsHeader[:])
+	copy(packet[4:], status.ProbeXuid[:])
+
+	if !isProbe(packet, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1}) {
+		t.Errorf("Expected probe from loopback to be recognized")
+	}
+	if isProbe(packet, &net.UDPAddr{IP: net.ParseIP("203.0.113.7"), Port: 1}) {
+		t.Errorf("Expected probe xuid from foreign address not to be trusted")
+	}
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+			if !isProbe(packet, &net.UDPAddr{IP: ipNet.IP, Port: 1}) {
+				t.Errorf("Expected probe from host address %s to be recognized", ipNet.IP)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"net/http"
+)
+
+// RegisterAdminRoutes exposes sessions on admin api
+func (r *Registry) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /sessions", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.Snapshot())
+	})
+
+	a.HandleFunc("GET /sessions/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		sessions := r.FindByXuid(req.PathValue("xuid"))
+		if len(sessions) == 0 {
+			admin.WriteError(w, http.StatusNotFound, "no active session")
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, sessions)
+	})
+
+	a.HandleFunc("GET /sessions/count", func(w http.ResponseWriter, req *http.Request) {
+		players, perService := r.Counts()
+		admin.WriteJSON(w, http.StatusOK, map[string]any{
+			"online_players": players,
+			"services":       perService,
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	onlinePlayersDesc = prometheus.NewDesc(
+		"sessions_online_players",
+		"Number of distinct players (by XUID) with an active session",
+		nil, nil,
+	)
+	activeSessionsDesc = prometheus.NewDesc(
+		"sessions_active",
+		"Number of active sessions that contacted given service",
+		[]string{"service"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- onlinePlayersDesc
+	ch <- activeSessionsDesc
+}
+
+// Collect implements prometheus.Collector. Values are computed at scrape time.
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	players, perService := r.Counts()
+	ch <- prometheus.MustNewConstMetric(onlinePlayersDesc, prometheus.GaugeValue, float64(players))
+	for svc, n := range perService {
+		ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(n), svc)
+	}
+}
@@ -0,0 +1,296 @@
+package session
+
+import (
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/logging"
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Key identifies a session. Xuid is empty for clients that only ever contacted services
+// which do not carry identity (e.g. echo servers) from an address we have not seen a hello from.
+type Key struct {
+	Xuid string
+	Addr string
+}
+
+// Session holds what we know about a single client
+type Session struct {
+	Xuid        string            `json:"xuid"`
+	Addr        string            `json:"addr"`
+	ClientBuild string            `json:"client_build"`
+	FirstSeen   time.Time         `json:"first_seen"`
+	LastSeen    time.Time         `json:"last_seen"`
+	Packets     uint64            `json:"packets"`
+	Services    map[string]uint64 `json:"services"` // packets received per service label
+
+	network string // counted against limit of anonymous sessions from this network, if set
+}
+
+// Registry keeps track of client sessions across all services.
+// Safe for concurrent use.
+type Registry struct {
+	mu                     sync.Mutex
+	idleTimeout            time.Duration
+	maxSessions            int
+	maxAnonymousPerNetwork int
+	sessions               map[Key]*Session
+	byAddr                 map[string]Key // last identified session per source address
+	online                 map[string]int // identified sessions per xuid
+	anonymous              map[string]int // sessions without identity per network
+	events                 *events.Bus
+}
+
+// NewRegistry creates session registry dropping sessions idle for longer than idleTimeout.
+// It tracks at most maxSessions sessions and maxAnonymousPerNetwork sessions without identity
+// from single /24 (/64 for IPv6) network, 0 meaning unlimited. Packets over the limits are not recorded.
+func NewRegistry(idleTimeout time.Duration, maxSessions int, maxAnonymousPerNetwork int) *Registry {
+	return &Registry{
+		idleTimeout:            idleTimeout,
+		maxSessions:            maxSessions,
+		maxAnonymousPerNetwork: maxAnonymousPerNetwork,
+		sessions:               make(map[Key]*Session),
+		byAddr:                 make(map[string]Key),
+		online:                 make(map[string]int),
+		anonymous:              make(map[string]int),
+	}
+}
+
+// SetEvents makes registry publish started sessions and players coming online and going offline to bus
+func (r *Registry) SetEvents(bus *events.Bus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = bus
+}
+
+// Touch records packet from identified client, e.g. hello message received by status server
+func (r *Registry) Touch(xuid string, addr *net.UDPAddr, clientBuild string, service string, now time.Time) {
+	key := Key{Xuid: xuid, Addr: addr.String()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// identity is now known for this address, anonymous session is folded into identified one
+	anonKey := Key{Addr: key.Addr}
+	anon, hadAnon := r.sessions[anonKey]
+	if hadAnon && xuid != "" {
+		r.remove(anonKey)
+	}
+
+	s := r.getOrCreate(key, addr, service, now)
+	if s == nil {
+		return
+	}
+	if clientBuild != "" {
+		s.ClientBuild = clientBuild
+	}
+	r.record(s, service, now)
+
+	if hadAnon && xuid != "" {
+		s.Packets += anon.Packets
+		for svc, n := range anon.Services {
+			s.Services[svc] += n
+		}
+		if anon.FirstSeen.Before(s.FirstSeen) {
+			s.FirstSeen = anon.FirstSeen
+		}
+	}
+	r.byAddr[key.Addr] = key
+}
+
+// TouchAddr records packet from client known only by its address.
+// It is attributed to the identified session from the same address, if there's one.
+func (r *Registry) TouchAddr(addr *net.UDPAddr, service string, now time.Time) {
+	addrString := addr.String()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.byAddr[addrString]
+	if !ok {
+		key = Key{Addr: addrString}
+	}
+	if s := r.getOrCreate(key, addr, service, now); s != nil {
+		r.record(s, service, now)
+	}
+}
+
+// returns nil when session doesn't exist and limits don't allow creating it
+func (r *Registry) getOrCreate(key Key, addr *net.UDPAddr, service string, now time.Time) *Session {
+	s, ok := r.sessions[key]
+	if !ok {
+		if r.maxSessions > 0 && len(r.sessions) >= r.maxSessions {
+			return nil
+		}
+		var network string
+		if key.Xuid == "" {
+			network = networkOf(addr)
+			if r.maxAnonymousPerNetwork > 0 && r.anonymous[network] >= r.maxAnonymousPerNetwork {
+				return nil
+			}
+			r.anonymous[network]++
+		}
+		s = &Session{
+			Xuid:      key.Xuid,
+			Addr:      key.Addr,
+			FirstSeen: now,
+			Services:  make(map[string]uint64),
+			network:   network,
+		}
+		r.sessions[key] = s
+		r.events.Publish(events.SessionStarted, events.Session{Xuid: key.Xuid, Addr: key.Addr, Service: service})
+		if key.Xuid != "" {
+			r.online[key.Xuid]++
+			if r.online[key.Xuid] == 1 {
+				r.events.Publish(events.PlayerOnline, events.Player{Xuid: key.Xuid})
+			}
+		}
+	}
+	return s
+}
+
+func (r *Registry) record(s *Session, service string, now time.Time) {
+	s.LastSeen = now
+	s.Packets++
+	s.Services[service]++
+}
+
+// XuidForAddr returns identity last seen from given address, if any
+func (r *Registry) XuidForAddr(addr *net.UDPAddr) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.byAddr[addr.String()]
+	if !ok || key.Xuid == "" {
+		return "", false
+	}
+	return key.Xuid, true
+}
+
+// Expire drops sessions idle for longer than configured timeout. Returns number of dropped sessions.
+func (r *Registry) Expire(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := 0
+	for key, s := range r.sessions {
+		if now.Sub(s.LastSeen) > r.idleTimeout {
+			r.remove(key)
+			if r.byAddr[key.Addr] == key {
+				delete(r.byAddr, key.Addr)
+			}
+			if key.Xuid != "" {
+				r.online[key.Xuid]--
+				if r.online[key.Xuid] == 0 {
+					delete(r.online, key.Xuid)
+					r.events.Publish(events.PlayerOffline, events.Player{Xuid: key.Xuid})
+				}
+			}
+			expired++
+		}
+	}
+	return expired
+}
+
+// drops session, releasing its slot of anonymous sessions. caller has to hold lock
+func (r *Registry) remove(key Key) {
+	s, ok := r.sessions[key]
+	if !ok {
+		return
+	}
+	delete(r.sessions, key)
+	if key.Xuid == "" {
+		r.anonymous[s.network]--
+		if r.anonymous[s.network] <= 0 {
+			delete(r.anonymous, s.network)
+		}
+	}
+}
+
+// network of address that anonymous sessions are limited by
+func networkOf(addr *net.UDPAddr) string {
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return addr.IP.Mask(net.CIDRMask(64, 128)).String()
+}
+
+// Snapshot returns copy of all active sessions, most recently seen first
+func (r *Registry) Snapshot() []Session {
+	r.mu.Lock()
+	result := make([]Session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		result = append(result, s.copy())
+	}
+	r.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result
+}
+
+// FindByXuid returns all active sessions of given client
+func (r *Registry) FindByXuid(xuid string) []Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []Session
+	for key, s := range r.sessions {
+		if key.Xuid == xuid {
+			result = append(result, s.copy())
+		}
+	}
+	return result
+}
+
+// Counts returns number of distinct online players and number of sessions per service
+func (r *Registry) Counts() (players int, perService map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	xuids := make(map[string]struct{})
+	perService = make(map[string]int)
+	for key, s := range r.sessions {
+		if key.Xuid != "" {
+			xuids[key.Xuid] = struct{}{}
+		}
+		for svc := range s.Services {
+			perService[svc]++
+		}
+	}
+	return len(xuids), perService
+}
+
+// Run periodically expires idle sessions until context is cancelled
+func (r *Registry) Run(ctx context.Context) {
+	interval := r.idleTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if expired := r.Expire(now); expired > 0 {
+				logging.Info.Printf("[SESSIONS] expired %d idle sessions", expired)
+			}
+		}
+	}
+}
+
+func (s *Session) copy() Session {
+	c := *s
+	c.Services = make(map[string]uint64, len(s.Services))
+	for svc, n := range s.Services {
+		c.Services[svc] = n
+	}
+	return c
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTouchAndExpire(t *testing.T) {
+	registry := NewRegistry(time.Minute, 0, 0)
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}
+	start := time.Now()
+
+	registry.Touch("00900004EA25063", addr, "00000001", "STATUS", start)
+	registry.Touch("00900004EA25063", addr, "", "STATUS", start.Add(time.Second))
+
+	sessions := registry.Snapshot()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	s := sessions[0]
+	if s.Packets != 2 {
+		t.Errorf("Expected 2 packets, got %d", s.Packets)
+	}
+	if s.ClientBuild != "00000001" {
+		t.Errorf("Expected client build to be kept, got %q", s.ClientBuild)
+	}
+	if !s.FirstSeen.Equal(start) || !s.LastSeen.Equal(start.Add(time.Second)) {
+		t.Errorf("Unexpected first/last seen: %v / %v", s.FirstSeen, s.LastSeen)
+	}
+
+	if expired := registry.Expire(start.Add(30 * time.Second)); expired != 0 {
+		t.Errorf("Expected no sessions expired, got %d", expired)
+	}
+	if expired := registry.Expire(start.Add(2 * time.Minute)); expired != 1 {
+		t.Errorf("Expected 1 session expired, got %d", expired)
+	}
+	if len(registry.Snapshot()) != 0 {
+		t.Errorf("Expected registry to be empty after expiry")
+	}
+}
+
+func TestTouchAddrAttributedToIdentifiedSession(t *testing.T) {
+	registry := NewRegistry(time.Minute, 0, 0)
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}
+	now := time.Now()
+
+	// echo traffic before hello is tracked anonymously, then merged
+	registry.TouchAddr(addr, "WORLD", now)
+	registry.Touch("00900004EA25063", addr, "", "STATUS", now)
+	registry.TouchAddr(addr, "WORLD", now)
+
+	sessions := registry.Snapshot()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	s := sessions[0]
+	if s.Xuid != "00900004EA25063" {
+		t.Errorf("Expected session to be identified, got xuid %q", s.Xuid)
+	}
+	if s.Services["WORLD"] != 2 || s.Services["STATUS"] != 1 {
+		t.Errorf("Unexpected per-service packet counts: %v", s.Services)
+	}
+
+	players, perService := registry.Counts()
+	if players != 1 {
+		t.Errorf("Expected 1 online player, got %d", players)
+	}
+	if perService["WORLD"] != 1 || perService["STATUS"] != 1 {
+		t.Errorf("Unexpected per-service session counts: %v", perService)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	registry := NewRegistry(time.Minute, 4, 2)
+	now := time.Now()
+
+	// spoofed sources from single network only take up its share
+	for port := range 5 {
+		registry.TouchAddr(&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1000 + port}, "WORLD", now)
+	}
+	registry.TouchAddr(&net.UDPAddr{IP: net.ParseIP("10.0.0.200"), Port: 1000}, "WORLD", now)
+	if sessions := registry.Snapshot(); len(sessions) != 2 {
+		t.Errorf("Expected 2 anonymous sessions from the network, got %d", len(sessions))
+	}
+
+	// identified session replaces anonymous one from the same address
+	registry.Touch("PLAYER1", &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1000}, "", "STATUS", now)
+	registry.TouchAddr(&net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1000}, "WORLD", now)
+	if sessions := registry.Snapshot(); len(sessions) != 3 {
+		t.Errorf("Expected identified session to free anonymous slot, got %d sessions", len(sessions))
+	}
+
+	registry.Touch("PLAYER2", &net.UDPAddr{IP: net.ParseIP("10.0.1.1"), Port: 1000}, "", "STATUS", now)
+	registry.Touch("PLAYER3", &net.UDPAddr{IP: net.ParseIP("10.0.2.1"), Port: 1000}, "", "STATUS", now)
+	if sessions := registry.Snapshot(); len(sessions) != 4 {
+		t.Errorf("Expected total limit of 4 sessions, got %d", len(sessions))
+	}
+
+	registry.Expire(now.Add(2 * time.Minute))
+	for port := range 2 {
+		registry.TouchAddr(&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2000 + port}, "WORLD", now.Add(2*time.Minute))
+	}
+	if sessions := registry.Snapshot(); len(sessions) != 2 {
+		t.Errorf("Expected expired sessions to free their slots, got %d sessions", len(sessions))
+	}
+}
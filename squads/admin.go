@@ -0,0 +1,192 @@
+package squads
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/war"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// body of squad management requests. Actor is XUID of player on whose behalf
+// the community site acts, Admin is set instead for administrative changes.
+type request struct {
+	Actor  string     `json:"actor"`
+	Admin  bool       `json:"admin"`
+	Tag    string     `json:"tag"`
+	Name   string     `json:"name"`
+	Nation war.Nation `json:"nation"`
+	Leader string     `json:"leader"`
+	Xuid   string     `json:"xuid"`
+	Role   Role       `json:"role"`
+}
+
+// RegisterAdminRoutes exposes squad management on admin api
+func (r *Registry) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /squads", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.List())
+	})
+
+	a.HandleFunc("GET /squads/{tag}", func(w http.ResponseWriter, req *http.Request) {
+		squad, ok := r.Get(req.PathValue("tag"))
+		if !ok {
+			admin.WriteError(w, http.StatusNotFound, ErrNotFound.Error())
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, squad)
+	})
+
+	a.HandleFunc("GET /players/{xuid}/squad", func(w http.ResponseWriter, req *http.Request) {
+		squad, ok := r.SquadOf(req.PathValue("xuid"))
+		if !ok {
+			admin.WriteError(w, http.StatusNotFound, ErrNotMember.Error())
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, squad)
+	})
+
+	a.HandleFunc("GET /players/{xuid}/invites", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, r.InvitesOf(req.PathValue("xuid"), time.Now()))
+	})
+
+	a.HandleFunc("POST /squads", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := decodeRequest(w, req)
+		if !ok {
+			return
+		}
+		squad, err := r.Create(body.Tag, body.Name, body.Nation, body.Leader, time.Now())
+		if err == nil {
+			admin.Audit(req, "squad.create", squad.Tag, nil, squad)
+		}
+		writeResult(w, http.StatusCreated, squad, err)
+	})
+
+	a.HandleFunc("DELETE /squads/{tag}", func(w http.ResponseWriter, req *http.Request) {
+		before, found := r.Get(req.PathValue("tag"))
+		err := r.Disband(req.PathValue("tag"), queryActor(req))
+		if err == nil {
+			admin.Audit(req, "squad.disband", before.Tag, optional(before, found), nil)
+		}
+		writeResult(w, http.StatusNoContent, nil, err)
+	})
+
+	a.HandleFunc("PUT /squads/{tag}/nation", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := decodeRequest(w, req)
+		if !ok {
+			return
+		}
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.SetNation(req.PathValue("tag"), body.actor(), body.Nation)
+		auditChange(req, "squad.nation", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+
+	a.HandleFunc("POST /squads/{tag}/invites", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := decodeRequest(w, req)
+		if !ok {
+			return
+		}
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.Invite(req.PathValue("tag"), body.actor(), body.Xuid, time.Now())
+		auditChange(req, "squad.invite", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+
+	a.HandleFunc("POST /squads/{tag}/invites/{xuid}/accept", func(w http.ResponseWriter, req *http.Request) {
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.Accept(req.PathValue("tag"), req.PathValue("xuid"), time.Now())
+		auditChange(req, "squad.accept", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+
+	a.HandleFunc("DELETE /squads/{tag}/invites/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.Decline(req.PathValue("tag"), queryActor(req), req.PathValue("xuid"))
+		auditChange(req, "squad.decline", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+
+	a.HandleFunc("DELETE /squads/{tag}/members/{xuid}", func(w http.ResponseWriter, req *http.Request) {
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.Remove(req.PathValue("tag"), queryActor(req), req.PathValue("xuid"))
+		auditChange(req, "squad.remove", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+
+	a.HandleFunc("PUT /squads/{tag}/members/{xuid}/role", func(w http.ResponseWriter, req *http.Request) {
+		body, ok := decodeRequest(w, req)
+		if !ok {
+			return
+		}
+		before, found := r.Get(req.PathValue("tag"))
+		squad, err := r.SetRole(req.PathValue("tag"), body.actor(), req.PathValue("xuid"), body.Role)
+		auditChange(req, "squad.role", optional(before, found), squad, err)
+		writeResult(w, http.StatusOK, squad, err)
+	})
+}
+
+// returns actor of request, AdminActor only when admin flag is set
+func (body request) actor() string {
+	return actorOf(body.Actor, body.Admin)
+}
+
+// returns actor given by actor or admin=true query parameter of request
+func queryActor(req *http.Request) string {
+	query := req.URL.Query()
+	return actorOf(query.Get("actor"), query.Get("admin") == "true")
+}
+
+func actorOf(actor string, admin bool) string {
+	switch {
+	case admin:
+		return AdminActor
+	case actor == AdminActor:
+		// site can't pass players off as admin
+		return ""
+	default:
+		return actor
+	}
+}
+
+// records successful change of squad
+func auditChange(req *http.Request, action string, before any, after Squad, err error) {
+	if err == nil {
+		admin.Audit(req, action, after.Tag, before, after)
+	}
+}
+
+func optional(squad Squad, found bool) any {
+	if !found {
+		return nil
+	}
+	return squad
+}
+
+func decodeRequest(w http.ResponseWriter, req *http.Request) (request, bool) {
+	var body request
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return request{}, false
+	}
+	return body, true
+}
+
+func writeResult(w http.ResponseWriter, status int, value any, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotMember), errors.Is(err, ErrNoInvite):
+		admin.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotLeader), errors.Is(err, ErrNoActor):
+		admin.WriteError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrTaken), errors.Is(err, ErrAlreadyInSquad), errors.Is(err, ErrSquadFull), errors.Is(err, ErrLeaderLeaving):
+		admin.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrInvalidSquad), errors.Is(err, ErrInvalidRole), errors.Is(err, ErrUnknownPlayer), errors.Is(err, war.ErrUnknownNation):
+		admin.WriteError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		admin.WriteError(w, http.StatusInternalServerError, err.Error())
+	case value == nil:
+		w.WriteHeader(status)
+	default:
+		admin.WriteJSON(w, status, value)
+	}
+}
@@ -0,0 +1,43 @@
+package squads
+
+import (
+	"ChromehoundsStatusServer/war"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	squadsDesc = prometheus.NewDesc(
+		"squads",
+		"Number of squads by nation",
+		[]string{"nation"}, nil,
+	)
+	membersDesc = prometheus.NewDesc(
+		"squad_members",
+		"Number of players in squads by nation",
+		[]string{"nation"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- squadsDesc
+	ch <- membersDesc
+}
+
+// Collect implements prometheus.Collector
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	squads := make(map[war.Nation]int)
+	members := make(map[war.Nation]int)
+	r.mu.RLock()
+	for _, s := range r.squads {
+		squads[s.Nation]++
+		members[s.Nation] += len(s.Members)
+	}
+	r.mu.RUnlock()
+
+	for _, nation := range war.Nations {
+		ch <- prometheus.MustNewConstMetric(squadsDesc, prometheus.GaugeValue, float64(squads[nation]), string(nation))
+		ch <- prometheus.MustNewConstMetric(membersDesc, prometheus.GaugeValue, float64(members[nation]), string(nation))
+	}
+}
@@ -0,0 +1,511 @@
+package squads
+
+import (
+	"ChromehoundsStatusServer/accounts"
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/war"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const squadsBucket = "squads"
+
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial squad schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+var (
+	ErrNotFound       = errors.New("squad not found")
+	ErrInvalidSquad   = errors.New("squad needs name and tag of 2 to 6 letters or digits")
+	ErrTaken          = errors.New("squad name or tag already taken")
+	ErrUnknownPlayer  = errors.New("player has no account")
+	ErrAlreadyInSquad = errors.New("player is already member of a squad")
+	ErrNotMember      = errors.New("player is not member of squad")
+	ErrNotLeader      = errors.New("only squad leader can do that")
+	ErrLeaderLeaving  = errors.New("leader has to hand over leadership before leaving")
+	ErrSquadFull      = errors.New("squad is full")
+	ErrNoInvite       = errors.New("player is not invited to squad")
+	ErrInvalidRole    = errors.New("unknown squad role")
+	ErrNoActor        = errors.New("acting player is required")
+)
+
+// AdminActor acts as administrator, bypassing role checks of squad operations
+const AdminActor = "admin"
+
+// Role of squad member
+type Role string
+
+const (
+	RoleLeader Role = "leader"
+	RoleMember Role = "member"
+)
+
+// Member of squad, identified by XUID
+type Member struct {
+	Xuid   string    `json:"xuid"`
+	Role   Role      `json:"role"`
+	Joined time.Time `json:"joined"`
+}
+
+// Invite lets player join squad until it expires
+type Invite struct {
+	Xuid      string    `json:"xuid"`
+	InvitedBy string    `json:"invited_by"`
+	Expires   time.Time `json:"expires"`
+}
+
+// Stats of matches squad members played together
+type Stats struct {
+	Matches int `json:"matches"`
+	Wins    int `json:"wins"`
+	Losses  int `json:"losses"`
+	Kills   int `json:"kills"`
+	Deaths  int `json:"deaths"`
+}
+
+// Squad is a team of players fighting for one nation. Tag identifies the squad.
+type Squad struct {
+	Tag     string     `json:"tag"`
+	Name    string     `json:"name"`
+	Nation  war.Nation `json:"nation"`
+	Created time.Time  `json:"created"`
+	Members []Member   `json:"members"` // ordered by join time
+	Invites []Invite   `json:"invites"`
+	Stats   Stats      `json:"stats"`
+}
+
+// Leader returns XUID of squad leader
+func (s Squad) Leader() string {
+	for _, m := range s.Members {
+		if m.Role == RoleLeader {
+			return m.Xuid
+		}
+	}
+	return ""
+}
+
+func (s Squad) memberIndex(xuid string) int {
+	return slices.IndexFunc(s.Members, func(m Member) bool { return m.Xuid == xuid })
+}
+
+func (s Squad) inviteIndex(xuid string) int {
+	return slices.IndexFunc(s.Invites, func(i Invite) bool { return i.Xuid == xuid })
+}
+
+// Registry holds all squads persisted in storage. Safe for concurrent use.
+//
+// Operations take XUID of the acting player and check their role in squad.
+// AdminActor bypasses the checks, empty actor is rejected.
+type Registry struct {
+	store       storage.Store
+	accounts    *accounts.Registry // nil allows any XUID
+	maxMembers  int
+	inviteValid time.Duration
+
+	mu       sync.RWMutex
+	squads   map[string]*Squad // by tag
+	byMember map[string]string // xuid to tag
+}
+
+// Open loads squads from store. When accounts are given, only players with account can join squads.
+func Open(store storage.Store, cfg config.SquadsConfig, accountRegistry *accounts.Registry) (*Registry, error) {
+	if err := storage.Migrate(store, squadsBucket, migrations); err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		store:       store,
+		accounts:    accountRegistry,
+		maxMembers:  cfg.MaxMembers,
+		inviteValid: time.Duration(cfg.InviteExpiryHours) * time.Hour,
+		squads:      make(map[string]*Squad),
+		byMember:    make(map[string]string),
+	}
+	err := storage.LoadAllJSON(store, squadsBucket, func(_ string, s Squad) {
+		r.squads[s.Tag] = &s
+		for _, m := range s.Members {
+			r.byMember[m.Xuid] = s.Tag
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading squads: %w", err)
+	}
+	return r, nil
+}
+
+// Get returns squad by tag
+func (r *Registry) Get(tag string) (Squad, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return Squad{}, false
+	}
+	return s.copy(), true
+}
+
+// List returns all squads ordered by tag
+func (r *Registry) List() []Squad {
+	r.mu.RLock()
+	result := make([]Squad, 0, len(r.squads))
+	for _, s := range r.squads {
+		result = append(result, s.copy())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+// SquadOf returns squad player is member of
+func (r *Registry) SquadOf(xuid string) (Squad, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tag, ok := r.byMember[xuid]
+	if !ok {
+		return Squad{}, false
+	}
+	return r.squads[tag].copy(), true
+}
+
+// InvitesOf returns squads player has pending invites from
+func (r *Registry) InvitesOf(xuid string, now time.Time) []Squad {
+	result := []Squad{}
+	for _, s := range r.List() {
+		if i := s.inviteIndex(xuid); i >= 0 && now.Before(s.Invites[i].Expires) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Create founds squad with given player as its leader
+func (r *Registry) Create(tag string, name string, nation war.Nation, leader string, now time.Time) (Squad, error) {
+	tag = normalizeTag(tag)
+	name = strings.TrimSpace(name)
+	if !validTag(tag) || name == "" {
+		return Squad{}, ErrInvalidSquad
+	}
+	if _, err := war.ParseNation(string(nation)); err != nil {
+		return Squad{}, err
+	}
+	if err := r.checkPlayer(leader); err != nil {
+		return Squad{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byMember[leader]; ok {
+		return Squad{}, ErrAlreadyInSquad
+	}
+	for _, s := range r.squads {
+		if s.Tag == tag || strings.EqualFold(s.Name, name) {
+			return Squad{}, ErrTaken
+		}
+	}
+
+	s := &Squad{
+		Tag:     tag,
+		Name:    name,
+		Nation:  nation,
+		Created: now,
+		Members: []Member{{Xuid: leader, Role: RoleLeader, Joined: now}},
+		Invites: []Invite{},
+	}
+	if err := r.write(s); err != nil {
+		return Squad{}, err
+	}
+	r.squads[tag] = s
+	r.byMember[leader] = tag
+	return s.copy(), nil
+}
+
+// Disband removes squad and frees its members
+func (r *Registry) Disband(tag string, actor string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.authorize(tag, actor)
+	if err != nil {
+		return err
+	}
+	if err := r.store.Delete(squadsBucket, s.Tag); err != nil {
+		return fmt.Errorf("deleting squad %s: %w", s.Tag, err)
+	}
+	for _, m := range s.Members {
+		delete(r.byMember, m.Xuid)
+	}
+	delete(r.squads, s.Tag)
+	return nil
+}
+
+// Invite allows player to join squad. Inviting again renews the invite.
+func (r *Registry) Invite(tag string, actor string, xuid string, now time.Time) (Squad, error) {
+	if err := r.checkPlayer(xuid); err != nil {
+		return Squad{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.authorize(tag, actor)
+	if err != nil {
+		return Squad{}, err
+	}
+	if s.memberIndex(xuid) >= 0 {
+		return Squad{}, ErrAlreadyInSquad
+	}
+
+	invite := Invite{Xuid: xuid, InvitedBy: actor, Expires: now.Add(r.inviteValid)}
+	return r.update(s, func(s *Squad) {
+		s.pruneInvites(now)
+		if i := s.inviteIndex(xuid); i >= 0 {
+			s.Invites[i] = invite
+		} else {
+			s.Invites = append(s.Invites, invite)
+		}
+	})
+}
+
+// Accept makes invited player member of squad
+func (r *Registry) Accept(tag string, xuid string, now time.Time) (Squad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return Squad{}, ErrNotFound
+	}
+	if i := s.inviteIndex(xuid); i < 0 || !now.Before(s.Invites[i].Expires) {
+		return Squad{}, ErrNoInvite
+	}
+	if _, ok := r.byMember[xuid]; ok {
+		return Squad{}, ErrAlreadyInSquad
+	}
+	if len(s.Members) >= r.maxMembers {
+		return Squad{}, ErrSquadFull
+	}
+
+	result, err := r.update(s, func(s *Squad) {
+		s.Invites = slices.Delete(s.Invites, s.inviteIndex(xuid), s.inviteIndex(xuid)+1)
+		s.pruneInvites(now)
+		s.Members = append(s.Members, Member{Xuid: xuid, Role: RoleMember, Joined: now})
+	})
+	if err == nil {
+		r.byMember[xuid] = s.Tag
+	}
+	return result, err
+}
+
+// Decline drops invite of player. Invited player, leader or admin may decline it.
+func (r *Registry) Decline(tag string, actor string, xuid string) (Squad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return Squad{}, ErrNotFound
+	}
+	if actor != xuid {
+		if _, err := r.authorize(tag, actor); err != nil {
+			return Squad{}, err
+		}
+	}
+	i := s.inviteIndex(xuid)
+	if i < 0 {
+		return Squad{}, ErrNoInvite
+	}
+	return r.update(s, func(s *Squad) {
+		s.Invites = slices.Delete(s.Invites, i, i+1)
+	})
+}
+
+// Remove takes player out of squad. Members may leave on their own, leader or admin may kick them.
+func (r *Registry) Remove(tag string, actor string, xuid string) (Squad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return Squad{}, ErrNotFound
+	}
+	if actor != xuid {
+		if _, err := r.authorize(tag, actor); err != nil {
+			return Squad{}, err
+		}
+	}
+	i := s.memberIndex(xuid)
+	if i < 0 {
+		return Squad{}, ErrNotMember
+	}
+	if s.Members[i].Role == RoleLeader {
+		return Squad{}, ErrLeaderLeaving
+	}
+
+	result, err := r.update(s, func(s *Squad) {
+		s.Members = slices.Delete(s.Members, i, i+1)
+	})
+	if err == nil {
+		delete(r.byMember, xuid)
+	}
+	return result, err
+}
+
+// SetRole changes role of member. Making member leader demotes the current one.
+func (r *Registry) SetRole(tag string, actor string, xuid string, role Role) (Squad, error) {
+	if role != RoleLeader && role != RoleMember {
+		return Squad{}, ErrInvalidRole
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.authorize(tag, actor)
+	if err != nil {
+		return Squad{}, err
+	}
+	i := s.memberIndex(xuid)
+	if i < 0 {
+		return Squad{}, ErrNotMember
+	}
+	if role == RoleMember && s.Members[i].Role == RoleLeader {
+		// squad can't be left without leader, hand leadership over instead
+		return Squad{}, ErrLeaderLeaving
+	}
+
+	return r.update(s, func(s *Squad) {
+		if role == RoleLeader {
+			for j := range s.Members {
+				s.Members[j].Role = RoleMember
+			}
+		}
+		s.Members[i].Role = role
+	})
+}
+
+// SetNation changes allegiance of squad
+func (r *Registry) SetNation(tag string, actor string, nation war.Nation) (Squad, error) {
+	if _, err := war.ParseNation(string(nation)); err != nil {
+		return Squad{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.authorize(tag, actor)
+	if err != nil {
+		return Squad{}, err
+	}
+	return r.update(s, func(s *Squad) {
+		s.Nation = nation
+	})
+}
+
+// RecordMatch adds result of match played by squad to its stats
+func (r *Registry) RecordMatch(tag string, won bool, kills int, deaths int) (Squad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return Squad{}, ErrNotFound
+	}
+	return r.update(s, func(s *Squad) {
+		s.Stats.Matches++
+		if won {
+			s.Stats.Wins++
+		} else {
+			s.Stats.Losses++
+		}
+		s.Stats.Kills += kills
+		s.Stats.Deaths += deaths
+	})
+}
+
+// checks player may be squad member
+func (r *Registry) checkPlayer(xuid string) error {
+	if xuid == "" {
+		return ErrUnknownPlayer
+	}
+	if r.accounts != nil {
+		if _, ok := r.accounts.Get(xuid); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownPlayer, xuid)
+		}
+	}
+	return nil
+}
+
+// returns squad if actor leads it. caller has to hold lock.
+func (r *Registry) authorize(tag string, actor string) (*Squad, error) {
+	s, ok := r.squads[normalizeTag(tag)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	switch {
+	case actor == "":
+		return nil, ErrNoActor
+	case actor != AdminActor && s.Leader() != actor:
+		return nil, ErrNotLeader
+	}
+	return s, nil
+}
+
+// applies change to copy of squad and keeps it once persisted. caller has to hold lock.
+func (r *Registry) update(s *Squad, change func(*Squad)) (Squad, error) {
+	updated := s.copy()
+	change(&updated)
+	if err := r.write(&updated); err != nil {
+		return Squad{}, err
+	}
+	*s = updated
+	return updated.copy(), nil
+}
+
+// caller has to hold lock
+func (r *Registry) write(s *Squad) error {
+	if err := storage.PutJSON(r.store, squadsBucket, s.Tag, s); err != nil {
+		return fmt.Errorf("storing squad %s: %w", s.Tag, err)
+	}
+	return nil
+}
+
+func (s *Squad) pruneInvites(now time.Time) {
+	s.Invites = slices.DeleteFunc(s.Invites, func(i Invite) bool {
+		return !now.Before(i.Expires)
+	})
+}
+
+func (s Squad) copy() Squad {
+	s.Members = slices.Clone(s.Members)
+	s.Invites = slices.Clone(s.Invites)
+	return s
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToUpper(strings.TrimSpace(tag))
+}
+
+func validTag(tag string) bool {
+	if len(tag) < 2 || len(tag) > 6 {
+		return false
+	}
+	for _, c := range tag {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package squads
+
+import (
+	"ChromehoundsStatusServer/accounts"
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/storage"
+	"ChromehoundsStatusServer/war"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	leader = "00900004EA25063"
+	member = "00900004EA25064"
+	other  = "00900004EA25065"
+)
+
+func openTestRegistry(t *testing.T, store storage.Store) *Registry {
+	registry, err := Open(store, config.SquadsConfig{MaxMembers: 2, InviteExpiryHours: 1}, nil)
+	if err != nil {
+		t.Fatalf("Failed opening registry: %v", err)
+	}
+	return registry
+}
+
+func TestSquadMembershipLifecycle(t *testing.T) {
+	store := storage.NewMemoryStore()
+	registry := openTestRegistry(t, store)
+	now := time.Now()
+
+	if _, err := registry.Create("x", "Bad", war.Morskoj, leader, now); !errors.Is(err, ErrInvalidSquad) {
+		t.Errorf("Expected ErrInvalidSquad for short tag, got %v", err)
+	}
+	if _, err := registry.Create("hnd", "Hounds", war.Morskoj, leader, now); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := registry.Create("HND2", "hounds", war.Rutania, other, now); !errors.Is(err, ErrTaken) {
+		t.Errorf("Expected ErrTaken for duplicate name, got %v", err)
+	}
+
+	if _, err := registry.Invite("HND", member, other, now); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Expected ErrNotLeader for invite by non leader, got %v", err)
+	}
+	registry.Invite("HND", leader, member, now)
+	if _, err := registry.Accept("HND", member, now.Add(2*time.Hour)); !errors.Is(err, ErrNoInvite) {
+		t.Errorf("Expected expired invite to be rejected, got %v", err)
+	}
+	registry.Invite("HND", leader, member, now)
+	if _, err := registry.Accept("HND", member, now); err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+
+	registry.Invite("HND", leader, other, now)
+	if _, err := registry.Accept("HND", other, now); !errors.Is(err, ErrSquadFull) {
+		t.Errorf("Expected ErrSquadFull, got %v", err)
+	}
+
+	if _, err := registry.Remove("HND", leader, leader); !errors.Is(err, ErrLeaderLeaving) {
+		t.Errorf("Expected leader to be unable to leave, got %v", err)
+	}
+	squad, err := registry.SetRole("HND", leader, member, RoleLeader)
+	if err != nil || squad.Leader() != member {
+		t.Fatalf("Expected leadership handed over, got %v, %v", squad.Leader(), err)
+	}
+	if _, err := registry.Remove("HND", leader, leader); err != nil {
+		t.Errorf("Expected former leader to leave, got %v", err)
+	}
+
+	reloaded := openTestRegistry(t, store)
+	squad, ok := reloaded.SquadOf(member)
+	if !ok || squad.Tag != "HND" || len(squad.Members) != 1 {
+		t.Errorf("Expected member alone in HND after reload, got %v", squad)
+	}
+	if _, ok := reloaded.SquadOf(leader); ok {
+		t.Errorf("Expected former leader without squad after reload")
+	}
+
+	if err := reloaded.Disband("HND", ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("Expected ErrNoActor for disband without actor, got %v", err)
+	}
+	if err := reloaded.Disband("HND", AdminActor); err != nil {
+		t.Fatalf("Disband failed: %v", err)
+	}
+	if _, ok := reloaded.SquadOf(member); ok {
+		t.Errorf("Expected member to be freed by disband")
+	}
+}
+
+func TestSquadRequiresAccount(t *testing.T) {
+	store := storage.NewMemoryStore()
+	accountRegistry, _ := accounts.Open(store, config.AccountsConfig{})
+	registry, _ := Open(store, config.SquadsConfig{MaxMembers: 4, InviteExpiryHours: 1}, accountRegistry)
+
+	if _, err := registry.Create("HND", "Hounds", war.Tarakia, leader, time.Now()); !errors.Is(err, ErrUnknownPlayer) {
+		t.Errorf("Expected ErrUnknownPlayer, got %v", err)
+	}
+	accountRegistry.Put(accounts.Account{Xuid: leader})
+	if _, err := registry.Create("HND", "Hounds", war.Tarakia, leader, time.Now()); err != nil {
+		t.Errorf("Expected create to succeed for known player, got %v", err)
+	}
+}
+
+func TestAdminFlagRequiredForAdminActor(t *testing.T) {
+	if actor := actorOf(AdminActor, false); actor != "" {
+		t.Errorf("Expected admin actor without flag to be dropped, got %q", actor)
+	}
+	if actor := actorOf(leader, true); actor != AdminActor {
+		t.Errorf("Expected admin flag to act as admin, got %q", actor)
+	}
+	if actor := actorOf(leader, false); actor != leader {
+		t.Errorf("Expected player actor, got %q", actor)
+	}
+}
@@ -1,6 +1,8 @@
 package status
 
 import (
+	"bytes"
+	"encoding/binary"
 	"time"
 )
 
@@ -60,6 +62,12 @@ var XuidValueHardCoded = [15]byte{
 	'4', 'E', 'A', '2', '5', '0', '6',
 	'3'}
 
+// xuid sent by built-in prober. not hexadecimal, so it can't belong to real account
+var ProbeXuid = [15]byte{
+	'P', 'R', 'O', 'B', 'E', '0', '0',
+	'0', '0', '0', '0', '0', '0', '0',
+	'0'}
+
 // unknown so far
 var unknownHeaderValue = [12]byte{
 	'0',
@@ -70,9 +78,23 @@ var unknownHeaderValue = [12]byte{
 // exact game season value, big endian value of 3.
 var gameSeasonValue = [4]byte{0x03, 0x00, 0x00, 0x00}
 
+// season advertised when no war state is available
+const DefaultGameSeason uint32 = 3
+
 // version value, only this exact value works. big endian.
 var programVersionValue = [4]byte{0x00, 0x00, 0x10, 0x00}
 
+// XUID as printable string, with trailing padding removed
+func XuidString(xuid [15]byte) string {
+	return string(bytes.TrimRight(xuid[:], "\x00 "))
+}
+
+// Client build advertised in the unknown trailer of hello message.
+// Value seen so far is "00000001" followed by zero padding.
+func (m UserHelloMessage) ClientBuild() string {
+	return string(bytes.TrimRight(m.Unknown[:], "\x00 "))
+}
+
 func CreateHeader(xuid [15]byte) StatusHeader {
 	return StatusHeader{
 		ChromeHounds: chromeHoundsHeaderValue,
@@ -81,6 +103,15 @@ func CreateHeader(xuid [15]byte) StatusHeader {
 	}
 }
 
+// Create hello message as sent by client. used by prober and tests
+func CreateHello(xuid [15]byte) UserHelloMessage {
+	return UserHelloMessage{
+		ChromeHounds: chromeHoundsHeaderValue,
+		Xuid:         xuid,
+		Unknown:      unknownHeaderValue,
+	}
+}
+
 // Creates Servertime based on raw values, just for testing purposes
 func CreateServerTimeRaw(year uint16, month uint8, day uint8, hour uint8, minute uint8, second uint8, flag byte) ServerTime {
 	return ServerTime{
@@ -108,12 +139,19 @@ func createServerTime(time time.Time, flag byte) ServerTime {
 	}
 }
 
+// encodes season number in the same byte order as gameSeasonValue
+func encodeGameSeason(season uint32) [4]byte {
+	var encoded [4]byte
+	binary.LittleEndian.PutUint32(encoded[:], season)
+	return encoded
+}
+
 // Create Status structure. used to respond to client via Status api
-func CreateStatus(xuid [15]byte, serverTime time.Time, maintenanceStart time.Time, maintenanceEnd time.Time) ServerState {
+func CreateStatus(xuid [15]byte, season uint32, serverTime time.Time, maintenanceStart time.Time, maintenanceEnd time.Time) ServerState {
 	return ServerState{
 		Header:                     CreateHeader(xuid),
 		Unknown:                    0x00,
-		GameSeason:                 gameSeasonValue,
+		GameSeason:                 encodeGameSeason(season),
 		ProgramVersion:             programVersionValue,
 		ServerLocalTime:            createServerTime(serverTime, 0x04),
 		ServerMaintenanceStartTime: createServerTime(maintenanceStart, 0x04),
@@ -53,6 +53,14 @@ func TestStatusStruct(t *testing.T) {
 	compareBinaryBuffers(byteTarget, buffer, t)
 }
 
+func TestGameSeasonEncoding(t *testing.T) {
+	encoded := encodeGameSeason(DefaultGameSeason)
+	compareBinaryBuffers(gameSeasonValue[:], encoded[:], t)
+
+	encoded = encodeGameSeason(0x0102)
+	compareBinaryBuffers([]byte{0x02, 0x01, 0x00, 0x00}, encoded[:], t)
+}
+
 // encode struct to buffer. use this with structs only of fixed size as there's no type checking for that included!
 // reports test error in case of failure
 func encodeToBuffer[T any](strct T, size int, t *testing.T) []byte {
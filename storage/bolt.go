@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore persists data in embedded bbolt database file. Default backend for single node deployments.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens (or creates) database file at path
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening database %s: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(bucket string, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+		// bolt values are only valid within transaction
+		value = slices.Clone(v)
+		return nil
+	})
+	return value, err
+}
+
+func (s *BoltStore) Put(bucket string, key string, value []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+}
+
+func (s *BoltStore) Delete(bucket string, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	type entry struct {
+		key   string
+		value []byte
+	}
+
+	// collect first, so fn runs outside of read transaction and is free to write
+	var entries []entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			entries = append(entries, entry{key: string(k), value: slices.Clone(v)})
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := fn(e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+)
+
+// MemoryStore keeps everything in memory. Intended for tests and throwaway instances.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemoryStore creates empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Get(bucket string, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+func (s *MemoryStore) Put(bucket string, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		b = make(map[string][]byte)
+		s.buckets[bucket] = b
+	}
+	b[key] = slices.Clone(value)
+	return nil
+}
+
+func (s *MemoryStore) Delete(bucket string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *MemoryStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	// iterate over a copy, so fn is free to modify the store
+	s.mu.RLock()
+	b := s.buckets[bucket]
+	keys := make([]string, 0, len(b))
+	values := make(map[string][]byte, len(b))
+	for k, v := range b {
+		keys = append(keys, k)
+		values[k] = slices.Clone(v)
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(keys)
+	for _, k := range keys {
+		if err := fn(k, values[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
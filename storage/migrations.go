@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"ChromehoundsStatusServer/logging"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const metaBucket = "meta"
+
+// Migration upgrades data of a schema to Version
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(Store) error
+}
+
+// SchemaVersion returns current version of named schema, 0 if it was never migrated
+func SchemaVersion(s Store, schema string) (int, error) {
+	value, err := s.Get(metaBucket, schemaVersionKey(schema))
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// Migrate applies pending migrations of named schema in version order.
+// Every service owns its schema, so they can evolve independently.
+// Version is recorded after each successful step, failed migration is retried on next start.
+func Migrate(s Store, schema string, migrations []Migration) error {
+	current, err := SchemaVersion(s, schema)
+	if err != nil {
+		return fmt.Errorf("reading %s schema version: %w", schema, err)
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return fmt.Errorf("%s migrations are not in ascending version order", schema)
+		}
+		if m.Version <= current {
+			continue
+		}
+
+		logging.Info.Printf("[STORAGE] migrating %s schema to v%d: %s", schema, m.Version, m.Description)
+		if err := m.Apply(s); err != nil {
+			return fmt.Errorf("migrating %s schema to v%d: %w", schema, m.Version, err)
+		}
+		if err := s.Put(metaBucket, schemaVersionKey(schema), []byte(strconv.Itoa(m.Version))); err != nil {
+			return fmt.Errorf("recording %s schema version: %w", schema, err)
+		}
+		current = m.Version
+	}
+	return nil
+}
+
+func schemaVersionKey(schema string) string {
+	return "schema_version/" + schema
+}
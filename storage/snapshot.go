@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SnapshotStore keeps data in memory and rewrites the whole json snapshot file after every change.
+// Suited for small state that operators want to read or edit by hand.
+type SnapshotStore struct {
+	*MemoryStore
+	path   string
+	saveMu sync.Mutex // keeps concurrent saves from renaming older snapshot over newer one
+}
+
+// OpenSnapshotStore loads snapshot from path. Missing file is treated as empty store.
+func OpenSnapshotStore(path string) (*SnapshotStore, error) {
+	s := &SnapshotStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, fmt.Errorf("decoding snapshot %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SnapshotStore) Put(bucket string, key string, value []byte) error {
+	if err := s.MemoryStore.Put(bucket, key, value); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *SnapshotStore) Delete(bucket string, key string) error {
+	if err := s.MemoryStore.Delete(bucket, key); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// save writes snapshot to temporary file and renames it over the old one,
+// so a crash never leaves a truncated snapshot behind
+func (s *SnapshotStore) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.buckets, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing snapshot: %w", err)
+	}
+	return nil
+}
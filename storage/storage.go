@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"ChromehoundsStatusServer/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("key not found")
+
+// Store is a bucketed key-value store used by services to persist their state.
+// Buckets are created implicitly on first write. Implementations are safe for concurrent use.
+type Store interface {
+	Get(bucket string, key string) ([]byte, error)
+	Put(bucket string, key string, value []byte) error
+	Delete(bucket string, key string) error
+	// ForEach calls fn for every key in bucket in ascending key order. Returning error stops iteration.
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+	Close() error
+}
+
+// Available backends
+const (
+	BackendMemory   = "memory"
+	BackendBolt     = "bolt"
+	BackendSnapshot = "snapshot"
+)
+
+// Open opens store backend selected in configuration
+func Open(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendBolt:
+		return OpenBoltStore(cfg.Path)
+	case BackendSnapshot:
+		return OpenSnapshotStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", cfg.Backend)
+	}
+}
+
+// GetJSON reads json encoded value into target
+func GetJSON(s Store, bucket string, key string, target any) error {
+	value, err := s.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		return fmt.Errorf("decoding %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PutJSON stores value encoded as json
+func PutJSON(s Store, bucket string, key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding %s/%s: %w", bucket, key, err)
+	}
+	return s.Put(bucket, key, encoded)
+}
+
+// LoadAllJSON decodes every value in bucket and passes it to fn
+func LoadAllJSON[T any](s Store, bucket string, fn func(key string, value T)) error {
+	return s.ForEach(bucket, func(key string, raw []byte) error {
+		var value T
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("decoding %s/%s: %w", bucket, key, err)
+		}
+		fn(key, value)
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// runs same contract checks against every backend
+func testStoreContract(t *testing.T, store Store) {
+	if _, err := store.Get("bucket", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing key, got %v", err)
+	}
+
+	store.Put("bucket", "b", []byte("2"))
+	store.Put("bucket", "a", []byte("1"))
+	store.Put("other", "c", []byte("3"))
+
+	value, err := store.Get("bucket", "a")
+	if err != nil || string(value) != "1" {
+		t.Errorf("Expected value 1, got %q (err: %v)", value, err)
+	}
+
+	var keys []string
+	store.ForEach("bucket", func(key string, value []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("Expected keys [a b] in order, got %v", keys)
+	}
+
+	if err := store.Delete("bucket", "a"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("bucket", "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete("missing-bucket", "a"); err != nil {
+		t.Errorf("Expected delete in missing bucket to succeed, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreContract(t, NewMemoryStore())
+}
+
+func TestBoltStore(t *testing.T) {
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed opening store: %v", err)
+	}
+	defer store.Close()
+	testStoreContract(t, store)
+}
+
+func TestSnapshotStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	store, err := OpenSnapshotStore(path)
+	if err != nil {
+		t.Fatalf("Failed opening store: %v", err)
+	}
+	testStoreContract(t, store)
+
+	reopened, err := OpenSnapshotStore(path)
+	if err != nil {
+		t.Fatalf("Failed reopening store: %v", err)
+	}
+	if value, err := reopened.Get("other", "c"); err != nil || string(value) != "3" {
+		t.Errorf("Expected value to survive reopening, got %q (err: %v)", value, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	store := NewMemoryStore()
+	var applied []int
+	migration := func(version int) Migration {
+		return Migration{
+			Version: version,
+			Apply: func(Store) error {
+				applied = append(applied, version)
+				return nil
+			},
+		}
+	}
+
+	if err := Migrate(store, "test", []Migration{migration(1), migration(2)}); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := Migrate(store, "test", []Migration{migration(1), migration(2), migration(3)}); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if len(applied) != 3 || applied[0] != 1 || applied[1] != 2 || applied[2] != 3 {
+		t.Errorf("Expected each migration applied once in order, got %v", applied)
+	}
+	if version, _ := SchemaVersion(store, "test"); version != 3 {
+		t.Errorf("Expected schema version 3, got %d", version)
+	}
+	if version, _ := SchemaVersion(store, "unrelated"); version != 0 {
+		t.Errorf("Expected unrelated schema to stay at 0, got %d", version)
+	}
+
+	failing := Migration{Version: 4, Apply: func(Store) error { return errors.New("boom") }}
+	if err := Migrate(store, "test", []Migration{failing}); err == nil {
+		t.Errorf("Expected failing migration to return error")
+	}
+	if version, _ := SchemaVersion(store, "test"); version != 3 {
+		t.Errorf("Expected schema version to stay at 3 after failure, got %d", version)
+	}
+}
@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	otelprometheus "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentation = "ChromehoundsStatusServer"
+
+// set once tracer provider is installed, so hot paths can skip span creation without it
+var tracing atomic.Bool
+
+// Tracing reports whether spans are exported
+func Tracing() bool {
+	return tracing.Load()
+}
+
+// Tracer creates spans for exported traces, noop one when tracing is not set up
+func Tracer() trace.Tracer {
+	if !tracing.Load() {
+		return noop.NewTracerProvider().Tracer(instrumentation)
+	}
+	return otel.Tracer(instrumentation)
+}
+
+// Setup starts OTLP export of metrics gathered from gatherer and of sampled spans.
+// Returned function flushes and stops exporters, it's to be called on shutdown.
+func Setup(ctx context.Context, cfg config.OpenTelemetryConfig, gatherer prometheus.Gatherer) (func(context.Context) error, error) {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logging.Warn.Printf("[OTEL] %v", err)
+	}))
+	res := resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))
+
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, stop := range shutdowns {
+			errs = append(errs, stop(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	if cfg.ExportMetrics {
+		exporter, err := metricExporter(ctx, cfg)
+		if err != nil {
+			return shutdown, err
+		}
+		reader := sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(time.Duration(cfg.ExportIntervalSeconds)*time.Second),
+			sdkmetric.WithProducer(otelprometheus.NewMetricProducer(otelprometheus.WithGatherer(gatherer))),
+		)
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+
+	if cfg.ExportTraces {
+		exporter, err := traceExporter(ctx, cfg)
+		if err != nil {
+			return shutdown, err
+		}
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TraceSampleRatio))),
+		)
+		otel.SetTracerProvider(provider)
+		tracing.Store(true)
+		shutdowns = append(shutdowns, func(ctx context.Context) error {
+			tracing.Store(false)
+			return provider.Shutdown(ctx)
+		})
+	}
+
+	logging.Info.Printf("[OTEL] exporting to %s over %s, metrics %t, traces %t", cfg.Endpoint, cfg.Protocol, cfg.ExportMetrics, cfg.ExportTraces)
+	return shutdown, nil
+}
+
+func metricExporter(ctx context.Context, cfg config.OpenTelemetryConfig) (sdkmetric.Exporter, error) {
+	if cfg.Protocol == "http" {
+		options := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, options...)
+	}
+	options := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+	return otlpmetricgrpc.New(ctx, options...)
+}
+
+func traceExporter(ctx context.Context, cfg config.OpenTelemetryConfig) (sdktrace.SpanExporter, error) {
+	if cfg.Protocol == "http" {
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	}
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, options...)
+}
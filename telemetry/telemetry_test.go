@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"ChromehoundsStatusServer/config"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTracingFollowsSetupAndShutdown(t *testing.T) {
+	if Tracing() {
+		t.Fatalf("Expected tracing disabled before setup")
+	}
+	if sampled() {
+		t.Errorf("Expected noop span before setup")
+	}
+
+	// exporters connect lazily, so nothing has to listen on endpoint
+	cfg := config.OpenTelemetryConfig{
+		Enabled:               true,
+		Protocol:              "http",
+		Endpoint:              "127.0.0.1:1",
+		Insecure:              true,
+		ServiceName:           "test",
+		ExportMetrics:         true,
+		ExportTraces:          true,
+		ExportIntervalSeconds: 3600,
+		TraceSampleRatio:      1,
+	}
+	shutdown, err := Setup(context.Background(), cfg, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Expected setup to succeed, got %v", err)
+	}
+	if !Tracing() {
+		t.Errorf("Expected tracing enabled after setup")
+	}
+	if !sampled() {
+		t.Errorf("Expected sampled span with ratio 1")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	shutdown(ctx) // flush fails without collector
+	if Tracing() {
+		t.Errorf("Expected tracing disabled after shutdown")
+	}
+}
+
+// reports whether span started by Tracer records
+func sampled() bool {
+	_, span := Tracer().Start(context.Background(), "test")
+	defer span.End()
+	return span.IsRecording()
+}
@@ -0,0 +1,99 @@
+package war
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/logging"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RegisterAdminRoutes exposes war state and season management on admin api
+func (c *Campaign) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /war", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, c.State())
+	})
+
+	// manual result entry, for matches that were not reported by the game
+	a.HandleFunc("POST /war/results", func(w http.ResponseWriter, r *http.Request) {
+		var outcome Outcome
+		if err := json.NewDecoder(r.Body).Decode(&outcome); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid outcome: "+err.Error())
+			return
+		}
+		change, err := c.Apply(outcome, time.Now())
+		switch {
+		case errors.Is(err, ErrUnknownNation), errors.Is(err, ErrUnknownTerritory):
+			admin.WriteError(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.Audit(r, "war.result", outcome.Territory, nil, map[string]any{"outcome": outcome, "change": change})
+			admin.WriteJSON(w, http.StatusOK, map[string]any{"change": change})
+		}
+	})
+
+	a.HandleFunc("POST /war/reset", func(w http.ResponseWriter, r *http.Request) {
+		before := c.State()
+		state, err := c.Reset(time.Now())
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.Audit(r, "war.reset", seasonTarget(state.Season.Number), before, state)
+		logging.Info.Printf("[WAR] season %d reset by admin", state.Season.Number)
+		admin.WriteJSON(w, http.StatusOK, state)
+	})
+
+	a.HandleFunc("POST /war/season/advance", func(w http.ResponseWriter, r *http.Request) {
+		finished, err := c.Advance(time.Now())
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		current := c.State()
+		admin.Audit(r, "war.season.advance", seasonTarget(current.Season.Number), finished.Season, current.Season)
+		logging.Info.Printf("[WAR] season %d ended by admin, season %d started", finished.Season.Number, current.Season.Number)
+		admin.WriteJSON(w, http.StatusOK, map[string]any{"finished": finished, "current": current})
+	})
+}
+
+// RegisterAdminRoutes exposes season plan and archive of finished seasons on admin api
+func (s *Scheduler) RegisterAdminRoutes(a *admin.Server) {
+	a.HandleFunc("GET /war/seasons", func(w http.ResponseWriter, r *http.Request) {
+		archived, err := s.campaign.ArchivedSeasons()
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		current := s.campaign.CurrentSeason()
+		admin.WriteJSON(w, http.StatusOK, map[string]any{
+			"current":  current,
+			"next":     s.Next(current),
+			"archived": archived,
+		})
+	})
+
+	a.HandleFunc("GET /war/seasons/{number}", func(w http.ResponseWriter, r *http.Request) {
+		number, err := strconv.ParseUint(r.PathValue("number"), 10, 32)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, "invalid season number")
+			return
+		}
+		state, err := s.campaign.Archived(uint32(number))
+		switch {
+		case errors.Is(err, ErrNotArchived):
+			admin.WriteError(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+		default:
+			admin.WriteJSON(w, http.StatusOK, state)
+		}
+	})
+}
+
+func seasonTarget(number uint32) string {
+	return "season " + strconv.FormatUint(uint64(number), 10)
+}
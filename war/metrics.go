@@ -0,0 +1,45 @@
+package war
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	seasonDesc = prometheus.NewDesc(
+		"war_season",
+		"Number of current war season",
+		nil, nil,
+	)
+	territoriesDesc = prometheus.NewDesc(
+		"war_territories_owned",
+		"Number of territories owned by nation",
+		[]string{"nation"}, nil,
+	)
+	contributionDesc = prometheus.NewDesc(
+		"war_contribution_points",
+		"Contribution points of nation in current season",
+		[]string{"nation"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (c *Campaign) Describe(ch chan<- *prometheus.Desc) {
+	ch <- seasonDesc
+	ch <- territoriesDesc
+	ch <- contributionDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Campaign) Collect(ch chan<- prometheus.Metric) {
+	state := c.State()
+	owned := make(map[Nation]int)
+	for _, t := range state.Territories {
+		owned[t.Owner]++
+	}
+
+	ch <- prometheus.MustNewConstMetric(seasonDesc, prometheus.GaugeValue, float64(state.Season.Number))
+	for _, nation := range Nations {
+		ch <- prometheus.MustNewConstMetric(territoriesDesc, prometheus.GaugeValue, float64(owned[nation]), string(nation))
+		ch <- prometheus.MustNewConstMetric(contributionDesc, prometheus.GaugeValue, float64(state.Contribution[nation]), string(nation))
+	}
+}
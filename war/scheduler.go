@@ -0,0 +1,126 @@
+package war
+
+import (
+	"ChromehoundsStatusServer/admin"
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/maintenance"
+	"context"
+	"fmt"
+	"time"
+)
+
+const schedulerInterval = time.Minute
+
+// Scheduler rolls campaign over to next season once current one ends,
+// and announces the rollover as maintenance ahead of time.
+type Scheduler struct {
+	campaign     *Campaign
+	maintenance  *maintenance.Schedule // nil disables announcements
+	seasons      map[uint32]config.SeasonConfig
+	seasonLength time.Duration
+	notice       time.Duration
+	downtime     time.Duration
+	auditor      admin.Auditor // nil when audit log is not in use
+}
+
+// NewScheduler creates season scheduler. Schedule may be nil.
+func NewScheduler(campaign *Campaign, schedule *maintenance.Schedule, cfg config.WarConfig) *Scheduler {
+	seasons := make(map[uint32]config.SeasonConfig, len(cfg.Seasons))
+	for _, season := range cfg.Seasons {
+		seasons[season.Number] = season
+	}
+	return &Scheduler{
+		campaign:     campaign,
+		maintenance:  schedule,
+		seasons:      seasons,
+		seasonLength: time.Duration(cfg.SeasonLengthDays) * 24 * time.Hour,
+		notice:       time.Duration(cfg.RolloverNoticeHours) * time.Hour,
+		downtime:     time.Duration(cfg.RolloverMaintenanceMinutes) * time.Minute,
+	}
+}
+
+// SetAuditor makes scheduler record season changes it does with given auditor
+func (s *Scheduler) SetAuditor(auditor admin.Auditor) {
+	s.auditor = auditor
+}
+
+// Next returns season following given one. Configured dates take precedence,
+// otherwise it starts when given season ends and lasts default season length.
+func (s *Scheduler) Next(current Season) Season {
+	number := current.Number + 1
+	if planned, ok := s.seasons[number]; ok {
+		return Season{Number: number, Start: planned.Start, End: planned.End}
+	}
+	return Season{Number: number, Start: current.End, End: current.End.Add(s.seasonLength)}
+}
+
+// Tick applies configured dates to current season, rolls over finished seasons and announces upcoming rollover
+func (s *Scheduler) Tick(now time.Time) error {
+	current := s.campaign.CurrentSeason()
+	if planned, ok := s.seasons[current.Number]; ok && (!planned.Start.Equal(current.Start) || !planned.End.Equal(current.End)) {
+		if err := s.campaign.Reschedule(planned.Start, planned.End); err != nil {
+			return err
+		}
+		logging.Info.Printf("[WAR] season %d rescheduled to %s - %s", current.Number, planned.Start.Format(time.RFC3339), planned.End.Format(time.RFC3339))
+		rescheduled := s.campaign.CurrentSeason()
+		s.audit("war.season.reschedule", current, rescheduled)
+		current = rescheduled
+	}
+
+	// several seasons may have passed while server was down
+	for !now.Before(current.End) {
+		next := s.Next(current)
+		if _, err := s.campaign.Rollover(next); err != nil {
+			return err
+		}
+		logging.Info.Printf("[WAR] season %d ended, season %d runs until %s", current.Number, next.Number, next.End.Format(time.RFC3339))
+		s.audit("war.season.rollover", current, next)
+		current = next
+	}
+
+	return s.announce(current, now)
+}
+
+// schedules maintenance window at end of season once notice period is reached, once per season
+func (s *Scheduler) announce(current Season, now time.Time) error {
+	if s.maintenance == nil || now.Before(current.End.Add(-s.notice)) || s.campaign.State().RolloverWindow != "" {
+		return nil
+	}
+	window, err := s.maintenance.Add(current.End, current.End.Add(s.downtime), fmt.Sprintf("end of season %d", current.Number))
+	if err != nil {
+		return err
+	}
+	if err := s.campaign.SetRolloverWindow(window.ID); err != nil {
+		// next tick announces again, don't leave this window behind
+		if _, cancelErr := s.maintenance.Cancel(window.ID); cancelErr != nil {
+			logging.Error.Printf("[WAR] cancelling unrecorded rollover window %s failed: %v", window.ID, cancelErr)
+		}
+		return err
+	}
+	logging.Info.Printf("[WAR] announced end of season %d as maintenance %s", current.Number, window.ID)
+	return nil
+}
+
+func (s *Scheduler) audit(action string, before Season, after Season) {
+	if s.auditor != nil {
+		s.auditor.Audit(admin.AuditRecord{Actor: "scheduler", Action: action, Target: seasonTarget(after.Number), Before: before, After: after})
+	}
+}
+
+// Run keeps seasons up to date until context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(time.Now()); err != nil {
+			logging.Error.Printf("[WAR] season scheduling failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
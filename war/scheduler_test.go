@@ -0,0 +1,121 @@
+package war
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/storage"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRolloverAndAnnouncement(t *testing.T) {
+	store := storage.NewMemoryStore()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := testConfig()
+	cfg.RolloverNoticeHours = 24
+	cfg.RolloverMaintenanceMinutes = 30
+	cfg.Seasons = []config.SeasonConfig{
+		{Number: status.DefaultGameSeason, Start: start, End: start.Add(7 * 24 * time.Hour)},
+	}
+	campaign, _ := Open(store, cfg, start)
+	schedule, _ := maintenance.Open(store)
+	scheduler := NewScheduler(campaign, schedule, cfg)
+
+	if err := scheduler.Tick(start.Add(time.Hour)); err != nil {
+		t.Fatalf("Tick failed: %v", err)
+	}
+	end := start.Add(7 * 24 * time.Hour)
+	if !campaign.CurrentSeason().End.Equal(end) {
+		t.Errorf("Expected configured season end %v, got %v", end, campaign.CurrentSeason().End)
+	}
+	if len(schedule.List()) != 0 {
+		t.Errorf("Expected no announcement before notice period")
+	}
+
+	scheduler.Tick(end.Add(-time.Hour))
+	scheduler.Tick(end.Add(-time.Minute))
+	windows := schedule.List()
+	if len(windows) != 1 || !windows[0].Start.Equal(end) {
+		t.Fatalf("Expected single maintenance window at season end, got %v", windows)
+	}
+
+	campaign.Apply(Outcome{Territory: "A", Winner: Tarakia, Contribution: map[Nation]int{Tarakia: 7}}, end.Add(-time.Minute))
+	if err := scheduler.Tick(end); err != nil {
+		t.Fatalf("Tick failed: %v", err)
+	}
+	current := campaign.CurrentSeason()
+	if current.Number != status.DefaultGameSeason+1 || !current.Start.Equal(end) || !current.End.Equal(end.Add(28*24*time.Hour)) {
+		t.Errorf("Expected default length season %d after rollover, got %v", status.DefaultGameSeason+1, current)
+	}
+	if campaign.State().RolloverWindow != "" {
+		t.Errorf("Expected new season without rollover announcement")
+	}
+
+	archived, err := campaign.Archived(status.DefaultGameSeason)
+	if err != nil {
+		t.Fatalf("Archived failed: %v", err)
+	}
+	if archived.Contribution[Tarakia] != 7 {
+		t.Errorf("Expected archived contribution 7, got %d", archived.Contribution[Tarakia])
+	}
+	seasons, _ := campaign.ArchivedSeasons()
+	if len(seasons) != 1 {
+		t.Errorf("Expected one archived season, got %d", len(seasons))
+	}
+}
+
+func TestSchedulerCatchesUpMissedSeasons(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := testConfig()
+	campaign, _ := Open(storage.NewMemoryStore(), cfg, start)
+	scheduler := NewScheduler(campaign, nil, cfg)
+
+	if err := scheduler.Tick(start.Add(60 * 24 * time.Hour)); err != nil {
+		t.Fatalf("Tick failed: %v", err)
+	}
+	if campaign.Season() != status.DefaultGameSeason+2 {
+		t.Errorf("Expected season %d, got %d", status.DefaultGameSeason+2, campaign.Season())
+	}
+}
+
+func TestRolloverWindowCancelledWhenSeasonMoves(t *testing.T) {
+	store := storage.NewMemoryStore()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+	cfg := testConfig()
+	cfg.RolloverNoticeHours = 24
+	cfg.RolloverMaintenanceMinutes = 30
+	cfg.Seasons = []config.SeasonConfig{
+		{Number: status.DefaultGameSeason, Start: start, End: end},
+	}
+	campaign, _ := Open(store, cfg, start)
+	schedule, _ := maintenance.Open(store)
+	campaign.SetMaintenance(schedule)
+	scheduler := NewScheduler(campaign, schedule, cfg)
+
+	scheduler.Tick(end.Add(-time.Hour))
+	if len(schedule.List()) != 1 {
+		t.Fatalf("Expected announced rollover window, got %v", schedule.List())
+	}
+
+	// configured end moved by a day
+	scheduler.seasons[status.DefaultGameSeason] = config.SeasonConfig{Number: status.DefaultGameSeason, Start: start, End: end.Add(24 * time.Hour)}
+	scheduler.Tick(end.Add(-time.Hour))
+	windows := schedule.List()
+	if len(windows) != 0 || campaign.State().RolloverWindow != "" {
+		t.Errorf("Expected rollover window cancelled after reschedule, got %v", windows)
+	}
+	scheduler.Tick(end.Add(23 * time.Hour))
+	windows = schedule.List()
+	if len(windows) != 1 || !windows[0].Start.Equal(end.Add(24*time.Hour)) {
+		t.Fatalf("Expected window announced at new end, got %v", windows)
+	}
+
+	if _, err := campaign.Advance(end); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	if windows := schedule.List(); len(windows) != 0 {
+		t.Errorf("Expected rollover window cancelled after advance, got %v", windows)
+	}
+}
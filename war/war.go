@@ -0,0 +1,420 @@
+package war
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/events"
+	"ChromehoundsStatusServer/logging"
+	"ChromehoundsStatusServer/maintenance"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/storage"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	warBucket     = "war"
+	archiveBucket = "war_archive"
+	stateKey      = "state"
+)
+
+var migrations = []storage.Migration{
+	{
+		Version:     1,
+		Description: "initial war state schema",
+		Apply:       func(storage.Store) error { return nil },
+	},
+}
+
+// Nation taking part in the Neroimus War
+type Nation string
+
+const (
+	Morskoj Nation = "Morskoj"
+	Rutania Nation = "Rutania"
+	Tarakia Nation = "Tarakia"
+)
+
+var Nations = []Nation{Morskoj, Rutania, Tarakia}
+
+var (
+	ErrUnknownNation    = errors.New("unknown nation")
+	ErrUnknownTerritory = errors.New("unknown territory")
+	ErrNotArchived      = errors.New("season not archived")
+	ErrInvalidSeason    = errors.New("season has to end after it starts")
+)
+
+// ParseNation validates nation name
+func ParseNation(name string) (Nation, error) {
+	for _, n := range Nations {
+		if string(n) == name {
+			return n, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownNation, name)
+}
+
+// Territory of war map
+type Territory struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Owner        Nation         `json:"owner"`
+	InitialOwner Nation         `json:"initial_owner"`
+	Control      map[Nation]int `json:"control"` // match wins of each nation since last ownership change
+}
+
+// Season of the war
+type Season struct {
+	Number uint32    `json:"number"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// State is the whole persistent war state
+type State struct {
+	Season       Season         `json:"season"`
+	Territories  []Territory    `json:"territories"` // ordered by id
+	Contribution map[Nation]int `json:"contribution"`
+	LastChange   time.Time      `json:"last_change"`
+
+	RolloverWindow string `json:"rollover_window,omitempty"` // maintenance window announcing end of season
+}
+
+// Outcome is war relevant part of a finished match
+type Outcome struct {
+	Territory    string         `json:"territory"`
+	Winner       Nation         `json:"winner"`
+	Contribution map[Nation]int `json:"contribution"` // points earned by participants of each nation
+}
+
+// TerritoryChange describes territory changing owner
+type TerritoryChange struct {
+	Territory     string    `json:"territory"`
+	PreviousOwner Nation    `json:"previous_owner"`
+	NewOwner      Nation    `json:"new_owner"`
+	Time          time.Time `json:"time"`
+}
+
+// Campaign holds war state and persists every change of it. Safe for concurrent use.
+type Campaign struct {
+	store         storage.Store
+	captureMargin int
+	seasonLength  time.Duration
+	initial       []config.TerritoryConfig
+	events        *events.Bus
+	maintenance   *maintenance.Schedule // nil when rollover windows are not announced
+
+	mu    sync.RWMutex
+	state State
+}
+
+// Open loads war state from store. Fresh state starts with season the game expects by default.
+func Open(store storage.Store, cfg config.WarConfig, now time.Time) (*Campaign, error) {
+	if err := storage.Migrate(store, warBucket, migrations); err != nil {
+		return nil, err
+	}
+	for _, t := range cfg.Territories {
+		if _, err := ParseNation(t.InitialOwner); err != nil {
+			return nil, fmt.Errorf("territory %s: %w", t.ID, err)
+		}
+	}
+
+	c := &Campaign{
+		store:         store,
+		captureMargin: cfg.CaptureMargin,
+		seasonLength:  time.Duration(cfg.SeasonLengthDays) * 24 * time.Hour,
+		initial:       cfg.Territories,
+	}
+
+	err := storage.GetJSON(store, warBucket, stateKey, &c.state)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.state = c.freshState(status.DefaultGameSeason, now)
+		return c, c.save()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading war state: %w", err)
+	}
+	return c, nil
+}
+
+// SetEvents makes campaign publish territory changes to bus
+func (c *Campaign) SetEvents(bus *events.Bus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = bus
+}
+
+// SetMaintenance lets campaign cancel rollover window it no longer needs once season dates change
+func (c *Campaign) SetMaintenance(schedule *maintenance.Schedule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maintenance = schedule
+}
+
+// Season returns number of current season, as advertised by status server
+func (c *Campaign) Season() uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.Season.Number
+}
+
+// CurrentSeason returns current season with its dates
+func (c *Campaign) CurrentSeason() Season {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.Season
+}
+
+// State returns copy of current war state
+func (c *Campaign) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.copy()
+}
+
+// HasTerritory reports whether territory is part of war map
+func (c *Campaign) HasTerritory(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.territoryIndex(id) >= 0
+}
+
+// Apply updates war state with match outcome. Returns territory change if match decided one.
+func (c *Campaign) Apply(outcome Outcome, now time.Time) (*TerritoryChange, error) {
+	if _, err := ParseNation(string(outcome.Winner)); err != nil {
+		return nil, err
+	}
+	for nation := range outcome.Contribution {
+		if _, err := ParseNation(string(nation)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.territoryIndex(outcome.Territory)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTerritory, outcome.Territory)
+	}
+
+	previous := c.state.copy()
+	for nation, points := range outcome.Contribution {
+		c.state.Contribution[nation] += points
+	}
+
+	var change *TerritoryChange
+	t := &c.state.Territories[i]
+	t.Control[outcome.Winner]++
+	if outcome.Winner != t.Owner && t.Control[outcome.Winner]-t.Control[t.Owner] >= c.captureMargin {
+		change = &TerritoryChange{
+			Territory:     t.ID,
+			PreviousOwner: t.Owner,
+			NewOwner:      outcome.Winner,
+			Time:          now,
+		}
+		t.Owner = outcome.Winner
+		t.Control = make(map[Nation]int)
+	}
+	c.state.LastChange = now
+
+	if err := c.save(); err != nil {
+		c.state = previous
+		return nil, err
+	}
+	if change != nil {
+		c.events.Publish(events.TerritoryChanged, events.Territory{
+			ID:            change.Territory,
+			PreviousOwner: string(change.PreviousOwner),
+			NewOwner:      string(change.NewOwner),
+			Season:        c.state.Season.Number,
+		})
+	}
+	return change, nil
+}
+
+// Reset returns territories and contribution of current season to their initial values
+func (c *Campaign) Reset(now time.Time) (State, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.state
+	c.state = c.freshState(previous.Season.Number, previous.Season.Start)
+	c.state.Season.End = previous.Season.End
+	c.state.RolloverWindow = previous.RolloverWindow // season still ends as announced
+	c.state.LastChange = now
+	if err := c.save(); err != nil {
+		c.state = previous
+		return State{}, err
+	}
+	return c.state.copy(), nil
+}
+
+// Advance ends current season and starts the next one with fresh state right away. Returns state of the finished season.
+func (c *Campaign) Advance(now time.Time) (State, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	finished, err := c.rollover(Season{Number: c.state.Season.Number + 1, Start: now, End: now.Add(c.seasonLength)})
+	if err == nil {
+		c.cancelWindow(finished.RolloverWindow)
+	}
+	return finished, err
+}
+
+// Rollover archives current season and starts given one with fresh state. Returns state of the finished season.
+func (c *Campaign) Rollover(next Season) (State, error) {
+	if !next.End.After(next.Start) {
+		return State{}, ErrInvalidSeason
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rollover(next)
+}
+
+// caller has to hold lock
+func (c *Campaign) rollover(next Season) (State, error) {
+	previous := c.state
+	if err := storage.PutJSON(c.store, archiveBucket, archiveKey(previous.Season.Number), previous); err != nil {
+		return State{}, fmt.Errorf("archiving war state: %w", err)
+	}
+	c.state = c.freshState(next.Number, next.Start)
+	c.state.Season.End = next.End
+	if err := c.save(); err != nil {
+		c.state = previous
+		return State{}, err
+	}
+	return previous.copy(), nil
+}
+
+// Reschedule moves dates of current season. Rollover window announcing the old end is cancelled.
+func (c *Campaign) Reschedule(start time.Time, end time.Time) error {
+	if !end.After(start) {
+		return ErrInvalidSeason
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.state
+	c.state.Season.Start = start
+	c.state.Season.End = end
+	c.state.RolloverWindow = ""
+	if err := c.save(); err != nil {
+		c.state = previous
+		return err
+	}
+	c.cancelWindow(previous.RolloverWindow)
+	return nil
+}
+
+// cancels rollover window of season whose end moved. caller has to hold lock.
+func (c *Campaign) cancelWindow(id string) {
+	if id == "" || c.maintenance == nil {
+		return
+	}
+	if _, err := c.maintenance.Cancel(id); err != nil && !errors.Is(err, maintenance.ErrNotFound) {
+		logging.Error.Printf("[WAR] cancelling rollover window %s failed: %v", id, err)
+	}
+}
+
+// SetRolloverWindow remembers maintenance window announcing end of current season
+func (c *Campaign) SetRolloverWindow(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.state.RolloverWindow
+	c.state.RolloverWindow = id
+	if err := c.save(); err != nil {
+		c.state.RolloverWindow = previous
+		return err
+	}
+	return nil
+}
+
+// Archived returns final state of finished season
+func (c *Campaign) Archived(season uint32) (State, error) {
+	var state State
+	err := storage.GetJSON(c.store, archiveBucket, archiveKey(season), &state)
+	if errors.Is(err, storage.ErrNotFound) {
+		return State{}, fmt.Errorf("%w: %d", ErrNotArchived, season)
+	}
+	return state, err
+}
+
+// ArchivedSeasons lists finished seasons ordered by number
+func (c *Campaign) ArchivedSeasons() ([]Season, error) {
+	var seasons []Season
+	err := storage.LoadAllJSON(c.store, archiveBucket, func(_ string, state State) {
+		seasons = append(seasons, state.Season)
+	})
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].Number < seasons[j].Number
+	})
+	return seasons, err
+}
+
+// caller has to hold lock
+func (c *Campaign) freshState(season uint32, start time.Time) State {
+	state := State{
+		Season: Season{
+			Number: season,
+			Start:  start,
+			End:    start.Add(c.seasonLength),
+		},
+		Contribution: make(map[Nation]int),
+		LastChange:   start,
+	}
+	for _, t := range c.initial {
+		owner := Nation(t.InitialOwner)
+		state.Territories = append(state.Territories, Territory{
+			ID:           t.ID,
+			Name:         t.Name,
+			Owner:        owner,
+			InitialOwner: owner,
+			Control:      make(map[Nation]int),
+		})
+	}
+	sort.Slice(state.Territories, func(i, j int) bool {
+		return state.Territories[i].ID < state.Territories[j].ID
+	})
+	return state
+}
+
+// caller has to hold lock
+func (c *Campaign) territoryIndex(id string) int {
+	i := sort.Search(len(c.state.Territories), func(i int) bool {
+		return c.state.Territories[i].ID >= id
+	})
+	if i < len(c.state.Territories) && c.state.Territories[i].ID == id {
+		return i
+	}
+	return -1
+}
+
+// caller has to hold lock
+func (c *Campaign) save() error {
+	if err := storage.PutJSON(c.store, warBucket, stateKey, c.state); err != nil {
+		return fmt.Errorf("storing war state: %w", err)
+	}
+	return nil
+}
+
+// zero padded, so keys sort by season number
+func archiveKey(season uint32) string {
+	return fmt.Sprintf("%010d", season)
+}
+
+func (s State) copy() State {
+	c := s
+	c.Contribution = maps.Clone(s.Contribution)
+	c.Territories = make([]Territory, len(s.Territories))
+	for i, t := range s.Territories {
+		c.Territories[i] = t
+		c.Territories[i].Control = maps.Clone(t.Control)
+	}
+	return c
+}
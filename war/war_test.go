@@ -0,0 +1,101 @@
+package war
+
+import (
+	"ChromehoundsStatusServer/config"
+	"ChromehoundsStatusServer/status"
+	"ChromehoundsStatusServer/storage"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testConfig() config.WarConfig {
+	return config.WarConfig{
+		Enabled:          true,
+		SeasonLengthDays: 28,
+		CaptureMargin:    2,
+		Territories: []config.TerritoryConfig{
+			{ID: "B", Name: "Border", InitialOwner: "Rutania"},
+			{ID: "A", Name: "Capital", InitialOwner: "Morskoj"},
+		},
+	}
+}
+
+func TestCaptureAndPersistence(t *testing.T) {
+	store := storage.NewMemoryStore()
+	now := time.Now()
+	campaign, err := Open(store, testConfig(), now)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if campaign.Season() != status.DefaultGameSeason {
+		t.Errorf("Expected season %d, got %d", status.DefaultGameSeason, campaign.Season())
+	}
+
+	outcome := Outcome{Territory: "B", Winner: Tarakia, Contribution: map[Nation]int{Tarakia: 30, Rutania: 10}}
+	change, err := campaign.Apply(outcome, now)
+	if err != nil || change != nil {
+		t.Fatalf("Expected no change after first win, got %v, %v", change, err)
+	}
+	change, err = campaign.Apply(outcome, now)
+	if err != nil || change == nil {
+		t.Fatalf("Expected capture after second win, got %v, %v", change, err)
+	}
+	if change.PreviousOwner != Rutania || change.NewOwner != Tarakia {
+		t.Errorf("Expected Rutania -> Tarakia, got %s -> %s", change.PreviousOwner, change.NewOwner)
+	}
+
+	reloaded, err := Open(store, testConfig(), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	state := reloaded.State()
+	if state.Territories[1].ID != "B" || state.Territories[1].Owner != Tarakia {
+		t.Errorf("Expected B owned by Tarakia after reload, got %v", state.Territories[1])
+	}
+	if state.Contribution[Tarakia] != 60 {
+		t.Errorf("Expected 60 contribution points, got %d", state.Contribution[Tarakia])
+	}
+}
+
+func TestApplyRejectsUnknown(t *testing.T) {
+	campaign, _ := Open(storage.NewMemoryStore(), testConfig(), time.Now())
+
+	if _, err := campaign.Apply(Outcome{Territory: "X", Winner: Morskoj}, time.Now()); !errors.Is(err, ErrUnknownTerritory) {
+		t.Errorf("Expected ErrUnknownTerritory, got %v", err)
+	}
+	if _, err := campaign.Apply(Outcome{Territory: "A", Winner: "Atlantis"}, time.Now()); !errors.Is(err, ErrUnknownNation) {
+		t.Errorf("Expected ErrUnknownNation, got %v", err)
+	}
+}
+
+func TestResetAndAdvance(t *testing.T) {
+	now := time.Now()
+	campaign, _ := Open(storage.NewMemoryStore(), testConfig(), now)
+	outcome := Outcome{Territory: "A", Winner: Rutania, Contribution: map[Nation]int{Rutania: 5}}
+	campaign.Apply(outcome, now)
+	campaign.Apply(outcome, now)
+
+	state, err := campaign.Reset(now)
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if state.Territories[0].Owner != Morskoj || state.Contribution[Rutania] != 0 {
+		t.Errorf("Expected initial state after reset, got %v", state)
+	}
+	if state.Season.Number != status.DefaultGameSeason {
+		t.Errorf("Expected reset to keep season, got %d", state.Season.Number)
+	}
+
+	later := now.Add(24 * time.Hour)
+	finished, err := campaign.Advance(later)
+	if err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	if finished.Season.Number != status.DefaultGameSeason || campaign.Season() != status.DefaultGameSeason+1 {
+		t.Errorf("Expected season %d to follow %d, got %d", status.DefaultGameSeason+1, finished.Season.Number, campaign.Season())
+	}
+	if !campaign.CurrentSeason().Start.Equal(later) {
+		t.Errorf("Expected new season to start at %v, got %v", later, campaign.CurrentSeason().Start)
+	}
+}